@@ -0,0 +1,114 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"errors"
+)
+
+// gzipFile is an active log file written as a gzip stream, see
+// CompressActive.  Appending to an existing file starts a new gzip member,
+// which gzip readers decompress as part of the same stream.
+type gzipFile struct {
+	File
+
+	gz  *gzip.Writer
+	out *countingWriter
+
+	// reported is the part of out.n already returned by emitted.
+	reported int64
+}
+
+func newGzipFile(f File) *gzipFile {
+	out := &countingWriter{w: f}
+
+	return &gzipFile{File: f, gz: gzip.NewWriter(out), out: out}
+}
+
+func (z *gzipFile) Write(p []byte) (int, error) {
+	return z.gz.Write(p)
+}
+
+// Sync flushes the pending compressed data before committing the file, so
+// that everything written so far can be decompressed.
+func (z *gzipFile) Sync() error {
+	if err := z.gz.Flush(); err != nil {
+		return err
+	}
+
+	return z.File.Sync()
+}
+
+func (z *gzipFile) Close() error {
+	err := z.gz.Close()
+
+	if errClose := z.File.Close(); err == nil {
+		err = errClose
+	}
+
+	return err
+}
+
+// emitted returns the number of compressed bytes written to the file since
+// the last call.
+func (z *gzipFile) emitted() int64 {
+	n := z.out.n - z.reported
+	z.reported = z.out.n
+
+	return n
+}
+
+// activeSuffix returns the suffix of the active file and its backups added by
+// CompressActive.
+func (l *Logger) activeSuffix() string {
+	if l.CompressActive {
+		return compressSuffix
+	}
+
+	return ""
+}
+
+// openedFile wraps a newly opened active file for CompressActive or
+// Transformers.
+func (l *Logger) openedFile(f File) File {
+	if l.CompressActive {
+		return newGzipFile(f)
+	}
+
+	if len(l.Transformers) > 0 {
+		return newTransformedFile(f, l.Transformers, &l.hooks)
+	}
+
+	return f
+}
+
+// grown returns by how much the active file grew by writing n bytes to it.
+// With CompressActive, that's the compressed data the writes pushed out, as
+// the compressor holds back the rest until it has enough or is flushed.  It
+// must be called with l.mu held.
+func (l *Logger) grown(n int) int64 {
+	if z, ok := l.file.(*gzipFile); ok {
+		return z.emitted()
+	}
+
+	return int64(n)
+}
+
+// checkCompressActive reports an error if CompressActive is combined with
+// settings which need the backups to be finalized by the mill or to read the
+// active file back.
+func (l *Logger) checkCompressActive() error {
+	if !l.CompressActive {
+		return nil
+	}
+
+	switch {
+	case l.CompressionFormat != "" && l.CompressionFormat != CompressionGzip:
+		return errors.New("CompressActive requires CompressionFormat gzip")
+	case l.encrypts():
+		return errors.New("EncryptKey can't be used with CompressActive")
+	case l.VerifyTailBytes > 0:
+		return errors.New("VerifyTailBytes can't be used with CompressActive")
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// existsWithGzipContent checks that the gzip stream at path decompresses to
+// content.  If open is set, the stream may lack its trailer, as the active
+// file does until it is closed.
+func existsWithGzipContent(tb testing.TB, path string, content []byte, open bool) {
+	tb.Helper()
+
+	f, err := os.Open(path)
+	isNilUp(tb, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	isNilUp(tb, err)
+
+	got, err := io.ReadAll(gz)
+	if open && errors.Is(err, io.ErrUnexpectedEOF) {
+		err = nil
+	}
+
+	isNilUp(tb, err)
+	equalsUp(tb, string(content), string(got))
+}
+
+func TestCompressActive(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressActive")
+	defer os.RemoveAll(dir)
+
+	active := logFile(dir) + compressSuffix
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		CompressActive: true,
+		MaxBytes:       1000,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	// Sync makes everything written so far readable.
+	isNil(t, l.Sync())
+	existsWithGzipContent(t, active, b, true)
+	notExist(t, logFile(dir))
+
+	// Appending after a restart adds a gzip member to the same stream.
+	isNil(t, l.Close())
+
+	b2 := []byte("foo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+	isNil(t, l.Close())
+	existsWithGzipContent(t, active, append(b, b2...), false)
+
+	// MaxBytes is measured against the compressed size.
+	var b3 []byte
+
+	for i := 0; i < 10; i++ {
+		chunk := bytes.Repeat([]byte("a"), 500)
+		_, err = l.Write(chunk)
+		isNil(t, err)
+
+		b3 = append(b3, chunk...)
+	}
+
+	fileCount(t, dir, 1)
+
+	// The backup keeps the suffix and isn't compressed again.
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	existsWithGzipContent(t, backupFile(dir)+compressSuffix, append(append(b, b2...), b3...), false)
+	exists(t, active)
+	fileCount(t, dir, 2)
+}
+
+func TestCompressActiveInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressActiveInvalid")
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		l    *Logger
+		want string
+	}{
+		{&Logger{CompressionFormat: CompressionZstd}, "CompressActive requires CompressionFormat gzip"},
+		{&Logger{EncryptKey: make([]byte, 32)}, "EncryptKey can't be used with CompressActive"},
+		{&Logger{VerifyTailBytes: 10}, "VerifyTailBytes can't be used with CompressActive"},
+	}
+
+	for _, test := range tests {
+		test.l.Filename = logFile(dir)
+		test.l.CompressActive = true
+
+		_, err := test.l.Write([]byte("boo!"))
+		notNil(t, err)
+		equals(t, test.want, err.Error())
+	}
+
+	fileCount(t, dir, 0)
+}
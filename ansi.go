@@ -0,0 +1,78 @@
+package lumberjack
+
+import "bytes"
+
+const esc = 0x1b
+
+// stripANSI returns p without ANSI escape sequences, such as the colors of
+// console output: control sequences ("ESC [" ... final byte), operating
+// system commands ("ESC ]" ... BEL or "ESC \") and other escapes such as
+// "ESC ( B".  It returns p itself if there are none, and doesn't modify p.
+func stripANSI(p []byte) []byte {
+	i := bytes.IndexByte(p, esc)
+	if i < 0 {
+		return p
+	}
+
+	out := make([]byte, 0, len(p))
+
+	for i >= 0 {
+		out = append(out, p[:i]...)
+		p = p[i+ansiLen(p[i:]):]
+		i = bytes.IndexByte(p, esc)
+	}
+
+	return append(out, p...)
+}
+
+// ansiLen returns the length of the escape sequence at the start of p, which
+// starts with ESC.  A sequence cut off by the end of p extends to it.
+func ansiLen(p []byte) int {
+	if len(p) < 2 {
+		return len(p)
+	}
+
+	switch p[1] {
+	case '[':
+		// Parameter and intermediate bytes, up to the final byte.
+		for i := 2; i < len(p); i++ {
+			if p[i] >= 0x40 && p[i] <= 0x7e {
+				return i + 1
+			}
+
+			if p[i] < 0x20 || p[i] > 0x3f {
+				// Not a control sequence after all.
+				return i
+			}
+		}
+
+		return len(p)
+	case ']':
+		for i := 2; i < len(p); i++ {
+			switch {
+			case p[i] == 0x07:
+				return i + 1
+			case p[i] == esc && i+1 < len(p) && p[i+1] == '\\':
+				return i + 2
+			case p[i] == '\n':
+				// An unterminated command doesn't swallow the next line.
+				return i
+			}
+		}
+
+		return len(p)
+	}
+
+	// Other escapes, such as charset designations, have intermediate bytes
+	// followed by a final byte.
+	i := 1
+	for i < len(p) && p[i] >= 0x20 && p[i] <= 0x2f {
+		i++
+	}
+
+	if i < len(p) && p[i] >= 0x30 && p[i] <= 0x7e {
+		return i + 1
+	}
+
+	return i
+}
@@ -0,0 +1,53 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"\x1b[31mred\x1b[0m", "red"},
+		{"\x1b[1;32mbold green\x1b[m done", "bold green done"},
+		{"\x1b[2K\x1b[1Gprogress", "progress"},
+		{"\x1b]0;title\x07text", "text"},
+		{"\x1b]8;;http://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"\x1b]0;unterminated\nnext", "\nnext"},
+		{"\x1b(Bcharset", "charset"},
+		{"cut off\x1b[3", "cut off"},
+		{"trailing\x1b", "trailing"},
+		{"\x1b\nline", "\nline"},
+		{"\x1b[\nnot a sequence", "\nnot a sequence"},
+	}
+
+	for _, tt := range tests {
+		in := []byte(tt.in)
+
+		equals(t, tt.want, string(stripANSI(in)))
+		equals(t, tt.in, string(in))
+	}
+}
+
+func TestStripANSIWrite(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestStripANSIWrite")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:  filename,
+		StripANSI: true,
+	}
+	defer l.Close()
+
+	b := []byte("\x1b[33mWARN\x1b[0m disk almost full\n")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	existsWithContent(t, filename, []byte("WARN disk almost full\n"))
+}
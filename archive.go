@@ -0,0 +1,236 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Archiver uploads backups to long-term storage, such as an object store.
+type Archiver interface {
+	// Archive uploads the backup described by b, whose file is at b.Path.
+	Archive(ctx context.Context, b BackupInfo) error
+}
+
+// archiveFunc adapts the Archive setting of a Logger to an Archiver.
+type archiveFunc func(path string) error
+
+func (f archiveFunc) Archive(_ context.Context, b BackupInfo) error {
+	return f(b.Path)
+}
+
+// ArchiveErrorPolicy selects what happens to a backup whose upload failed.
+type ArchiveErrorPolicy string
+
+const (
+	// ArchiveRetry keeps a backup whose upload failed queued, so that the
+	// upload is retried on every cleanup run until it succeeds or the backup
+	// is removed.
+	ArchiveRetry ArchiveErrorPolicy = "retry"
+
+	// ArchiveRetain gives up on a backup whose upload failed, leaving it on
+	// the local disk like a backup made without archiving.
+	ArchiveRetain ArchiveErrorPolicy = "retain"
+)
+
+// archiver returns the Archiver set by Archiver or Archive, or nil if backups
+// are not archived.
+func (l *Logger) archiver() Archiver {
+	switch {
+	case l.Archiver != nil:
+		return l.Archiver
+	case l.Archive != nil:
+		return archiveFunc(l.Archive)
+	}
+
+	return nil
+}
+
+// checkArchive reports an error if both Archiver and Archive are set, or if
+// ArchiveErrorPolicy is unknown.
+func (l *Logger) checkArchive() error {
+	if l.Archiver != nil && l.Archive != nil {
+		return errors.New("Archiver and Archive can't be used together")
+	}
+
+	switch l.ArchiveErrorPolicy {
+	case "", ArchiveRetry, ArchiveRetain:
+		return nil
+	}
+
+	return fmt.Errorf("unknown ArchiveErrorPolicy %q", l.ArchiveErrorPolicy)
+}
+
+// queueArchive records a new backup, given by its path before compression,
+// for the mill goroutine to archive.  It must be called with l.mu held.
+func (l *Logger) queueArchive(path string) {
+	if l.archiver() == nil {
+		return
+	}
+
+	l.hooksMu.Lock()
+	l.archives = append(l.archives, filepath.Base(path))
+	l.hooksMu.Unlock()
+}
+
+// shiftArchives renames the queued backups like shiftBackups renames the
+// files.  It must be called with l.millMu held.
+func (l *Logger) shiftArchives(n *backupNamer) {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	for i, name := range l.archives {
+		if p, ok := n.parse(name); ok {
+			// The namer of NamingSequence can't fail.
+			l.archives[i], _ = n.format("", p.seq+1)
+		}
+	}
+}
+
+// archiveBackups uploads the queued backups which have been finalized, and
+// keeps the others queued.  Backups which no longer exist are dropped, and
+// failed ones as well under ArchiveRetain.  It returns the first error.  It
+// must be called with l.millMu held.  Uploads are canceled with ctx.
+func (l *Logger) archiveBackups(ctx context.Context) error {
+	a := l.archiver()
+	if a == nil {
+		return nil
+	}
+
+	l.hooksMu.Lock()
+	queued := l.archives
+	l.archives = nil
+	l.hooksMu.Unlock()
+
+	if len(queued) == 0 {
+		return nil
+	}
+
+	var (
+		err     error
+		pending []string
+	)
+
+	defer func() {
+		l.hooksMu.Lock()
+		l.archives = append(pending, l.archives...)
+		l.hooksMu.Unlock()
+	}()
+
+	suffix, _, err := l.finalizer(l.Compress || l.CompressActive)
+	if err != nil {
+		pending = queued
+
+		return err
+	}
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		pending = queued
+
+		return err
+	}
+
+	backups := make(map[string]logInfo, len(files))
+	for _, f := range files {
+		name := f.Name()
+		backups[name[:len(name)-len(backupSuffix(name))]] = f
+	}
+
+	for _, name := range queued {
+		f, ok := backups[name]
+		if !ok {
+			continue
+		}
+
+		if backupSuffix(f.Name()) != suffix {
+			// Not compressed or encrypted yet.
+			pending = append(pending, name)
+
+			continue
+		}
+
+		if errArchive := a.Archive(ctx, l.backupInfo(f)); errArchive != nil {
+			if l.ArchiveErrorPolicy != ArchiveRetain {
+				pending = append(pending, name)
+			}
+
+			if err == nil {
+				err = fmt.Errorf("can't archive %s: %v", f.Name(), errArchive)
+			}
+		}
+	}
+
+	return err
+}
+
+// KeyTemplate describes where an archived backup is stored in object storage,
+// such as "logs/{app}/{yyyy}/{mm}/{dd}/{file}", so that uploaded objects land
+// in the partition layout expected by external query engines.
+//
+// The following placeholders are derived from the backup:
+//
+//	{yyyy}  four digit year of the backup's timestamp
+//	{mm}    two digit month
+//	{dd}    two digit day of the month
+//	{hh}    two digit hour
+//	{file}  base name of the backup file
+//
+// Any other placeholder is looked up in the variables passed to Key.  An empty
+// template is equivalent to "{file}".
+type KeyTemplate string
+
+// Key expands the template for the given backup.  vars supplies per-upload
+// placeholders; it is an error for the template to reference a placeholder
+// that is neither built in nor present in vars.
+func (t KeyTemplate) Key(b BackupInfo, vars map[string]string) (string, error) {
+	tmpl := string(t)
+	if tmpl == "" {
+		tmpl = "{file}"
+	}
+
+	ts := b.Timestamp
+	builtin := map[string]string{
+		"yyyy": fmt.Sprintf("%04d", ts.Year()),
+		"mm":   fmt.Sprintf("%02d", ts.Month()),
+		"dd":   fmt.Sprintf("%02d", ts.Day()),
+		"hh":   fmt.Sprintf("%02d", ts.Hour()),
+		"file": filepath.Base(b.Path),
+	}
+
+	var key strings.Builder
+
+	for rest := tmpl; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start == -1 {
+			key.WriteString(rest)
+
+			break
+		}
+
+		end := strings.IndexByte(rest[start:], '}')
+		if end == -1 {
+			return "", fmt.Errorf("invalid key template %q: unterminated placeholder", tmpl)
+		}
+
+		name := rest[start+1 : start+end]
+
+		value, ok := builtin[name]
+		if !ok {
+			value, ok = vars[name]
+		}
+
+		if !ok {
+			return "", fmt.Errorf("invalid key template %q: unknown placeholder {%s}", tmpl, name)
+		}
+
+		key.WriteString(rest[:start])
+		key.WriteString(value)
+
+		rest = rest[start+end+1:]
+	}
+
+	return key.String(), nil
+}
@@ -0,0 +1,261 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyTemplate(t *testing.T) {
+	b := BackupInfo{
+		Path:      "/var/log/app/foo-2014-05-04T09-44-33.555.log.gz",
+		Timestamp: time.Date(2014, 5, 4, 9, 44, 33, 555000000, time.UTC),
+	}
+	vars := map[string]string{"app": "billing"}
+
+	tests := []struct {
+		tmpl    KeyTemplate
+		want    string
+		wantErr bool
+	}{
+		{"", "foo-2014-05-04T09-44-33.555.log.gz", false},
+		{"logs/{app}/{yyyy}/{mm}/{dd}/{hh}/{file}", "logs/billing/2014/05/04/09/foo-2014-05-04T09-44-33.555.log.gz", false},
+		{"year={yyyy}/month={mm}/{file}", "year=2014/month=05/foo-2014-05-04T09-44-33.555.log.gz", false},
+		{"logs/{host}/{file}", "", true},
+		{"logs/{file", "", true},
+	}
+
+	for _, test := range tests {
+		got, err := test.tmpl.Key(b, vars)
+		equals(t, test.want, got)
+		equals(t, test.wantErr, err != nil)
+	}
+}
+
+// fakeArchiver records the archived backups and fails while fail is set.
+type fakeArchiver struct {
+	mu       sync.Mutex
+	fail     bool
+	archived []BackupInfo
+	content  [][]byte
+}
+
+func (a *fakeArchiver) Archive(_ context.Context, b BackupInfo) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.fail {
+		return errors.New("unavailable")
+	}
+
+	content, err := os.ReadFile(b.Path)
+	if err != nil {
+		return err
+	}
+
+	a.archived = append(a.archived, b)
+	a.content = append(a.content, content)
+
+	return nil
+}
+
+func (a *fakeArchiver) setFail(fail bool) {
+	a.mu.Lock()
+	a.fail = fail
+	a.mu.Unlock()
+}
+
+func (a *fakeArchiver) get() ([]BackupInfo, [][]byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]BackupInfo(nil), a.archived...), append([][]byte(nil), a.content...)
+}
+
+func TestArchiver(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestArchiver")
+	defer os.RemoveAll(dir)
+
+	// Backups existing beforehand are not archived.
+	err := os.WriteFile(backupFile(dir), []byte("old"), fileModeNew)
+	isNil(t, err)
+
+	a := &fakeArchiver{fail: true}
+	errs := make(chan error, 10)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		Compress:   true,
+		MaxBackups: 1,
+		Archiver:   a,
+		OnError:    func(err error) { errs <- err },
+	}
+	defer l.Close()
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.waitMill(context.Background()))
+
+	// Every run of the mill since the rotation failed to upload the backup.
+	assert(t, len(errs) > 0, "no error reported")
+
+	for len(errs) > 0 {
+		err := <-errs
+		equals(t, "can't archive "+filepath.Base(backupFile(dir))+compressSuffix+": unavailable", err.Error())
+	}
+
+	// The failed upload is retried on the next run, before the backup is
+	// removed for exceeding MaxBackups.
+	a.setFail(false)
+
+	_, err = l.Write([]byte("bar"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.waitMill(context.Background()))
+
+	archived, content := a.get()
+	equals(t, 2, len(archived))
+	equals(t, true, archived[0].Compressed)
+	equals(t, backupFile(dir)+compressSuffix, archived[1].Path)
+	equals(t, 0, len(errs))
+
+	r, err := gzip.NewReader(bytes.NewReader(content[1]))
+	isNil(t, err)
+	got, err := io.ReadAll(r)
+	isNil(t, err)
+	equals(t, []byte("bar"), got)
+
+	fileCount(t, dir, 2)
+}
+
+func TestArchiverSequence(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestArchiverSequence")
+	defer os.RemoveAll(dir)
+
+	a := &fakeArchiver{fail: true}
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		NamingScheme: NamingSequence,
+		Archiver:     a,
+	}
+	defer l.Close()
+
+	for _, s := range []string{"a", "b"} {
+		_, err := l.Write([]byte(s))
+		isNil(t, err)
+		isNil(t, l.Rotate())
+		isNil(t, l.waitMill(context.Background()))
+	}
+
+	// The queued backups were renumbered along with the files.
+	a.setFail(false)
+
+	l.mu.Lock()
+	l.mill()
+	l.mu.Unlock()
+
+	isNil(t, l.waitMill(context.Background()))
+
+	archived, content := a.get()
+	equals(t, 2, len(archived))
+	equals(t, filename+".2", archived[0].Path)
+	equals(t, []byte("a"), content[0])
+	equals(t, filename+".1", archived[1].Path)
+	equals(t, []byte("b"), content[1])
+}
+
+func TestArchiveFuncRetain(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestArchiveFuncRetain")
+	defer os.RemoveAll(dir)
+
+	var (
+		mu       sync.Mutex
+		fail     = true
+		archived []string
+	)
+
+	errs := make(chan error, 10)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Archive: func(path string) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if fail {
+				return errors.New("unavailable")
+			}
+
+			archived = append(archived, path)
+
+			return nil
+		},
+		ArchiveErrorPolicy: ArchiveRetain,
+		OnError:            func(err error) { errs <- err },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	first := backupFile(dir)
+
+	isNil(t, l.waitMill(context.Background()))
+	assert(t, len(errs) > 0, "no error reported")
+
+	for len(errs) > 0 {
+		err := <-errs
+		equals(t, "can't archive "+filepath.Base(first)+": unavailable", err.Error())
+	}
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+
+	_, err = l.Write([]byte("bar"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.waitMill(context.Background()))
+
+	// The failed backup was given up on, but kept locally.
+	mu.Lock()
+	equals(t, []string{backupFile(dir)}, archived)
+	mu.Unlock()
+
+	existsWithContent(t, first, []byte("boo!"))
+	equals(t, 0, len(errs))
+}
+
+func TestArchiveInvalid(t *testing.T) {
+	_, err := New("foo.log", WithArchive(func(string) error { return nil }), WithArchiveErrorPolicy("drop"))
+	equals(t, `unknown ArchiveErrorPolicy "drop"`, err.Error())
+
+	l := &Logger{Archiver: &fakeArchiver{}, Archive: func(string) error { return nil }}
+	_, err = l.Write([]byte("boo!"))
+	equals(t, "Archiver and Archive can't be used together", err.Error())
+}
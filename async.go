@@ -0,0 +1,106 @@
+package lumberjack
+
+import (
+	"sync"
+	"time"
+)
+
+// asyncQueue holds the records accepted by Write with AsyncBufferSize until a
+// background goroutine writes them.  It is guarded by its own mutex, so that
+// Write never waits for l.mu.
+type asyncQueue struct {
+	mu      sync.Mutex
+	idle    *sync.Cond
+	records [][]byte
+
+	// size is the number of bytes queued or being written.
+	size int
+
+	// running is true while the goroutine writing the queue runs, and
+	// progress is the last time it started or wrote a record.
+	running  bool
+	progress time.Time
+}
+
+// writeAsync queues a copy of the record p for the background goroutine, or
+// drops it if the queue is full.  It never blocks on I/O, so errors are
+// reported like other background errors.
+func (l *Logger) writeAsync(p []byte) (int, error) {
+	q := &l.async
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.size+len(p) > l.AsyncBufferSize {
+		l.recordDrop(len(p))
+
+		return len(p), nil
+	}
+
+	q.records = append(q.records, append([]byte(nil), p...))
+	q.size += len(p)
+
+	if !q.running {
+		q.running = true
+		q.progress = l.now()
+
+		go l.runAsync()
+	}
+
+	return len(p), nil
+}
+
+// runAsync writes the queued records until the queue is empty.
+func (l *Logger) runAsync() {
+	q := &l.async
+
+	q.mu.Lock()
+
+	for len(q.records) > 0 {
+		records := q.records
+		q.records = nil
+		q.mu.Unlock()
+
+		for _, r := range records {
+			if _, err := l.writeRecord(r); err != nil {
+				l.queueError(err)
+
+				l.mu.Lock()
+				l.mill()
+				l.mu.Unlock()
+			}
+
+			q.mu.Lock()
+			q.size -= len(r)
+			q.progress = l.now()
+			q.mu.Unlock()
+		}
+
+		q.mu.Lock()
+	}
+
+	q.running = false
+
+	if q.idle != nil {
+		q.idle.Broadcast()
+	}
+
+	q.mu.Unlock()
+}
+
+// drainAsync waits until the queued records are written.  It must not be
+// called with l.mu held.
+func (l *Logger) drainAsync() {
+	q := &l.async
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.idle == nil {
+		q.idle = sync.NewCond(&q.mu)
+	}
+
+	for q.running {
+		q.idle.Wait()
+	}
+}
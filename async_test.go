@@ -0,0 +1,53 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+// blockingWriter blocks writes until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+
+	return len(p), nil
+}
+
+func TestAsyncBufferSize(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestAsyncBufferSize")
+	defer os.RemoveAll(dir)
+
+	// The mirror stalls the background goroutine like a slow disk would.
+	stall := blockingWriter{release: make(chan struct{})}
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		AsyncBufferSize: 8,
+		Mirror:          stall,
+	}
+	defer l.Close()
+
+	for _, b := range []string{"boo!", "foo!", "bar!"} {
+		n, err := l.Write([]byte(b))
+		isNil(t, err)
+		equals(t, len(b), n)
+	}
+
+	close(stall.release)
+
+	// Close writes out the queued records, while the last one didn't fit in
+	// the queue.
+	isNil(t, l.Close())
+	existsWithContent(t, filename, []byte("boo!foo!"))
+
+	stats := l.Stats()
+	equals(t, int64(2), stats.Writes)
+	equals(t, int64(1), stats.DroppedWrites)
+	equals(t, int64(4), stats.DroppedBytes)
+}
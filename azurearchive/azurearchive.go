@@ -0,0 +1,198 @@
+// Package azurearchive archives lumberjack backups to Azure Blob Storage,
+// using the REST API directly so that no SDK is needed:
+//
+//	l := &lumberjack.Logger{
+//		Filename:   "/var/log/myapp/foo.log",
+//		MaxBackups: 3,
+//		Compress:   true,
+//		Archiver: &azurearchive.Archiver{
+//			ContainerURL: "https://myaccount.blob.core.windows.net/logs",
+//			Prefix:       "myapp/",
+//			Key:          "{yyyy}/{mm}/{dd}/{file}",
+//		},
+//	}
+//
+// Requests are authorized with a shared access signature if SAS is set, and
+// with an access token of the managed identity of the Azure VM or AKS node
+// otherwise.
+package azurearchive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+// apiVersion is the version of the Blob Storage REST API used.
+const apiVersion = "2021-08-06"
+
+// imdsURL is the token endpoint of the Azure Instance Metadata Service.  It is
+// a variable so tests can mock it out.
+var imdsURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// Archiver is a lumberjack.Archiver uploading backups as block blobs to an
+// Azure Blob Storage container.
+type Archiver struct {
+	// ContainerURL is the URL of the container to upload to, e.g.
+	// "https://myaccount.blob.core.windows.net/logs".
+	ContainerURL string
+
+	// Prefix is prepended to the blob names, e.g. "myapp/".
+	Prefix string
+
+	// Key is the template for the blob names, following Prefix.  The default
+	// is the base name of the backup.
+	Key lumberjack.KeyTemplate
+
+	// Vars supplies the custom placeholders of Key.
+	Vars map[string]string
+
+	// SAS is a shared access signature granting write access to the
+	// container, with or without the leading "?".  If it is empty, the
+	// managed identity is used instead.
+	SAS string
+
+	// ClientID selects a user-assigned managed identity.  The default is the
+	// system-assigned identity.
+	ClientID string
+
+	// Client is the HTTP client used for requests.  The default is
+	// http.DefaultClient.
+	Client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Archive implements lumberjack.Archiver.
+func (a *Archiver) Archive(ctx context.Context, b lumberjack.BackupInfo) error {
+	if a.ContainerURL == "" {
+		return errors.New("azurearchive: no container URL")
+	}
+
+	key, err := a.Key.Key(b, a.Vars)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(b.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	u := strings.TrimSuffix(a.ContainerURL, "/") + "/" + (&url.URL{Path: a.Prefix + key}).EscapedPath()
+	if a.SAS != "" {
+		u += "?" + strings.TrimPrefix(a.SAS, "?")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, f)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = b.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-version", apiVersion)
+
+	if a.SAS == "" {
+		token, err := a.managedIdentityToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := a.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("azurearchive: uploading %s: %s: %s", key, resp.Status, msg)
+	}
+
+	return nil
+}
+
+func (a *Archiver) client() *http.Client {
+	if a.Client != nil {
+		return a.Client
+	}
+
+	return http.DefaultClient
+}
+
+// managedIdentityToken returns an access token for Azure Storage from the
+// Instance Metadata Service, reusing it until shortly before it expires.
+func (a *Archiver) managedIdentityToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Before(a.expiry) {
+		return a.token, nil
+	}
+
+	q := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {"https://storage.azure.com/"},
+	}
+
+	if a.ClientID != "" {
+		q.Set("client_id", a.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Metadata", "true")
+
+	resp, err := a.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("azurearchive: can't get managed identity token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("azurearchive: can't get managed identity token: %s", resp.Status)
+	}
+
+	// The service returns expires_in as a string.
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   string `json:"expires_in"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("azurearchive: invalid managed identity token: %v", err)
+	}
+
+	expiresIn, err := strconv.Atoi(token.ExpiresIn)
+	if err != nil {
+		return "", fmt.Errorf("azurearchive: invalid managed identity token expiry %q", token.ExpiresIn)
+	}
+
+	a.token = token.AccessToken
+	a.expiry = time.Now().Add(time.Duration(expiresIn)*time.Second - time.Minute)
+
+	return a.token, nil
+}
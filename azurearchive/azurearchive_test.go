@@ -0,0 +1,116 @@
+package azurearchive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+func TestArchive(t *testing.T) {
+	var (
+		tokens  int
+		uploads = make(map[string]string)
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("resource") != "https://storage.azure.com/" {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			tokens++
+			_, _ = io.WriteString(w, `{"access_token":"secret","expires_in":"3600","token_type":"Bearer"}`)
+
+			return
+		}
+
+		if r.Method != http.MethodPut || r.Header.Get("x-ms-blob-type") != "BlockBlob" ||
+			r.Header.Get("x-ms-version") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer secret" && r.URL.Query().Get("sig") != "signed" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		if !strings.HasPrefix(r.URL.Path, "/logs/") {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+		uploads[r.URL.Path] = string(b)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	imdsURL = srv.URL + "/token"
+
+	path := filepath.Join(t.TempDir(), "foo-2014-05-04T09-44-33.555.log")
+	if err := os.WriteFile(path, []byte("boo!"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b := lumberjack.BackupInfo{
+		Path:      path,
+		Timestamp: time.Date(2014, 5, 4, 9, 44, 33, 555000000, time.UTC),
+		Size:      4,
+	}
+
+	a := &Archiver{
+		ContainerURL: srv.URL + "/logs",
+		Prefix:       "myapp/",
+		Key:          "{yyyy}/{mm}/{file}",
+	}
+
+	// With the managed identity, the token is reused.
+	for i := 0; i < 2; i++ {
+		if err := a.Archive(context.Background(), b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tokens != 1 {
+		t.Fatalf("got %d tokens, want 1", tokens)
+	}
+
+	if got := uploads["/logs/myapp/2014/05/foo-2014-05-04T09-44-33.555.log"]; got != "boo!" {
+		t.Fatalf("unexpected uploads %v", uploads)
+	}
+
+	// With a SAS, no token is needed.
+	a = &Archiver{
+		ContainerURL: srv.URL + "/logs/",
+		SAS:          "?sv=2021-08-06&sig=signed",
+	}
+
+	if err := a.Archive(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+
+	if tokens != 1 || uploads["/logs/foo-2014-05-04T09-44-33.555.log"] != "boo!" {
+		t.Fatalf("unexpected uploads %v with %d tokens", uploads, tokens)
+	}
+
+	a.ContainerURL = srv.URL + "/other"
+
+	err := a.Archive(context.Background(), b)
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
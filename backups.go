@@ -0,0 +1,70 @@
+package lumberjack
+
+import "time"
+
+// Backups returns the rotated backups of the Logger, newest first, so that
+// applications can list, download or audit them without parsing the backup
+// names themselves.  Backups which are being compressed or removed while the
+// directory is read may be missing from the result.
+func (l *Logger) Backups() ([]BackupInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]BackupInfo, 0, len(files))
+	for _, f := range files {
+		backups = append(backups, l.backupInfo(f))
+	}
+
+	return backups, nil
+}
+
+// Purge removes the backups rotated before olderThan, to reclaim disk space
+// without waiting for the next rotation, e.g. when the disk is running low.
+// Backups which haven't been archived yet are removed as well.
+func (l *Logger) Purge(olderThan time.Time) error {
+	return l.purge(func(f logInfo) bool { return f.timestamp.Before(olderThan) })
+}
+
+// PurgeAll removes all backups.  The active log file is kept.
+func (l *Logger) PurgeAll() error {
+	return l.purge(func(logInfo) bool { return true })
+}
+
+// purge removes the backups for which match returns true, returning the first
+// error.
+func (l *Logger) purge(match func(logInfo) bool) error {
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+
+	unlock, err := l.lockMill()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	var remove []logInfo
+
+	for _, f := range files {
+		if match(f) {
+			remove = append(remove, f)
+		}
+	}
+
+	err = l.removeBackups(remove)
+
+	if errLink := l.linkNewestBackup(); err == nil {
+		err = errLink
+	}
+
+	return err
+}
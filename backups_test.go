@@ -0,0 +1,91 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBackups(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackups")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	backups, err := l.Backups()
+	isNil(t, err)
+	equals(t, 0, len(backups))
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	first := backupFile(dir)
+	isNil(t, l.Rotate())
+
+	_, err = l.Write([]byte("foooo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	backups, err = l.Backups()
+	isNil(t, err)
+	equals(t, 2, len(backups))
+
+	// The newest backup comes first.
+	equals(t, second, backups[0].Path)
+	equals(t, fakeTime().UTC().Truncate(time.Millisecond), backups[0].Timestamp)
+	equals(t, int64(len("foooo!")), backups[0].Size)
+	equals(t, false, backups[0].Compressed)
+	equals(t, first, backups[1].Path)
+	equals(t, int64(len("boo!")), backups[1].Size)
+}
+
+func TestPurge(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestPurge")
+	defer os.RemoveAll(dir)
+
+	var backups []string
+
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+
+		backup := backupFile(dir)
+		err := os.WriteFile(backup, []byte("foo!"), fileModeNew)
+		isNil(t, err)
+
+		backups = append(backups, backup)
+	}
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	// Only the backups rotated before the newest one are removed.
+	newest := fakeTime()
+	isNil(t, l.Purge(newest.Add(-time.Second)))
+	notExist(t, backups[0])
+	notExist(t, backups[1])
+	exists(t, backups[2])
+
+	isNil(t, l.PurgeAll())
+	notExist(t, backups[2])
+	existsWithContent(t, filename, []byte("boo!"))
+	fileCount(t, dir, 1)
+	equals(t, int64(3), l.Stats().RemovedBackups)
+}
@@ -0,0 +1,159 @@
+package lumberjack
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultFlushInterval is used when BufferSize is set without a FlushInterval.
+const defaultFlushInterval = time.Second
+
+// startBuffer wraps the freshly opened log file in a buffer if BufferSize is
+// set.  It must be called with l.mu held.
+func (l *Logger) startBuffer() {
+	l.buf = nil
+
+	if l.BufferSize > 0 && !l.SyncEveryWrite {
+		l.buf = bufio.NewWriterSize(l.file, l.BufferSize)
+	}
+}
+
+// writeFile writes p to the active file, through the buffer if there is one.
+// A flush is scheduled whenever data is left in the buffer.  It must be called
+// with l.mu held.
+func (l *Logger) writeFile(p []byte) (int, error) {
+	if l.buf == nil {
+		return l.file.Write(p)
+	}
+
+	n, err := l.buf.Write(p)
+
+	if l.buf.Buffered() > 0 && l.flushTimer == nil {
+		l.flushTimer = time.AfterFunc(l.flushInterval(), l.timedFlush)
+	}
+
+	return n, err
+}
+
+// flush writes any buffered data to the active file.  It must be called with
+// l.mu held.
+func (l *Logger) flush() error {
+	if l.flushTimer != nil {
+		l.flushTimer.Stop()
+		l.flushTimer = nil
+	}
+
+	if l.buf == nil {
+		return nil
+	}
+
+	return l.buf.Flush()
+}
+
+// timedFlush is run by the flush timer.
+func (l *Logger) timedFlush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flushTimer = nil
+
+	if l.buf == nil {
+		return
+	}
+
+	if err := l.buf.Flush(); err != nil {
+		l.queueError(err)
+		l.mill()
+	}
+}
+
+// flushInterval returns the maximum time buffered data is held back.
+func (l *Logger) flushInterval() time.Duration {
+	if l.FlushInterval > 0 {
+		return l.FlushInterval
+	}
+
+	return defaultFlushInterval
+}
+
+// startSyncTimer starts the timer syncing the active file every SyncInterval,
+// if set.  It must be called with l.mu held.
+func (l *Logger) startSyncTimer() {
+	l.stopSyncTimer()
+
+	if l.SyncInterval > 0 {
+		l.armSyncTimer()
+	}
+}
+
+// armSyncTimer arms a sync timer for the current generation.  It must be
+// called with l.mu held.
+func (l *Logger) armSyncTimer() {
+	gen := l.syncGen
+	l.syncTimer = time.AfterFunc(l.SyncInterval, func() { l.timedSync(gen) })
+}
+
+// syncWrite commits a write to stable storage if SyncEveryWrite is set.  It
+// must be called with l.mu held, at least for reading.
+func (l *Logger) syncWrite() error {
+	if !l.SyncEveryWrite {
+		return nil
+	}
+
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("can't sync log file: %s", err)
+	}
+
+	return nil
+}
+
+// checkSyncEveryWrite reports an error if SyncEveryWrite is combined with
+// AsyncBufferSize, which returns from Write before the record is written.
+func (l *Logger) checkSyncEveryWrite() error {
+	if l.SyncEveryWrite && l.AsyncBufferSize > 0 {
+		return errors.New("SyncEveryWrite can't be used with AsyncBufferSize")
+	}
+
+	return nil
+}
+
+// stopSyncTimer stops the sync timer, if any.  It must be called with l.mu
+// held.
+func (l *Logger) stopSyncTimer() {
+	l.syncGen++
+
+	if l.syncTimer != nil {
+		l.syncTimer.Stop()
+		l.syncTimer = nil
+	}
+}
+
+// timedSync is run by the sync timer of generation gen.  It flushes and syncs
+// the active file if it was written to since the last sync, and rearms the
+// timer, unless the timer was stopped in the meantime.
+func (l *Logger) timedSync(gen uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil || l.syncTimer == nil || gen != l.syncGen {
+		return
+	}
+
+	if l.unsynced.Load() {
+		err := l.flush()
+		if err == nil {
+			err = l.file.Sync()
+		}
+
+		if err != nil {
+			l.queueError(err)
+			l.mill()
+		}
+
+		l.unsynced.Store(false)
+	}
+
+	l.armSyncTimer()
+}
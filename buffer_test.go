@@ -0,0 +1,239 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBufferSize(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBufferSize")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		BufferSize:    64,
+		FlushInterval: time.Hour,
+		MaxBytes:      10,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	// The write is held back until it is flushed.
+	existsWithContent(t, filename, []byte{})
+
+	isNil(t, l.Sync())
+	existsWithContent(t, filename, b)
+
+	b2 := []byte("bar")
+	_, err = l.Write(b2)
+	isNil(t, err)
+
+	newFakeTime()
+
+	// Rotation flushes the buffer into the backup.
+	b3 := []byte("foooooo!")
+	_, err = l.Write(b3)
+	isNil(t, err)
+	existsWithContent(t, backupFile(dir), []byte("boo!bar"))
+	existsWithContent(t, filename, []byte{})
+
+	isNil(t, l.Close())
+	existsWithContent(t, filename, b3)
+}
+
+func TestFlushInterval(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFlushInterval")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		BufferSize:    64,
+		FlushInterval: 10 * time.Millisecond,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	<-time.After(100 * time.Millisecond)
+
+	existsWithContent(t, filename, b)
+}
+
+func TestSyncInterval(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestSyncInterval")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		BufferSize:    64,
+		FlushInterval: time.Hour,
+		SyncInterval:  10 * time.Millisecond,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	<-time.After(100 * time.Millisecond)
+
+	// The periodic sync flushes the buffer too.
+	existsWithContent(t, filename, b)
+
+	l.mu.Lock()
+	equals(t, false, l.unsynced.Load())
+	notNil(t, l.syncTimer)
+	l.mu.Unlock()
+
+	isNil(t, l.Close())
+
+	l.mu.Lock()
+	equals(t, (*time.Timer)(nil), l.syncTimer)
+	l.mu.Unlock()
+}
+
+func TestSyncIntervalStaleTimer(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestSyncIntervalStaleTimer")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		SyncInterval: time.Hour,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	l.mu.Lock()
+	stale := l.syncGen
+	l.mu.Unlock()
+
+	isNil(t, l.Close())
+
+	_, err = l.Write([]byte("foo!"))
+	isNil(t, err)
+
+	l.mu.Lock()
+	timer := l.syncTimer
+	l.mu.Unlock()
+
+	// The timer of the first file fires after it was stopped, and must not
+	// replace the timer of the reopened file.
+	l.timedSync(stale)
+
+	l.mu.Lock()
+	current := l.syncTimer
+	l.mu.Unlock()
+
+	assert(t, timer == current, "stale timer replaced the sync timer")
+}
+
+// syncingFS is the FS of the operating system, counting the syncs of its
+// files and failing them with err if set.
+type syncingFS struct {
+	osFS
+
+	syncs atomic.Int64
+	err   error
+}
+
+func (s *syncingFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	file, err := s.osFS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return syncingFile{file, s}, nil
+}
+
+type syncingFile struct {
+	File
+
+	fs *syncingFS
+}
+
+func (f syncingFile) Sync() error {
+	f.fs.syncs.Add(1)
+
+	if f.fs.err != nil {
+		return f.fs.err
+	}
+
+	return f.File.Sync()
+}
+
+func TestSyncEveryWrite(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestSyncEveryWrite")
+	defer os.RemoveAll(dir)
+
+	fs := &syncingFS{}
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		BufferSize:     64,
+		FlushInterval:  time.Hour,
+		SyncEveryWrite: true,
+		FS:             fs,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	// The record isn't buffered.
+	existsWithContent(t, filename, b)
+	equals(t, int64(1), fs.syncs.Load())
+
+	_, err = l.Write(b)
+	isNil(t, err)
+	equals(t, int64(2), fs.syncs.Load())
+
+	fs.err = errors.New("disk on fire")
+
+	n, err := l.Write(b)
+	equals(t, len(b), n)
+	notNil(t, err)
+	equals(t, "can't sync log file: disk on fire", err.Error())
+}
+
+func TestSyncEveryWriteAsync(t *testing.T) {
+	dir := makeTempDir(t, "TestSyncEveryWriteAsync")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		SyncEveryWrite:  true,
+		AsyncBufferSize: 1024,
+	}
+	defer l.Close()
+
+	err := l.Validate()
+	notNil(t, err)
+	equals(t, true, strings.Contains(err.Error(), "SyncEveryWrite can't be used with AsyncBufferSize"))
+}
@@ -0,0 +1,13 @@
+package lumberjack
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// isBusy reports whether err is caused by another process, such as a virus
+// scanner or the search indexer, briefly holding the file open.
+var isBusy = func(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
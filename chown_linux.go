@@ -5,15 +5,16 @@ import (
 	"syscall"
 )
 
-// osChown is a var so we can mock it out during tests.
-var osChown = os.Chown
-
-func chown(name string, info os.FileInfo) error {
-	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+func chown(fs FS, name string, info os.FileInfo) error {
+	f, err := fs.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
 	if err != nil {
 		return err
 	}
 	f.Close()
-	stat := info.Sys().(*syscall.Stat_t)
-	return osChown(name, int(stat.Uid), int(stat.Gid))
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		// The FS doesn't track ownership.
+		return nil
+	}
+	return fs.Chown(name, int(stat.Uid), int(stat.Gid))
 }
@@ -0,0 +1,313 @@
+// Command lumberjack inspects and maintains the backups of a log file managed
+// by the lumberjack package, e.g. from a cron job or while debugging a host:
+//
+//	lumberjack list /var/log/myapp/foo.log
+//	lumberjack compress -settings compressionformat=zstd /var/log/myapp/foo.log
+//	lumberjack prune -maxage 7 -maxtotalbytes 10GB /var/log/myapp/foo.log
+//	lumberjack verify -settings encryptkey=... /var/log/myapp/foo.log
+//
+// The -settings flag takes the settings of the Logger writing the log file,
+// in the format of Config.Set, so that backups in a BackupDir or named by a
+// NamingScheme or BackupNameTemplate are found.  Backups are parsed and
+// selected by the package itself, so the command agrees with the Logger on
+// which files are backups.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+const usage = `usage: lumberjack <command> [flags] [logfile]
+
+commands:
+  list      list the backups, newest first
+  compress  compress and encrypt the plain backups
+  prune     remove the backups exceeding the given retention
+  verify    check that compressed and encrypted backups can be read back
+`
+
+// errFailed is returned by a command which reported its failures itself.
+var errFailed = errors.New("failed")
+
+// commands maps the command names to their implementations.
+var commands = map[string]func(ctx context.Context, args []string, stdout io.Writer) error{
+	"list":     list,
+	"compress": compress,
+	"prune":    prune,
+	"verify":   verify,
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command given by args and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "lumberjack: unknown command %q\n%s", args[0], usage)
+
+		return 2
+	}
+
+	if err := cmd(ctx, args[1:], stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintf(stderr, "lumberjack %s: %v\n", args[0], err)
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+// command holds the flags shared by all commands.
+type command struct {
+	flags *flag.FlagSet
+	cfg   lumberjack.Config
+}
+
+func newCommand(name string) *command {
+	c := &command{flags: flag.NewFlagSet(name, flag.ContinueOnError)}
+	c.flags.Var(&c.cfg, "settings", "settings of the Logger writing the log file, like Config.Set accepts them")
+
+	return c
+}
+
+// parse parses args and returns the configuration of the Logger managing the
+// log file, which is given as the only argument or as the file setting.
+func (c *command) parse(args []string) (lumberjack.Config, error) {
+	if err := c.flags.Parse(args); err != nil {
+		return lumberjack.Config{}, err
+	}
+
+	switch c.flags.NArg() {
+	case 0:
+	case 1:
+		c.cfg.Filename = c.flags.Arg(0)
+	default:
+		return lumberjack.Config{}, fmt.Errorf("unexpected arguments %q", c.flags.Args()[1:])
+	}
+
+	if c.cfg.Filename == "" {
+		return lumberjack.Config{}, errors.New("no log file given")
+	}
+
+	return c.cfg, nil
+}
+
+func list(_ context.Context, args []string, stdout io.Writer) error {
+	cfg, err := newCommand("list").parse(args)
+	if err != nil {
+		return err
+	}
+
+	backups, err := cfg.NewLogger().Backups()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "TIMESTAMP\tSIZE\tCOMPRESSED\tENCRYPTED\tPATH")
+
+	for _, b := range backups {
+		fmt.Fprintf(w, "%s\t%d\t%t\t%t\t%s\n",
+			b.Timestamp.Format(time.RFC3339Nano), b.Size, b.Compressed, b.Encrypted, b.Path)
+	}
+
+	return w.Flush()
+}
+
+func compress(ctx context.Context, args []string, _ io.Writer) error {
+	cfg, err := newCommand("compress").parse(args)
+	if err != nil {
+		return err
+	}
+
+	return cfg.NewLogger().CompressExisting(ctx)
+}
+
+func prune(_ context.Context, args []string, stdout io.Writer) error {
+	c := newCommand("prune")
+	maxAge := c.flags.Int("maxage", 0, "remove backups older than this many days")
+	maxBackups := c.flags.Int("maxbackups", 0, "keep at most this many backups")
+	maxTotalBytes := c.flags.String("maxtotalbytes", "", "remove the oldest backups while the log files take more than this size, e.g. 10GB")
+	dryRun := c.flags.Bool("n", false, "only print the backups which would be removed")
+
+	cfg, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	retention := cfg
+	retention.MaxAge, retention.MaxBackups = *maxAge, *maxBackups
+
+	if *maxTotalBytes != "" {
+		n, err := lumberjack.ParseSize(*maxTotalBytes)
+		if err != nil {
+			return fmt.Errorf("invalid -maxtotalbytes: %v", err)
+		}
+
+		retention.MaxTotalBytes = lumberjack.ByteSize(n)
+	}
+
+	if retention.MaxAge <= 0 && retention.MaxBackups <= 0 && retention.MaxTotalBytes <= 0 {
+		return errors.New("one of -maxage, -maxbackups or -maxtotalbytes is required")
+	}
+
+	// Plan against a Logger keeping everything, so that only the retention
+	// given on the command line applies.
+	keepAll := cfg
+	keepAll.MaxAge, keepAll.MaxBackups, keepAll.MaxTotalBytes = 0, 0, 0
+	keepAll.MaxUncompressedBackups, keepAll.MaxCompressedBackups = 0, 0
+
+	remove, _, err := keepAll.NewLogger().DiffRetention(retention)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range remove {
+		fmt.Fprintln(stdout, b.Path)
+
+		if *dryRun {
+			continue
+		}
+
+		if err := os.Remove(b.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func verify(_ context.Context, args []string, stdout io.Writer) error {
+	cfg, err := newCommand("verify").parse(args)
+	if err != nil {
+		return err
+	}
+
+	backups, err := cfg.NewLogger().Backups()
+	if err != nil {
+		return err
+	}
+
+	failed := false
+
+	for _, b := range backups {
+		if !b.Compressed && !b.Encrypted {
+			continue
+		}
+
+		if err := verifyBackup(b, cfg.EncryptKey); err != nil {
+			failed = true
+
+			fmt.Fprintf(stdout, "FAIL  %s: %v\n", b.Path, err)
+
+			continue
+		}
+
+		fmt.Fprintf(stdout, "OK    %s\n", b.Path)
+	}
+
+	if failed {
+		return errFailed
+	}
+
+	return nil
+}
+
+// verifyBackup reads the backup b back through its decryption and
+// decompression, which fails if it is truncated or corrupt.
+func verifyBackup(b lumberjack.BackupInfo, key []byte) error {
+	f, err := os.Open(b.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+
+	name := b.Path
+
+	if b.Encrypted {
+		if len(key) == 0 {
+			return errors.New("backup is encrypted, but no encryptkey is set")
+		}
+
+		if r, err = lumberjack.NewDecryptReader(r, key); err != nil {
+			return err
+		}
+
+		name = strings.TrimSuffix(name, ".enc")
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".gz"):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+
+		r = zr
+	case strings.HasSuffix(name, ".zst"):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		r = zr
+	case strings.HasSuffix(name, ".xz"):
+		return verifyXz(r)
+	}
+
+	_, err = io.Copy(io.Discard, r)
+
+	return err
+}
+
+// verifyXz tests the xz stream read from r with the xz command, which the
+// Logger compresses backups with as well.
+func verifyXz(r io.Reader) error {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("xz", "--test", "--quiet")
+	cmd.Stdin = r
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeBackups creates the log file foo.log in a new directory, with n plain
+// backups rotated a day apart, the newest first, and returns its path.
+func makeBackups(t *testing.T, n int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "foo.log")
+
+	if err := os.WriteFile(filename, []byte("active\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+
+	for i := 0; i < n; i++ {
+		ts := now.Add(-time.Duration(i+1) * 24 * time.Hour).Format("2006-01-02T15-04-05.000")
+		name := filepath.Join(dir, "foo-"+ts+".log")
+
+		if err := os.WriteFile(name, []byte(strings.Repeat("x", 100)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return filename
+}
+
+func runCmd(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	code := run(context.Background(), args, &stdout, &stderr)
+	if stderr.Len() > 0 {
+		t.Log(stderr.String())
+	}
+
+	return stdout.String(), code
+}
+
+func TestList(t *testing.T) {
+	filename := makeBackups(t, 3)
+
+	out, code := runCmd(t, "list", filename)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a header and 3 backups, got:\n%s", out)
+	}
+
+	if strings.Contains(out, filename+"\n") {
+		t.Fatalf("the active log file is listed:\n%s", out)
+	}
+}
+
+func TestCompressAndVerify(t *testing.T) {
+	filename := makeBackups(t, 2)
+
+	if _, code := runCmd(t, "compress", "-settings", "compressionformat=zstd", filename); code != 0 {
+		t.Fatalf("compress exit code %d", code)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(filename), "*.zst"))
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 compressed backups, got %v", matches)
+	}
+
+	out, code := runCmd(t, "verify", filename)
+	if code != 0 || strings.Count(out, "OK") != 2 {
+		t.Fatalf("verify exit code %d:\n%s", code, out)
+	}
+
+	// Truncate one of the backups.
+	if err := os.WriteFile(matches[0], []byte{0x28, 0xb5, 0x2f, 0xfd}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, code = runCmd(t, "verify", filename)
+	if code != 1 || !strings.Contains(out, "FAIL  "+matches[0]) {
+		t.Fatalf("verify exit code %d:\n%s", code, out)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	filename := makeBackups(t, 5)
+
+	out, code := runCmd(t, "prune", "-n", "-maxbackups", "2", filename)
+	if code != 0 || len(strings.Fields(out)) != 3 {
+		t.Fatalf("dry run exit code %d:\n%s", code, out)
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(filename), "foo-*.log")); len(matches) != 5 {
+		t.Fatalf("dry run removed backups, %d left", len(matches))
+	}
+
+	if _, code := runCmd(t, "prune", "-maxage", "3", filename); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(filename), "foo-*.log")); len(matches) != 2 {
+		t.Fatalf("expected 2 backups younger than 3 days, got %v", matches)
+	}
+
+	if _, code := runCmd(t, "prune", filename); code != 1 {
+		t.Fatalf("prune without a retention: exit code %d", code)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if _, code := runCmd(t); code != 2 {
+		t.Fatalf("exit code %d", code)
+	}
+
+	if _, code := runCmd(t, "frobnicate"); code != 2 {
+		t.Fatalf("exit code %d", code)
+	}
+
+	if _, code := runCmd(t, "list"); code != 1 {
+		t.Fatalf("list without a log file: exit code %d", code)
+	}
+}
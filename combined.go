@@ -0,0 +1,236 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OpenCombined returns a reader of the whole log: the backups, oldest first,
+// followed by the active log file, so that support tooling can stream
+// everything the Logger kept without parsing the backup names itself.
+// Compressed backups are decompressed, and encrypted ones decrypted with
+// EncryptKey.  Data written so far is flushed, so the reader sees it, but
+// data written after OpenCombined returns may or may not be read.
+//
+// Backups are opened one at a time while reading.  A backup compressed in
+// the meantime is read from the compressed file, and a backup removed in the
+// meantime, e.g. because of MaxBackups, is skipped.  The active file is
+// opened right away, so that a rotation while reading doesn't lose it.  The
+// reader must be closed.
+func (l *Logger) OpenCombined() (io.ReadCloser, error) {
+	l.drainAsync()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Plain backups are finalized with this suffix, possibly while reading.
+	suffix, _, err := l.finalizer(l.Compress)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &combinedReader{fs: l.fs(), key: l.EncryptKey, suffix: suffix}
+
+	for i := len(files) - 1; i >= 0; i-- {
+		c.backups = append(c.backups, filepath.Join(l.backupDir(), files[i].Name()))
+	}
+
+	if l.file != nil {
+		if err := l.flush(); err != nil {
+			return nil, err
+		}
+
+		if gz, ok := l.file.(*gzipFile); ok {
+			if err := gz.gz.Flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	c.activeName = l.activeName()
+
+	c.active, err = c.fs.OpenFile(c.activeName, os.O_RDONLY, 0)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// combinedReader reads the files of OpenCombined one after the other.
+type combinedReader struct {
+	fs     FS
+	key    []byte
+	suffix string
+
+	// backups are the backups left to read, oldest first.
+	backups []string
+
+	active     File
+	activeName string
+
+	// f is the file being read through r.
+	f File
+	r io.ReadCloser
+
+	closed bool
+}
+
+func (c *combinedReader) Read(p []byte) (int, error) {
+	if c.closed {
+		return 0, os.ErrClosed
+	}
+
+	for {
+		if c.r == nil {
+			if err := c.next(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := c.r.Read(p)
+		if err == io.EOF {
+			c.closeCurrent()
+
+			if n == 0 {
+				continue
+			}
+
+			err = nil
+		}
+
+		return n, err
+	}
+}
+
+// next opens the next file to read, or returns io.EOF if all were read.
+func (c *combinedReader) next() error {
+	for len(c.backups) > 0 {
+		name := c.backups[0]
+		c.backups = c.backups[1:]
+
+		f, err := c.fs.OpenFile(name, os.O_RDONLY, 0)
+		if os.IsNotExist(err) && backupSuffix(name) == "" && c.suffix != "" {
+			// The backup was finalized since it was listed.
+			name += c.suffix
+			f, err = c.fs.OpenFile(name, os.O_RDONLY, 0)
+		}
+
+		if os.IsNotExist(err) {
+			// The backup was removed since it was listed.
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return c.open(f, name, false)
+	}
+
+	if c.active != nil {
+		f := c.active
+		c.active = nil
+
+		return c.open(f, c.activeName, true)
+	}
+
+	return io.EOF
+}
+
+// open starts reading f, named name.  An active file written with
+// CompressActive ends in an unterminated gzip stream, which is read up to the
+// data last flushed.
+func (c *combinedReader) open(f File, name string, active bool) error {
+	r, err := newBackupReader(f, name, c.key)
+	if err != nil {
+		f.Close()
+
+		return fmt.Errorf("can't read %s: %v", name, err)
+	}
+
+	if active {
+		r = unterminatedReader{r}
+	}
+
+	c.f, c.r = f, r
+
+	return nil
+}
+
+func (c *combinedReader) closeCurrent() {
+	if c.r != nil {
+		c.r.Close()
+		c.f.Close()
+		c.f, c.r = nil, nil
+	}
+}
+
+func (c *combinedReader) Close() error {
+	if c.closed {
+		return os.ErrClosed
+	}
+
+	c.closed = true
+	c.closeCurrent()
+
+	if c.active != nil {
+		return c.active.Close()
+	}
+
+	return nil
+}
+
+// newBackupReader returns a reader of the content of a log file read from r,
+// named name, which is decrypted with key and decompressed according to the
+// suffixes of the name.
+func newBackupReader(r io.Reader, name string, key []byte) (io.ReadCloser, error) {
+	if strings.HasSuffix(name, encryptSuffix) {
+		if len(key) == 0 {
+			return nil, errors.New("backup is encrypted, but EncryptKey is not set")
+		}
+
+		var err error
+		if r, err = NewDecryptReader(r, key); err != nil {
+			return nil, err
+		}
+
+		name = strings.TrimSuffix(name, encryptSuffix)
+	}
+
+	suffix := compressedSuffix(name)
+	if suffix == "" {
+		return io.NopCloser(r), nil
+	}
+
+	for _, c := range codecs {
+		if c.suffix == suffix {
+			return c.newReader(r)
+		}
+	}
+
+	return nil, fmt.Errorf("unknown compression of %s", name)
+}
+
+// unterminatedReader ends a stream cut off after its last complete block.
+type unterminatedReader struct {
+	io.ReadCloser
+}
+
+func (u unterminatedReader) Read(p []byte) (int, error) {
+	n, err := u.ReadCloser.Read(p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+
+	return n, err
+}
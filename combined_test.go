@@ -0,0 +1,119 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+)
+
+// readCombined returns everything read from OpenCombined.
+func readCombined(t testing.TB, l *Logger) string {
+	t.Helper()
+
+	r, err := l.OpenCombined()
+	isNil(t, err)
+
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	isNil(t, err)
+
+	return string(b)
+}
+
+func TestOpenCombined(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestOpenCombined")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		Compress:   true,
+		EncryptKey: testKey,
+		BufferSize: 1000,
+	}
+	defer l.Close()
+
+	for _, s := range []string{"one\n", "two\n"} {
+		_, err := l.Write([]byte(s))
+		isNil(t, err)
+
+		newFakeTime()
+		isNil(t, l.Rotate())
+	}
+
+	isNil(t, l.waitMill(context.Background()))
+
+	// The last record is still buffered.
+	_, err := l.Write([]byte("three\n"))
+	isNil(t, err)
+
+	equals(t, "one\ntwo\nthree\n", readCombined(t, l))
+
+	// Without a key, the encrypted backups can't be read.
+	l.mu.Lock()
+	l.EncryptKey = nil
+	l.mu.Unlock()
+
+	r, err := l.OpenCombined()
+	isNil(t, err)
+
+	defer r.Close()
+
+	_, err = io.ReadAll(r)
+	notNil(t, err)
+}
+
+func TestOpenCombinedPending(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestOpenCombinedPending")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		CompressActive: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.waitMill(context.Background()))
+
+	_, err = l.Write([]byte("two\n"))
+	isNil(t, err)
+
+	r, err := l.OpenCombined()
+	isNil(t, err)
+
+	defer r.Close()
+
+	// The backup is removed before it is read, and a rotation doesn't take
+	// the active file away.
+	isNil(t, l.PurgeAll())
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	b, err := io.ReadAll(r)
+	isNil(t, err)
+	equals(t, "two\n", string(b))
+
+	isNil(t, r.Close())
+	equals(t, os.ErrClosed, r.Close())
+}
+
+func TestOpenCombinedEmpty(t *testing.T) {
+	dir := makeTempDir(t, "TestOpenCombinedEmpty")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+
+	equals(t, "", readCombined(t, l))
+}
@@ -0,0 +1,394 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+)
+
+// CompressionFormat selects the algorithm used to compress rotated log files.
+type CompressionFormat string
+
+const (
+	// CompressionGzip compresses backups with gzip and the ".gz" suffix.
+	CompressionGzip CompressionFormat = "gzip"
+
+	// CompressionZstd compresses backups with Zstandard and the ".zst" suffix.
+	// It is considerably faster than gzip and usually compresses logs better.
+	CompressionZstd CompressionFormat = "zstd"
+
+	// CompressionXz compresses backups with xz and the ".xz" suffix.  It is
+	// much slower than the other formats, but compresses best, for backups
+	// which are archived for a long time.  It requires the xz command.
+	CompressionXz CompressionFormat = "xz"
+)
+
+const zstdSuffix = ".zst"
+
+// pgzipBlockSize is the size of the blocks compressed in parallel for
+// CompressConcurrency.
+const pgzipBlockSize = 1 << 20
+
+// codec describes how backups are compressed in a given format, and how they
+// are decompressed when read back.
+type codec struct {
+	suffix    string
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// codecs holds the supported compression formats.
+var codecs = map[CompressionFormat]codec{
+	CompressionGzip: {
+		suffix:    compressSuffix,
+		newWriter: newGzipWriter,
+		newReader: newGzipReader,
+	},
+	CompressionZstd: {
+		suffix:    zstdSuffix,
+		newWriter: newZstdEncoder,
+		newReader: newZstdReader,
+	},
+	CompressionXz: {
+		suffix:    xzSuffix,
+		newWriter: newXzWriter,
+		newReader: newXzReader,
+	},
+}
+
+func newGzipReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func newZstdReader(r io.Reader) (io.ReadCloser, error) {
+	dec, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return dec.IOReadCloser(), nil
+}
+
+// codec returns the codec for the Logger's CompressionFormat and
+// CompressConcurrency.
+func (l *Logger) codec() (codec, error) {
+	format := l.CompressionFormat
+	if format == "" {
+		format = CompressionGzip
+	}
+
+	c, ok := codecs[format]
+	if !ok {
+		return codec{}, fmt.Errorf("unknown CompressionFormat %q", l.CompressionFormat)
+	}
+
+	n := l.CompressConcurrency
+
+	switch {
+	case format == CompressionGzip && n > 1:
+		c.newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			gz := pgzip.NewWriter(w)
+
+			return gz, gz.SetConcurrency(pgzipBlockSize, n)
+		}
+	case format == CompressionZstd && n > 0:
+		c.newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w, zstd.WithEncoderConcurrency(n))
+		}
+	}
+
+	return c, nil
+}
+
+// checkCompression reports an error if compression is enabled with an unknown
+// format, so that the misconfiguration surfaces on Write instead of silently
+// failing in the mill goroutine.
+func (l *Logger) checkCompression() error {
+	if !l.Compress {
+		return nil
+	}
+
+	if _, err := l.codec(); err != nil {
+		return err
+	}
+
+	if l.CompressionFormat == CompressionXz {
+		return checkXz()
+	}
+
+	return nil
+}
+
+// finalizer returns the suffix and writer for finalizing backups: compressed
+// if compress is set, and then encrypted if EncryptKey is set.  The mill
+// passes Compress.
+func (l *Logger) finalizer(compress bool) (string, func(io.Writer) (io.WriteCloser, error), error) {
+	c := codec{
+		newWriter: func(w io.Writer) (io.WriteCloser, error) { return nopWriteCloser{w}, nil },
+	}
+
+	if compress {
+		var err error
+		if c, err = l.codec(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if !l.encrypts() {
+		return c.suffix, c.newWriter, nil
+	}
+
+	key := l.EncryptKey
+	newWriter := func(w io.Writer) (io.WriteCloser, error) {
+		ew, err := newEncryptWriter(w, key)
+		if err != nil {
+			return nil, err
+		}
+
+		cw, err := c.newWriter(ew)
+		if err != nil {
+			return nil, err
+		}
+
+		return chainedWriteCloser{cw, ew}, nil
+	}
+
+	return c.suffix + encryptSuffix, newWriter, nil
+}
+
+// nopWriteCloser adds a Close method doing nothing to a writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// chainedWriteCloser writes to the first of its writers, which writes into the
+// next one, and closes all of them in order.
+type chainedWriteCloser []io.WriteCloser
+
+func (c chainedWriteCloser) Write(p []byte) (int, error) {
+	return c[0].Write(p)
+}
+
+func (c chainedWriteCloser) Close() error {
+	for _, w := range c {
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compressedSuffix returns the suffix of a compressed backup filename, or ""
+// if the name does not end in the suffix of any supported format.
+func compressedSuffix(name string) string {
+	for _, c := range codecs {
+		if strings.HasSuffix(name, c.suffix) {
+			return c.suffix
+		}
+	}
+
+	return ""
+}
+
+// compressLogFile compresses the given log file with the writer returned by
+// newWriter, feeding it in chunks of bufSize bytes, and removes the
+// uncompressed log file if successful.  The compressed file gets the owner of
+// the log file if preserveOwner is set.  If ctx is canceled, compression is
+// aborted and the partial compressed file removed.
+func compressLogFile(
+	ctx context.Context, fs FS, src, dst string,
+	newWriter func(io.Writer) (io.WriteCloser, error), bufSize int, preserveOwner bool,
+) (res CompressionResult, err error) {
+	start := time.Now()
+
+	f, err := fs.OpenFile(src, os.O_RDONLY, 0)
+	if err != nil {
+		return res, fmt.Errorf("failed to open log file: %v", err)
+	}
+
+	defer f.Close()
+
+	fi, err := fs.Stat(src)
+	if err != nil {
+		return res, fmt.Errorf("failed to stat log file: %v", err)
+	}
+
+	if preserveOwner {
+		if err := chown(fs, dst, fi); err != nil {
+			return res, fmt.Errorf("failed to chown compressed log file: %v", err)
+		}
+	}
+
+	// If this file already exists, we presume it was created by
+	// a previous attempt to compress the log file.
+	gzf, err := fs.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return res, fmt.Errorf("failed to open compressed log file: %v", err)
+	}
+
+	defer gzf.Close()
+
+	out := &countingWriter{w: gzf}
+
+	defer func() {
+		if err != nil {
+			fs.Remove(dst)
+
+			err = fmt.Errorf("failed to compress log file: %v", err)
+		}
+	}()
+
+	gz, err := newWriter(out)
+	if err != nil {
+		return res, err
+	}
+
+	buf := getCopyBuffer(bufSize)
+	defer copyBuffers.Put(buf)
+
+	in, err := io.CopyBuffer(gz, ctxReader{ctx, f}, *buf)
+	if err != nil {
+		gz.Close()
+
+		return res, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return res, err
+	}
+
+	if err := gzf.Close(); err != nil {
+		return res, err
+	}
+
+	if err := f.Close(); err != nil {
+		return res, err
+	}
+
+	if err := fs.Remove(src); err != nil {
+		return res, err
+	}
+
+	return CompressionResult{
+		Path:        dst,
+		InputBytes:  in,
+		OutputBytes: out.n,
+		Duration:    time.Since(start),
+	}, nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// CompressExisting compresses all uncompressed backups now, for admin
+// endpoints and cron jobs which reclaim disk space outside of the write path.
+// It returns once they are compressed, or with ctx's error once ctx is
+// canceled.
+//
+// Backups are compressed with CompressionFormat, and encrypted if EncryptKey
+// is set, even if Compress is not set.  MaxUncompressedBackups is ignored, but
+// backups which have not been reported to OnRotate yet are left to the mill.
+func (l *Logger) CompressExisting(ctx context.Context) error {
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+
+	unlock, err := l.lockMill()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	var plain []logInfo
+
+	for _, f := range files {
+		if backupSuffix(f.Name()) == "" {
+			plain = append(plain, f)
+		}
+	}
+
+	if err := l.compressBackups(ctx, l.unreported(plain), true); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// compressDelayed reports whether the backup f is too young to be compressed,
+// see CompressAfter.
+func (l *Logger) compressDelayed(f logInfo) bool {
+	return l.CompressAfter > 0 && l.now().Before(f.timestamp.Add(l.CompressAfter))
+}
+
+// scheduleCompression arms a timer running the mill when the first of the
+// plain backups among files which is too young to be compressed reaches
+// CompressAfter.  It is run by the mill goroutine.
+func (l *Logger) scheduleCompression(files []logInfo) {
+	if l.CompressAfter <= 0 || !(l.Compress || l.encrypts()) {
+		return
+	}
+
+	now := l.now()
+
+	var next time.Time
+
+	for _, f := range files {
+		if backupSuffix(f.Name()) != "" || !l.compressDelayed(f) {
+			continue
+		}
+
+		if due := f.timestamp.Add(l.CompressAfter); next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+
+	if next.IsZero() {
+		return
+	}
+
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	if l.compressTimer != nil {
+		l.compressTimer.Stop()
+	}
+
+	l.compressTimer = time.AfterFunc(next.Sub(now), l.timedCompress)
+}
+
+// timedCompress is run by the compress timer.  It has the mill compress the
+// backups which reached CompressAfter, unless the Logger was closed.
+func (l *Logger) timedCompress() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		l.mill()
+	}
+}
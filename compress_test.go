@@ -0,0 +1,431 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompressZstd(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressZstd")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Compress:          true,
+		CompressionFormat: CompressionZstd,
+		Filename:          filename,
+		MaxBytes:          10,
+		MaxBackups:        1,
+	}
+	defer l.Close()
+
+	// A gzipped backup from before the format was changed still counts
+	// towards MaxBackups.
+	gzBackup := backupFile(dir) + compressSuffix
+	err := os.WriteFile(gzBackup, []byte("gzipped"), fileModeNew)
+	isNil(t, err)
+
+	newFakeTime()
+
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+
+	err = l.Rotate()
+	isNil(t, err)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	compressed, err := os.ReadFile(backupFile(dir) + zstdSuffix)
+	isNil(t, err)
+
+	dec, err := zstd.NewReader(nil)
+	isNil(t, err)
+	defer dec.Close()
+
+	content, err := dec.DecodeAll(compressed, nil)
+	isNil(t, err)
+	equals(t, b, content)
+
+	notExist(t, backupFile(dir))
+	notExist(t, gzBackup)
+	fileCount(t, dir, 2)
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 1, len(files))
+	equals(t, true, l.backupInfo(files[0]).Compressed)
+}
+
+func TestCompressConcurrency(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressConcurrency")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Compress:            true,
+		CompressConcurrency: 4,
+		Filename:            logFile(dir),
+		MaxBytes:            4 * pgzipBlockSize,
+	}
+	defer l.Close()
+
+	// Spread the backup across several blocks.
+	b := bytes.Repeat([]byte("boo!\n"), 3*pgzipBlockSize/5)
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+
+	err = l.Rotate()
+	isNil(t, err)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	f, err := os.Open(backupFile(dir) + compressSuffix)
+	isNil(t, err)
+	defer f.Close()
+
+	// The backup can be read by any gzip reader.
+	gz, err := gzip.NewReader(f)
+	isNil(t, err)
+
+	content, err := io.ReadAll(gz)
+	isNil(t, err)
+	equals(t, true, bytes.Equal(b, content))
+	notExist(t, backupFile(dir))
+}
+
+func TestCompressWorkers(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressWorkers")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Compress:        true,
+		CompressWorkers: 3,
+		Filename:        logFile(dir),
+	}
+	defer l.Close()
+
+	// More backups are pending than there are workers.
+	var backups []string
+
+	for i := 0; i < 5; i++ {
+		newFakeTime()
+
+		backup := backupFile(dir)
+		err := os.WriteFile(backup, []byte("foo!"), fileModeNew)
+		isNil(t, err)
+
+		backups = append(backups, backup)
+	}
+
+	newFakeTime()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	isNil(t, l.CloseAndWait())
+
+	for _, backup := range backups {
+		exists(t, backup+compressSuffix)
+		notExist(t, backup)
+	}
+
+	fileCount(t, dir, 6)
+	equals(t, int64(5), l.CompressionStats().Files)
+}
+
+func TestCompressBufferSize(t *testing.T) {
+	currentTime = fakeTime
+
+	readers := map[CompressionFormat]func(io.Reader) (io.Reader, error){
+		CompressionGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		CompressionZstd: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+
+	for format, newReader := range readers {
+		dir := makeTempDir(t, "TestCompressBufferSize")
+		defer os.RemoveAll(dir)
+
+		// Each backup is fed in several chunks, and the pooled compressors
+		// are reused across backups.
+		contents := make(map[string][]byte)
+
+		for i := 0; i < 4; i++ {
+			newFakeTime()
+
+			backup := backupFile(dir)
+			content := bytes.Repeat([]byte(fmt.Sprintf("backup %d\n", i)), 10+i)
+			err := os.WriteFile(backup, content, fileModeNew)
+			isNil(t, err)
+
+			contents[backup] = content
+		}
+
+		newFakeTime()
+
+		l := &Logger{
+			Compress:           true,
+			CompressionFormat:  format,
+			CompressBufferSize: 7,
+			CompressWorkers:    2,
+			Filename:           logFile(dir),
+		}
+		defer l.Close()
+
+		_, err := l.Write([]byte("boo!"))
+		isNil(t, err)
+		isNil(t, l.CloseAndWait())
+
+		suffix := codecs[format].suffix
+
+		for backup, content := range contents {
+			f, err := os.Open(backup + suffix)
+			isNil(t, err)
+
+			r, err := newReader(f)
+			isNil(t, err)
+
+			got, err := io.ReadAll(r)
+			isNil(t, err)
+			f.Close()
+
+			equals(t, string(content), string(got))
+			notExist(t, backup)
+		}
+	}
+}
+
+func TestCompressExisting(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressExisting")
+	defer os.RemoveAll(dir)
+
+	var backups []string
+
+	for i := 0; i < 2; i++ {
+		newFakeTime()
+
+		backup := backupFile(dir)
+		err := os.WriteFile(backup, []byte("foo!"), fileModeNew)
+		isNil(t, err)
+
+		backups = append(backups, backup)
+	}
+
+	newFakeTime()
+
+	// The backups are compressed on demand, even though Compress isn't set.
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	isNil(t, l.CompressExisting(context.Background()))
+
+	for _, backup := range backups {
+		exists(t, backup+compressSuffix)
+		notExist(t, backup)
+	}
+
+	fileCount(t, dir, 3)
+	equals(t, int64(2), l.CompressionStats().Files)
+
+	// Nothing is compressed once ctx is canceled.
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = l.CompressExisting(ctx)
+	equals(t, context.Canceled, err)
+	exists(t, backupFile(dir))
+}
+
+func TestCompressXz(t *testing.T) {
+	if _, err := exec.LookPath(xzCommand); err != nil {
+		t.Skip("no xz command available")
+	}
+
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressXz")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Compress:          true,
+		CompressionFormat: CompressionXz,
+		Filename:          logFile(dir),
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+
+	err = l.Rotate()
+	isNil(t, err)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	content, err := exec.Command(xzCommand, "--decompress", "--stdout", backupFile(dir)+xzSuffix).Output()
+	isNil(t, err)
+	equals(t, b, content)
+	notExist(t, backupFile(dir))
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 1, len(files))
+	equals(t, true, l.backupInfo(files[0]).Compressed)
+
+	// The backup can be read back.
+	equals(t, "boo!", readCombined(t, l))
+}
+
+func TestCompressXzMissing(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressXzMissing")
+	defer os.RemoveAll(dir)
+
+	defer func(cmd string) { xzCommand = cmd }(xzCommand)
+	xzCommand = "lumberjack-missing-xz"
+
+	l := &Logger{
+		Compress:          true,
+		CompressionFormat: CompressionXz,
+		Filename:          logFile(dir),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, true, strings.HasPrefix(err.Error(), "CompressionXz requires the xz command: "))
+	fileCount(t, dir, 0)
+}
+
+func TestCompressUnknownFormat(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressUnknownFormat")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Compress:          true,
+		CompressionFormat: "lz4",
+		Filename:          logFile(dir),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, `unknown CompressionFormat "lz4"`, err.Error())
+	fileCount(t, dir, 0)
+
+	// The format does not matter if compression is disabled.
+	l.Compress = false
+	_, err = l.Write([]byte("boo!"))
+	isNil(t, err)
+}
+
+func TestCompressAfter(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressAfter")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		Compress:      true,
+		CompressAfter: time.Hour,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	// The backup is left plain until it is an hour old.
+	backup := backupFile(dir)
+	existsWithContent(t, backup, b)
+	notExist(t, backup+compressSuffix)
+
+	fakeCurrentTime = fakeCurrentTime.Add(2 * time.Hour)
+
+	_, err = l.Write(b)
+	isNil(t, err)
+	isNil(t, l.CompressExisting(context.Background()))
+	notExist(t, backup)
+	exists(t, backup+compressSuffix)
+}
+
+func TestCompressAfterTimer(t *testing.T) {
+	currentTime = time.Now
+
+	dir := makeTempDir(t, "TestCompressAfterTimer")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		Compress:      true,
+		CompressAfter: 50 * time.Millisecond,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+	isNil(t, l.Rotate())
+
+	// The scheduled cleanup compresses the backup while the Logger is idle.
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		matches, err := filepath.Glob(filepath.Join(dir, "*"+compressSuffix))
+		isNil(t, err)
+
+		if len(matches) == 1 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the backup wasn't compressed")
+		}
+	}
+
+	isNil(t, l.CloseAndWait())
+	fileCount(t, dir, 2)
+}
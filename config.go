@@ -0,0 +1,452 @@
+package lumberjack
+
+import "time"
+
+// Config holds the rotation and retention settings of a Logger. Its fields
+// have the same meaning as the Logger fields of the same name and accept the
+// same JSON, YAML and TOML keys.
+type Config struct {
+	// Compress determines if the rotated log files should be compressed.
+	Compress bool `json:"compress" yaml:"compress"`
+
+	// CompressionFormat is the format used to compress rotated log files.
+	CompressionFormat CompressionFormat `json:"compressionformat" yaml:"compressionformat"`
+
+	// CompressConcurrency is the number of goroutines compressing a backup.
+	CompressConcurrency int `json:"compressconcurrency" yaml:"compressconcurrency"`
+
+	// CompressWorkers is the number of backups compressed at the same time.
+	CompressWorkers int `json:"compressworkers" yaml:"compressworkers"`
+
+	// CompressBufferSize is the size in bytes of the chunks in which backups
+	// are fed to the compressor.
+	CompressBufferSize int `json:"compressbuffersize" yaml:"compressbuffersize"`
+
+	// CompressActive determines if the active file is written as a gzip
+	// stream.
+	CompressActive bool `json:"compressactive" yaml:"compressactive"`
+
+	// CompressAfter is the minimum age of a backup before it is compressed.
+	CompressAfter time.Duration `json:"compressafter" yaml:"compressafter"`
+
+	// EncryptKey is a 32 byte key used to encrypt backups.
+	EncryptKey []byte `json:"encryptkey" yaml:"encryptkey"`
+
+	// Filename is the file to write logs to.
+	Filename string `json:"filename" yaml:"filename"`
+
+	// FilenameDateLayout is a time layout naming the active log file after the
+	// current period.
+	FilenameDateLayout string `json:"filenamedatelayout" yaml:"filenamedatelayout"`
+
+	// MaxAge is the maximum number of days to retain old log files.
+	MaxAge int `json:"maxage" yaml:"maxage"`
+
+	// MaxBackups is the maximum number of old log files to retain.
+	MaxBackups int `json:"maxbackups" yaml:"maxbackups"`
+
+	// MaxUncompressedBackups is the number of the most recent backups kept
+	// uncompressed.
+	MaxUncompressedBackups int `json:"maxuncompressedbackups" yaml:"maxuncompressedbackups"`
+
+	// MaxCompressedBackups is the maximum number of compressed backups to
+	// retain.
+	MaxCompressedBackups int `json:"maxcompressedbackups" yaml:"maxcompressedbackups"`
+
+	// MaxBytes is the maximum size in bytes of the log file before it gets
+	// rotated.
+	MaxBytes ByteSize `json:"maxbytes" yaml:"maxbytes"`
+
+	// Deprecated: use MaxBytes instead.
+	// MaxSize is the maximum size in megabytes of the log file before it gets
+	// rotated.
+	MaxSize int `json:"maxsize" yaml:"maxsize"`
+
+	// MaxTotalBytes is the maximum combined size in bytes of the active log
+	// file and all backups.
+	MaxTotalBytes ByteSize `json:"maxtotalbytes" yaml:"maxtotalbytes"`
+
+	// MinFreeBytes is the free space in bytes to keep on the filesystem
+	// holding the backups.
+	MinFreeBytes ByteSize `json:"minfreebytes" yaml:"minfreebytes"`
+
+	// MinFreePercent is the free space to keep on the filesystem holding the
+	// backups, as a percentage of its size.
+	MinFreePercent int `json:"minfreepercent" yaml:"minfreepercent"`
+
+	// DiskCheckInterval is the interval at which the free space is checked.
+	DiskCheckInterval time.Duration `json:"diskcheckinterval" yaml:"diskcheckinterval"`
+
+	// CompressOnLowDisk determines if uncompressed backups are compressed
+	// before any are deleted to free disk space.
+	CompressOnLowDisk bool `json:"compressonlowdisk" yaml:"compressonlowdisk"`
+
+	// JanitorInterval is the interval at which old log files are cleaned up
+	// while the log file is open.
+	JanitorInterval time.Duration `json:"janitorinterval" yaml:"janitorinterval"`
+
+	// LocalTime determines if the time used for formatting the timestamps in
+	// backup files is the computer's local time.
+	LocalTime bool `json:"localtime" yaml:"localtime"`
+
+	// Location is the time zone used for formatting the timestamps in backup
+	// files.  It is set by FromEnv and Set from a zone name.
+	Location *time.Location `json:"-" yaml:"-"`
+
+	// RotationInterval is the maximum amount of time a log file is written to
+	// before it gets rotated.
+	RotationInterval time.Duration `json:"rotationinterval" yaml:"rotationinterval"`
+
+	// RotateAt is a time of day in the form "HH:MM" at which the log file gets
+	// rotated.
+	RotateAt string `json:"rotateat" yaml:"rotateat"`
+
+	// RotateOnStart determines if an existing non-empty log file is rotated
+	// when the Logger first opens it.
+	RotateOnStart bool `json:"rotateonstart" yaml:"rotateonstart"`
+
+	// OpenMode selects what happens to the contents of an existing log file
+	// when the Logger first opens it.
+	OpenMode OpenMode `json:"openmode" yaml:"openmode"`
+
+	// BackupDir is the directory rotated log files are moved to.
+	BackupDir string `json:"backupdir" yaml:"backupdir"`
+
+	// BufferSize is the size in bytes of a buffer coalescing small writes.
+	BufferSize int `json:"buffersize" yaml:"buffersize"`
+
+	// FlushInterval is the maximum amount of time data is held in the buffer.
+	FlushInterval time.Duration `json:"flushinterval" yaml:"flushinterval"`
+
+	// AsyncBufferSize is the size in bytes of a queue which makes Write never
+	// block on I/O.
+	AsyncBufferSize int `json:"asyncbuffersize" yaml:"asyncbuffersize"`
+
+	// FallbackRetryInterval is the time for which writes go to the Fallback
+	// of the Logger before the log file is tried again.
+	FallbackRetryInterval time.Duration `json:"fallbackretryinterval" yaml:"fallbackretryinterval"`
+
+	// WriteRetries is the number of times a write failing with a transient
+	// error is retried.
+	WriteRetries int `json:"writeretries" yaml:"writeretries"`
+
+	// WriteRetryDelay is the delay before the first retry of a write.
+	WriteRetryDelay time.Duration `json:"writeretrydelay" yaml:"writeretrydelay"`
+
+	// PreserveOwner determines if new files get the owner of the files they
+	// replace.  Nil means true.
+	PreserveOwner *bool `json:"preserveowner" yaml:"preserveowner"`
+
+	// SyncInterval is the interval at which the active file is committed to
+	// stable storage.
+	SyncInterval time.Duration `json:"syncinterval" yaml:"syncinterval"`
+
+	// SyncEveryWrite determines if every record is committed to stable
+	// storage before Write returns.
+	SyncEveryWrite bool `json:"synceverywrite" yaml:"synceverywrite"`
+
+	// NamingScheme selects how backup files are named.
+	NamingScheme NamingScheme `json:"namingscheme" yaml:"namingscheme"`
+
+	// BackupNameTemplate is a text/template controlling how backup files are
+	// named.
+	BackupNameTemplate string `json:"backupnametemplate" yaml:"backupnametemplate"`
+
+	// TimestampPrecision selects the precision of the timestamps in backup
+	// names.
+	TimestampPrecision TimestampPrecision `json:"timestampprecision" yaml:"timestampprecision"`
+
+	// Preallocate determines if disk space for MaxBytes is reserved when a
+	// log file is opened.
+	Preallocate bool `json:"preallocate" yaml:"preallocate"`
+
+	// LargeWritePolicy selects how Write handles a record larger than
+	// MaxBytes.
+	LargeWritePolicy LargeWritePolicy `json:"largewritepolicy" yaml:"largewritepolicy"`
+
+	// MaxRecordBytes is the maximum length of a record.
+	MaxRecordBytes int `json:"maxrecordbytes" yaml:"maxrecordbytes"`
+
+	// MaxRecordPolicy selects how Write handles a record longer than
+	// MaxRecordBytes.
+	MaxRecordPolicy MaxRecordPolicy `json:"maxrecordpolicy" yaml:"maxrecordpolicy"`
+
+	// AppendNewline determines if a newline is appended to records which
+	// don't end with one.
+	AppendNewline bool `json:"appendnewline" yaml:"appendnewline"`
+
+	// StripANSI determines if ANSI escape sequences are removed from
+	// records.
+	StripANSI bool `json:"stripansi" yaml:"stripansi"`
+
+	// MaxBytesPerSecond limits the rate at which records are written.
+	MaxBytesPerSecond ByteSize `json:"maxbytespersecond" yaml:"maxbytespersecond"`
+
+	// RateLimitPolicy selects what happens to records exceeding
+	// MaxBytesPerSecond.
+	RateLimitPolicy RateLimitPolicy `json:"ratelimitpolicy" yaml:"ratelimitpolicy"`
+
+	// LockMode selects how the Logger coordinates with other processes using
+	// the same Filename.
+	LockMode LockMode `json:"lockmode" yaml:"lockmode"`
+
+	// SymlinkName is the path of a symlink kept pointing at the active log
+	// file.
+	SymlinkName string `json:"symlinkname" yaml:"symlinkname"`
+
+	// FollowName determines if a removed or renamed log file is replaced by a
+	// new one.
+	FollowName bool `json:"followname" yaml:"followname"`
+
+	// PrevSymlink determines if a symlink is kept pointing at the newest
+	// backup.
+	PrevSymlink bool `json:"prevsymlink" yaml:"prevsymlink"`
+
+	// PostRotateCommand is a program and its arguments run after every
+	// rotation with the path of the new backup.
+	PostRotateCommand []string `json:"postrotatecommand" yaml:"postrotatecommand"`
+
+	// PostRotateTimeout is the maximum amount of time PostRotateCommand may
+	// run.
+	PostRotateTimeout time.Duration `json:"postrotatetimeout" yaml:"postrotatetimeout"`
+
+	// ArchiveErrorPolicy selects what happens to a backup whose upload failed.
+	ArchiveErrorPolicy ArchiveErrorPolicy `json:"archiveerrorpolicy" yaml:"archiveerrorpolicy"`
+}
+
+// config returns the Logger's current settings. The caller is responsible for
+// holding l.mu if the Logger is in use.
+func (l *Logger) config() Config {
+	return Config{
+		Compress:               l.Compress,
+		CompressionFormat:      l.CompressionFormat,
+		CompressConcurrency:    l.CompressConcurrency,
+		CompressWorkers:        l.CompressWorkers,
+		CompressBufferSize:     l.CompressBufferSize,
+		CompressActive:         l.CompressActive,
+		CompressAfter:          l.CompressAfter,
+		EncryptKey:             l.EncryptKey,
+		Filename:               l.Filename,
+		FilenameDateLayout:     l.FilenameDateLayout,
+		MaxAge:                 l.MaxAge,
+		MaxBackups:             l.MaxBackups,
+		MaxUncompressedBackups: l.MaxUncompressedBackups,
+		MaxCompressedBackups:   l.MaxCompressedBackups,
+		MaxBytes:               ByteSize(l.MaxBytes),
+		MaxSize:                l.MaxSize,
+		MaxTotalBytes:          ByteSize(l.MaxTotalBytes),
+		MinFreeBytes:           ByteSize(l.MinFreeBytes),
+		MinFreePercent:         l.MinFreePercent,
+		DiskCheckInterval:      l.DiskCheckInterval,
+		CompressOnLowDisk:      l.CompressOnLowDisk,
+		JanitorInterval:        l.JanitorInterval,
+		LocalTime:              l.LocalTime,
+		Location:               l.Location,
+		RotationInterval:       l.RotationInterval,
+		RotateAt:               l.RotateAt,
+		RotateOnStart:          l.RotateOnStart,
+		OpenMode:               l.OpenMode,
+		BackupDir:              l.BackupDir,
+		BufferSize:             l.BufferSize,
+		FlushInterval:          l.FlushInterval,
+		AsyncBufferSize:        l.AsyncBufferSize,
+		FallbackRetryInterval:  l.FallbackRetryInterval,
+		WriteRetries:           l.WriteRetries,
+		WriteRetryDelay:        l.WriteRetryDelay,
+		PreserveOwner:          l.PreserveOwner,
+		SyncInterval:           l.SyncInterval,
+		SyncEveryWrite:         l.SyncEveryWrite,
+		NamingScheme:           l.NamingScheme,
+		BackupNameTemplate:     l.BackupNameTemplate,
+		TimestampPrecision:     l.TimestampPrecision,
+		Preallocate:            l.Preallocate,
+		LargeWritePolicy:       l.LargeWritePolicy,
+		MaxRecordBytes:         l.MaxRecordBytes,
+		MaxRecordPolicy:        l.MaxRecordPolicy,
+		AppendNewline:          l.AppendNewline,
+		StripANSI:              l.StripANSI,
+		MaxBytesPerSecond:      ByteSize(l.MaxBytesPerSecond),
+		RateLimitPolicy:        l.RateLimitPolicy,
+		LockMode:               l.LockMode,
+		SymlinkName:            l.SymlinkName,
+		FollowName:             l.FollowName,
+		PrevSymlink:            l.PrevSymlink,
+		PostRotateCommand:      l.PostRotateCommand,
+		PostRotateTimeout:      l.PostRotateTimeout,
+		ArchiveErrorPolicy:     l.ArchiveErrorPolicy,
+	}
+}
+
+// NewLogger returns an unopened Logger using the settings of c.  Loggers
+// created from the same Config share their rotation and retention settings.
+func (c Config) NewLogger() *Logger {
+	l := &Logger{}
+	c.apply(l)
+
+	return l
+}
+
+// apply sets the settings of l to those of c.
+func (c Config) apply(l *Logger) {
+	l.AsyncBufferSize = c.AsyncBufferSize
+	l.AppendNewline = c.AppendNewline
+	l.StripANSI = c.StripANSI
+	l.MaxRecordBytes = c.MaxRecordBytes
+	l.MaxRecordPolicy = c.MaxRecordPolicy
+	l.MaxBytesPerSecond = int64(c.MaxBytesPerSecond)
+	l.RateLimitPolicy = c.RateLimitPolicy
+	l.PostRotateCommand = c.PostRotateCommand
+	l.PostRotateTimeout = c.PostRotateTimeout
+
+	c.applyUpdate(l)
+}
+
+// applyUpdate sets the settings of l to those of c, except those which
+// UpdateConfig can't change, as they are read without l.mu.
+func (c Config) applyUpdate(l *Logger) {
+	l.Compress = c.Compress
+	l.CompressionFormat = c.CompressionFormat
+	l.CompressConcurrency = c.CompressConcurrency
+	l.CompressWorkers = c.CompressWorkers
+	l.CompressBufferSize = c.CompressBufferSize
+	l.CompressActive = c.CompressActive
+	l.CompressAfter = c.CompressAfter
+	l.EncryptKey = c.EncryptKey
+	l.Filename = c.Filename
+	l.FilenameDateLayout = c.FilenameDateLayout
+	l.MaxAge = c.MaxAge
+	l.MaxBackups = c.MaxBackups
+	l.MaxUncompressedBackups = c.MaxUncompressedBackups
+	l.MaxCompressedBackups = c.MaxCompressedBackups
+	l.MaxBytes = int64(c.MaxBytes)
+	l.MaxSize = c.MaxSize
+	l.MaxTotalBytes = int64(c.MaxTotalBytes)
+	l.MinFreeBytes = int64(c.MinFreeBytes)
+	l.MinFreePercent = c.MinFreePercent
+	l.DiskCheckInterval = c.DiskCheckInterval
+	l.CompressOnLowDisk = c.CompressOnLowDisk
+	l.JanitorInterval = c.JanitorInterval
+	l.LocalTime = c.LocalTime
+	l.Location = c.Location
+	l.RotationInterval = c.RotationInterval
+	l.RotateAt = c.RotateAt
+	l.RotateOnStart = c.RotateOnStart
+	l.OpenMode = c.OpenMode
+	l.BackupDir = c.BackupDir
+	l.BufferSize = c.BufferSize
+	l.FlushInterval = c.FlushInterval
+	l.FallbackRetryInterval = c.FallbackRetryInterval
+	l.WriteRetries = c.WriteRetries
+	l.WriteRetryDelay = c.WriteRetryDelay
+	l.PreserveOwner = c.PreserveOwner
+	l.SyncInterval = c.SyncInterval
+	l.SyncEveryWrite = c.SyncEveryWrite
+	l.NamingScheme = c.NamingScheme
+	l.BackupNameTemplate = c.BackupNameTemplate
+	l.TimestampPrecision = c.TimestampPrecision
+	l.Preallocate = c.Preallocate
+	l.LargeWritePolicy = c.LargeWritePolicy
+	l.LockMode = c.LockMode
+	l.SymlinkName = c.SymlinkName
+	l.FollowName = c.FollowName
+	l.PrevSymlink = c.PrevSymlink
+	l.ArchiveErrorPolicy = c.ArchiveErrorPolicy
+}
+
+// DiffRetention reports which existing backups would be deleted or compressed
+// by the next cleanup run under newCfg, but not under the Logger's current
+// configuration. No files are modified, so operators can review exactly what a
+// configuration change would destroy before applying it.
+func (l *Logger) DiffRetention(newCfg Config) (wouldDelete, wouldCompress []BackupInfo, err error) {
+	l.mu.Lock()
+	curLogger, newLogger := l.previewLogger(l.config()), l.previewLogger(newCfg)
+	l.mu.Unlock()
+
+	curRemove, curCompress, err := curLogger.plannedRetention()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newRemove, newCompress, err := newLogger.plannedRetention()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return backupsNotIn(newRemove, curRemove), backupsNotIn(newCompress, curCompress), nil
+}
+
+// previewLogger returns an unopened Logger with the settings of c, for
+// planning the cleanup of l's backups.  The settings of l which aren't part
+// of a Config, but decide which files are backups and how old they are, are
+// taken over from l.  It must be called with l.mu held, at least for reading.
+func (l *Logger) previewLogger(c Config) *Logger {
+	p := c.NewLogger()
+	p.FS = l.FS
+	p.Clock = l.Clock
+	p.BackupNameFunc = l.BackupNameFunc
+	p.ParseBackupName = l.ParseBackupName
+
+	return p
+}
+
+// plannedRetention lists the Logger's backups and returns the ones the next
+// cleanup run would remove and compress.  Removals due to MaxTotalBytes are
+// based on the current, possibly uncompressed, backup sizes.
+func (l *Logger) plannedRetention() (remove, compress []BackupInfo, err error) {
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rm, cmp := l.retention(files)
+
+	// Estimate the quota with the current sizes, as the compressed sizes are
+	// not known yet.
+	removed := make(map[string]bool, len(rm))
+	for _, f := range rm {
+		removed[f.Name()] = true
+	}
+
+	var kept []logInfo
+
+	for _, f := range files {
+		if !removed[f.Name()] {
+			kept = append(kept, f)
+		}
+	}
+
+	for _, f := range l.quota(kept, l.activeSize()) {
+		rm = append(rm, f)
+		removed[f.Name()] = true
+	}
+
+	for _, f := range rm {
+		remove = append(remove, l.backupInfo(f))
+	}
+
+	for _, f := range cmp {
+		if !removed[f.Name()] {
+			compress = append(compress, l.backupInfo(f))
+		}
+	}
+
+	return remove, compress, nil
+}
+
+// backupsNotIn returns the backups in a whose path does not appear in b.
+func backupsNotIn(a, b []BackupInfo) []BackupInfo {
+	seen := make(map[string]bool, len(b))
+	for _, f := range b {
+		seen[f.Path] = true
+	}
+
+	var diff []BackupInfo
+
+	for _, f := range a {
+		if !seen[f.Path] {
+			diff = append(diff, f)
+		}
+	}
+
+	return diff
+}
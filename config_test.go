@@ -0,0 +1,110 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffRetention(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestDiffRetention")
+	defer os.RemoveAll(dir)
+
+	// make 3 backup files, oldest first.
+	data := []byte("data")
+
+	oldest := backupFile(dir)
+	err := os.WriteFile(oldest, data, fileModeNew)
+	isNil(t, err)
+
+	newFakeTime()
+
+	middle := backupFile(dir)
+	err = os.WriteFile(middle, data, fileModeNew)
+	isNil(t, err)
+
+	newFakeTime()
+
+	newest := backupFile(dir)
+	err = os.WriteFile(newest, data, fileModeNew)
+	isNil(t, err)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 2,
+	}
+	defer l.Close()
+
+	// Keeping fewer backups would delete the middle one in addition to the
+	// oldest one which is already scheduled for removal.
+	wouldDelete, wouldCompress, err := l.DiffRetention(Config{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+	})
+	isNil(t, err)
+	equals(t, 1, len(wouldDelete))
+	equals(t, middle, wouldDelete[0].Path)
+	equals(t, int64(len(data)), wouldDelete[0].Size)
+	equals(t, false, wouldDelete[0].Compressed)
+	equals(t, 0, len(wouldCompress))
+
+	// Enabling compression would compress the two retained backups.
+	wouldDelete, wouldCompress, err = l.DiffRetention(Config{
+		Filename:   logFile(dir),
+		MaxBackups: 2,
+		Compress:   true,
+	})
+	isNil(t, err)
+	equals(t, 0, len(wouldDelete))
+	equals(t, 2, len(wouldCompress))
+	equals(t, newest, wouldCompress[0].Path)
+	equals(t, middle, wouldCompress[1].Path)
+
+	// Nothing was touched.
+	fileCount(t, dir, 3)
+}
+
+func TestDiffRetentionCustomNaming(t *testing.T) {
+	dir := makeTempDir(t, "TestDiffRetentionCustomNaming")
+	defer os.RemoveAll(dir)
+
+	const layout = "20060102T150405"
+
+	now := time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Clock:    func() time.Time { return now },
+		BackupNameFunc: func(baseName string, t time.Time) string {
+			return "host1." + baseName + "." + t.UTC().Format(layout)
+		},
+		ParseBackupName: func(name string) (time.Time, bool) {
+			if !strings.HasPrefix(name, "host1.foobar.log.") {
+				return time.Time{}, false
+			}
+
+			t, err := time.Parse(layout, strings.TrimPrefix(name, "host1.foobar.log."))
+
+			return t, err == nil
+		},
+	}
+	defer l.Close()
+
+	old := filepath.Join(dir, "host1.foobar.log."+now.AddDate(0, 0, -3).Format(layout))
+	recent := filepath.Join(dir, "host1.foobar.log."+now.AddDate(0, 0, -1).Format(layout))
+
+	for _, name := range []string{old, recent} {
+		isNil(t, os.WriteFile(name, []byte("data"), fileModeNew))
+	}
+
+	// The backups are recognized by ParseBackupName, and their age is
+	// measured by Clock rather than the wall clock.
+	wouldDelete, _, err := l.DiffRetention(Config{Filename: logFile(dir), MaxAge: 2})
+	isNil(t, err)
+	equals(t, 1, len(wouldDelete))
+	equals(t, old, wouldDelete[0].Path)
+}
@@ -0,0 +1,133 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// currentDate returns the current date formatted with FilenameDateLayout.
+func (l *Logger) currentDate() string {
+	return l.now().In(l.location()).Format(l.FilenameDateLayout)
+}
+
+// datedName returns the path of the log file for date, which is Filename with
+// the date inserted before the extension.
+func (l *Logger) datedName(date string) string {
+	name := l.filename()
+	ext := filepath.Ext(name)
+
+	return name[:len(name)-len(ext)] + "-" + date + ext
+}
+
+// activeName returns the path of the active log file: Filename, or with
+// FilenameDateLayout the file of the current period.
+func (l *Logger) activeName() string {
+	return l.plainActiveName() + l.activeSuffix()
+}
+
+// plainActiveName returns the path of the active log file without the suffix
+// of CompressActive.
+func (l *Logger) plainActiveName() string {
+	if l.FilenameDateLayout == "" {
+		return l.filename()
+	}
+
+	l.hooksMu.Lock()
+	date := l.date
+	l.hooksMu.Unlock()
+
+	if date == "" {
+		date = l.currentDate()
+	}
+
+	return l.datedName(date)
+}
+
+// dateChanged reports whether the period of the active file has ended.  It
+// must be called with l.mu held.
+func (l *Logger) dateChanged() bool {
+	return l.FilenameDateLayout != "" && l.date != "" && l.date != l.currentDate()
+}
+
+// rollDate moves on to the file of the current period before a log file is
+// opened.  The file of the previous period is left in place as a backup, and
+// reported to OnRotate and Stats like a rotation.  It returns the path of that
+// file if the period changed.  It must be called with l.mu held.
+func (l *Logger) rollDate() (prev string, rolled bool) {
+	if l.FilenameDateLayout == "" {
+		return "", false
+	}
+
+	date := l.currentDate()
+
+	l.hooksMu.Lock()
+	prevDate := l.date
+	l.date = date
+
+	rolled = prevDate != "" && prevDate != date
+	if rolled {
+		prev = l.datedName(prevDate)
+
+		if l.OnRotate != nil || len(l.PostRotateCommand) > 0 {
+			l.rotations = append(l.rotations, rotation{prev, prev, RotationTime})
+		}
+	}
+	l.hooksMu.Unlock()
+
+	if rolled {
+		l.recordRotation(RotationTime, nil)
+		l.diag("lumberjack: rotated log file", "filename", prev, "reason", RotationTime)
+	}
+
+	return prev, rolled
+}
+
+// parseDated parses the file of a previous period, returning its date.  The
+// file of the current period is not a backup.
+func (l *Logger) parseDated(name string) (time.Time, bool) {
+	if l.FilenameDateLayout == "" || name == filepath.Base(l.plainActiveName()) {
+		return time.Time{}, false
+	}
+
+	prefix, ext := l.prefixAndExt()
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return time.Time{}, false
+	}
+
+	date := name[len(prefix) : len(name)-len(ext)]
+
+	t, err := time.ParseInLocation(l.FilenameDateLayout, date, l.location())
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// checkDatedFilename reports an error if FilenameDateLayout doesn't name a
+// file per period.
+func (l *Logger) checkDatedFilename() error {
+	if l.FilenameDateLayout == "" {
+		return nil
+	}
+
+	if l.BackupDir != "" {
+		// The files of previous periods are backups in the directory of
+		// Filename.
+		return errors.New("FilenameDateLayout can't be used with BackupDir")
+	}
+
+	date := l.currentDate()
+
+	switch {
+	case strings.ContainsRune(date, filepath.Separator) || strings.ContainsRune(date, '/'):
+		return errors.New("FilenameDateLayout must not contain a path separator")
+	case date == l.FilenameDateLayout:
+		return fmt.Errorf("invalid FilenameDateLayout %q: must contain a date", l.FilenameDateLayout)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilenameDateLayout(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFilenameDateLayout")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		FilenameDateLayout: "2006-01-02",
+		MaxBackups:         1,
+	}
+	defer l.Close()
+
+	dated := func() string {
+		return filepath.Join(dir, "foobar-"+fakeTime().UTC().Format("2006-01-02")+".log")
+	}
+
+	first := dated()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+	existsWithContent(t, first, b)
+	notExist(t, logFile(dir))
+
+	// A new period starts a new file, leaving the previous one in place.
+	newFakeTime()
+
+	second := dated()
+
+	b2 := []byte("foo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+	existsWithContent(t, second, b2)
+	existsWithContent(t, first, b)
+
+	stats := l.Stats()
+	equals(t, int64(1), stats.Rotations)
+	equals(t, RotationTime, stats.LastRotationReason)
+
+	backups, err := l.Backups()
+	isNil(t, err)
+	equals(t, 1, len(backups))
+	equals(t, first, backups[0].Path)
+
+	// The files of previous periods count against MaxBackups.
+	newFakeTime()
+
+	_, err = l.Write(b)
+	isNil(t, err)
+	isNil(t, l.CloseAndWait())
+
+	existsWithContent(t, dated(), b)
+	existsWithContent(t, second, b2)
+	notExist(t, first)
+	fileCount(t, dir, 2)
+}
+
+func TestFilenameDateLayoutInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFilenameDateLayoutInvalid")
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		l    *Logger
+		want string
+	}{
+		{&Logger{FilenameDateLayout: "daily"}, `invalid FilenameDateLayout "daily": must contain a date`},
+		{&Logger{FilenameDateLayout: "2006/01/02"}, "FilenameDateLayout must not contain a path separator"},
+		{&Logger{FilenameDateLayout: "2006-01-02", BackupDir: "old"}, "FilenameDateLayout can't be used with BackupDir"},
+	}
+
+	for _, test := range tests {
+		test.l.Filename = logFile(dir)
+
+		_, err := test.l.Write([]byte("boo!"))
+		notNil(t, err)
+		equals(t, test.want, err.Error())
+	}
+
+	fileCount(t, dir, 0)
+}
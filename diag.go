@@ -0,0 +1,18 @@
+package lumberjack
+
+// DiagLogger records the diagnostics of a Logger, see Diag.  A *slog.Logger
+// implements it.
+type DiagLogger interface {
+	// Debug records the event msg with the given key-value pairs, like
+	// slog.Logger.Debug.
+	Debug(msg string, args ...any)
+}
+
+// diag records the event msg to Diag, if it is set.
+func (l *Logger) diag(msg string, args ...any) {
+	if l.Diag != nil {
+		defer l.hooks.exit(l.hooks.enter())
+
+		l.Diag.Debug(msg, args...)
+	}
+}
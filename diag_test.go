@@ -0,0 +1,63 @@
+package lumberjack
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+// recordingDiag records the messages of the events recorded to it.
+type recordingDiag struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (d *recordingDiag) Debug(msg string, args ...any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.msgs = append(d.msgs, msg)
+}
+
+func TestDiag(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestDiag")
+	defer os.RemoveAll(dir)
+
+	var diag recordingDiag
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+		Compress:   true,
+		Diag:       &diag,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	equals(t, []string{
+		"lumberjack: rotated log file",
+		"lumberjack: compressed backup",
+		"lumberjack: rotated log file",
+		"lumberjack: compressed backup",
+		"lumberjack: removed backup",
+	}, diag.msgs)
+
+	// A file in place of the log directory makes the rotation fail.
+	isNil(t, os.RemoveAll(dir))
+	isNil(t, os.WriteFile(dir, nil, fileModeNew))
+
+	notNil(t, l.Rotate())
+	equals(t, "lumberjack: rotation failed", diag.msgs[len(diag.msgs)-1])
+}
@@ -0,0 +1,152 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultDiskCheckInterval is the interval at which free disk space is checked
+// if DiskCheckInterval is not set.
+const defaultDiskCheckInterval = time.Minute
+
+// diskSpace returns the bytes available to the process and the total size of
+// the filesystem holding dir.  It is a variable so that tests can fake it.
+var diskSpace = statDisk
+
+// watchesDisk reports if MinFreeBytes or MinFreePercent is set.
+func (l *Logger) watchesDisk() bool {
+	return l.MinFreeBytes > 0 || l.MinFreePercent > 0
+}
+
+// checkFreeSpace reports an error if MinFreePercent is out of range or the
+// free disk space can't be watched with the Logger's settings.
+func (l *Logger) checkFreeSpace() error {
+	if !l.watchesDisk() {
+		return nil
+	}
+
+	if l.FS != nil {
+		// Free space is measured on the file system of the operating system.
+		return errors.New("MinFreeBytes and MinFreePercent can't be used with a custom FS")
+	}
+
+	if l.MinFreePercent > 100 {
+		return fmt.Errorf("invalid MinFreePercent %d: must be at most 100", l.MinFreePercent)
+	}
+
+	return nil
+}
+
+func (l *Logger) diskCheckInterval() time.Duration {
+	if l.DiskCheckInterval > 0 {
+		return l.DiskCheckInterval
+	}
+
+	return defaultDiskCheckInterval
+}
+
+// startDiskTimer starts the timer checking the free disk space if
+// MinFreeBytes or MinFreePercent is set, replacing a running one.  It must be
+// called with l.mu held.
+func (l *Logger) startDiskTimer() {
+	l.stopDiskTimer()
+
+	if l.watchesDisk() {
+		l.diskTimer = time.AfterFunc(l.diskCheckInterval(), l.timedDiskCheck)
+	}
+}
+
+// stopDiskTimer stops the disk timer, if any.  It must be called with l.mu
+// held.
+func (l *Logger) stopDiskTimer() {
+	if l.diskTimer != nil {
+		l.diskTimer.Stop()
+		l.diskTimer = nil
+	}
+}
+
+// timedDiskCheck is run by the disk timer.  It has the mill check the free
+// disk space, and rearms the timer.
+func (l *Logger) timedDiskCheck() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil || l.diskTimer == nil {
+		return
+	}
+
+	l.mill()
+
+	l.diskTimer = time.AfterFunc(l.diskCheckInterval(), l.timedDiskCheck)
+}
+
+// lowOnDisk reports if the free space of the filesystem holding the backups
+// is below MinFreeBytes or MinFreePercent.
+func (l *Logger) lowOnDisk() (bool, error) {
+	avail, total, err := diskSpace(l.backupDir())
+	if err != nil {
+		return false, fmt.Errorf("can't determine free disk space: %s", err)
+	}
+
+	return avail < uint64(l.MinFreeBytes) || avail*100 < total*uint64(l.MinFreePercent), nil
+}
+
+// freeDiskSpace makes room if the free disk space is low: it compresses the
+// uncompressed backups if CompressOnLowDisk is set, and then removes the
+// oldest backups until enough space is free.  It is run by the mill
+// goroutine.
+func (l *Logger) freeDiskSpace(ctx context.Context) error {
+	if !l.watchesDisk() {
+		return nil
+	}
+
+	low, err := l.lowOnDisk()
+	if err != nil || !low {
+		return err
+	}
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	if l.CompressOnLowDisk {
+		var plain []logInfo
+
+		for _, f := range files {
+			if backupSuffix(f.Name()) == "" {
+				plain = append(plain, f)
+			}
+		}
+
+		if err := l.compressBackups(ctx, l.unreported(plain), true); err != nil {
+			return err
+		}
+
+		if low, err = l.lowOnDisk(); err != nil || !low {
+			return err
+		}
+
+		if files, err = l.oldLogFiles(); err != nil {
+			return err
+		}
+	}
+
+	for i := len(files) - 1; i >= 0 && low; i-- {
+		if err := l.removeBackups(files[i : i+1]); err != nil {
+			return err
+		}
+
+		if low, err = l.lowOnDisk(); err != nil {
+			return err
+		}
+	}
+
+	if low {
+		return errors.New("free disk space is below MinFreeBytes or MinFreePercent, and no backups are left to remove")
+	}
+
+	return nil
+}
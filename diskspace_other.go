@@ -0,0 +1,12 @@
+//go:build !darwin && !freebsd && !linux && !windows
+// +build !darwin,!freebsd,!linux,!windows
+
+package lumberjack
+
+import (
+	"errors"
+)
+
+func statDisk(_ string) (uint64, uint64, error) {
+	return 0, 0, errors.New("free disk space can't be determined on this platform")
+}
@@ -0,0 +1,179 @@
+package lumberjack
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDiskSpace makes the directory of the test look like a filesystem of
+// the given capacity, which its files fill, and returns a function restoring
+// the real diskSpace.
+func fakeDiskSpace(capacity uint64) func() {
+	diskSpace = func(dir string) (uint64, uint64, error) {
+		var used uint64
+
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for _, f := range files {
+			info, err := f.Info()
+			if err != nil {
+				return 0, 0, err
+			}
+
+			used += uint64(info.Size())
+		}
+
+		if used > capacity {
+			return 0, capacity, nil
+		}
+
+		return capacity - used, capacity, nil
+	}
+
+	return func() { diskSpace = statDisk }
+}
+
+func TestMinFreeBytes(t *testing.T) {
+	currentTime = fakeTime
+	defer fakeDiskSpace(1000)()
+
+	dir := makeTempDir(t, "TestMinFreeBytes")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		MinFreeBytes: 700,
+	}
+	defer l.Close()
+
+	var backups []string
+
+	for i := 0; i < 4; i++ {
+		_, err := l.Write(bytes.Repeat([]byte{byte('a' + i)}, 100))
+		isNil(t, err)
+
+		newFakeTime()
+		isNil(t, l.Rotate())
+
+		backups = append(backups, backupFile(dir))
+	}
+
+	isNil(t, l.CloseAndWait())
+
+	// The fourth backup left only 600 bytes free, so the oldest one went.
+	notExist(t, backups[0])
+	existsWithContent(t, backups[1], bytes.Repeat([]byte("b"), 100))
+	existsWithContent(t, backups[3], bytes.Repeat([]byte("d"), 100))
+	fileCount(t, dir, 4)
+	equals(t, int64(1), l.Stats().RemovedBackups)
+}
+
+func TestMinFreePercentCompress(t *testing.T) {
+	currentTime = fakeTime
+	defer fakeDiskSpace(1000)()
+
+	dir := makeTempDir(t, "TestMinFreePercentCompress")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:          logFile(dir),
+		MaxBytes:          1000,
+		MinFreePercent:    50,
+		CompressOnLowDisk: true,
+	}
+	defer l.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := l.Write(bytes.Repeat([]byte{byte('a' + i)}, 300))
+		isNil(t, err)
+
+		newFakeTime()
+		isNil(t, l.Rotate())
+	}
+
+	isNil(t, l.CloseAndWait())
+
+	// Compressing the backups freed enough space to keep both.
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 2, len(files))
+
+	for _, f := range files {
+		equals(t, compressSuffix, backupSuffix(f.Name()))
+	}
+
+	equals(t, int64(0), l.Stats().RemovedBackups)
+}
+
+func TestDiskCheckInterval(t *testing.T) {
+	currentTime = fakeTime
+
+	var low atomic.Bool
+
+	diskSpace = func(string) (uint64, uint64, error) {
+		if low.Load() {
+			return 0, 1000, nil
+		}
+
+		return 1000, 1000, nil
+	}
+	defer func() { diskSpace = statDisk }()
+
+	dir := makeTempDir(t, "TestDiskCheckInterval")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:          logFile(dir),
+		MinFreeBytes:      1,
+		DiskCheckInterval: 10 * time.Millisecond,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	backup := backupFile(dir)
+	exists(t, backup)
+
+	errs := l.Errors()
+
+	// Without a rotation, the timer finds the disk full.
+	low.Store(true)
+
+	select {
+	case err := <-errs:
+		equals(t, "free disk space is below MinFreeBytes or MinFreePercent, and no backups are left to remove", err.Error())
+	case <-time.After(5 * time.Second):
+		t.Fatal("disk space wasn't checked")
+	}
+
+	isNil(t, l.CloseAndWait())
+
+	notExist(t, backup)
+	exists(t, filepath.Join(dir, "foobar.log"))
+}
+
+func TestMinFreeInvalid(t *testing.T) {
+	dir := makeTempDir(t, "TestMinFreeInvalid")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		MinFreePercent: 101,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, "invalid MinFreePercent 101: must be at most 100", err.Error())
+}
@@ -0,0 +1,20 @@
+//go:build darwin || freebsd || linux
+// +build darwin freebsd linux
+
+package lumberjack
+
+import (
+	"syscall"
+)
+
+// statDisk returns the bytes available to unprivileged users and the total
+// size of the filesystem holding dir, using statfs(2).  The conversions are
+// needed as the field types differ between platforms.
+func statDisk(dir string) (avail, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, err
+	}
+
+	return uint64(st.Bavail) * uint64(st.Bsize), uint64(st.Blocks) * uint64(st.Bsize), nil
+}
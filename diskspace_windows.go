@@ -0,0 +1,20 @@
+package lumberjack
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// statDisk returns the bytes available to the user and the total size of the
+// volume holding dir, using GetDiskFreeSpaceEx.
+func statDisk(dir string) (avail, total uint64, err error) {
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := windows.GetDiskFreeSpaceEx(p, &avail, &total, nil); err != nil {
+		return 0, 0, err
+	}
+
+	return avail, total, nil
+}
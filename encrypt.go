@@ -0,0 +1,244 @@
+package lumberjack
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Encrypted backups consist of a header holding encryptMagic and a random
+// nonce prefix, followed by the log data in chunks of encryptChunkSize bytes,
+// each sealed with AES-256-GCM.  The nonce of a chunk is the prefix, the
+// chunk's index and a flag marking the last chunk, so that chunks can be
+// neither reordered nor dropped without detection.
+const (
+	encryptSuffix    = ".enc"
+	encryptMagic     = "LJENC1"
+	encryptChunkSize = 64 * 1024
+	encryptKeySize   = 32
+
+	noncePrefixSize = 7
+)
+
+// errDecrypt is returned for encrypted backups which can't be decrypted.
+var errDecrypt = errors.New("can't decrypt backup: wrong key or corrupted data")
+
+// checkEncryption reports an error if EncryptKey is set but unusable.
+func (l *Logger) checkEncryption() error {
+	if l.EncryptKey == nil || len(l.EncryptKey) == encryptKeySize {
+		return nil
+	}
+
+	return fmt.Errorf("EncryptKey must be %d bytes, got %d", encryptKeySize, len(l.EncryptKey))
+}
+
+// encrypts reports whether backups are encrypted.
+func (l *Logger) encrypts() bool {
+	return l.EncryptKey != nil
+}
+
+// backupSuffix returns the suffixes added to a backup filename by compression
+// and encryption, or "" if it has neither been compressed nor encrypted.
+func backupSuffix(name string) string {
+	if !strings.HasSuffix(name, encryptSuffix) {
+		return compressedSuffix(name)
+	}
+
+	name = strings.TrimSuffix(name, encryptSuffix)
+
+	return compressedSuffix(name) + encryptSuffix
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptWriter encrypts the data written to it in the format described
+// above.
+type encryptWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	nonce []byte
+	index uint32
+	buf   []byte
+	out   []byte
+}
+
+// newEncryptWriter writes the header of an encrypted backup to w and returns
+// a writer encrypting data into it.  Close must be called to write the last
+// chunk.
+func newEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce[:noncePrefixSize]); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.WriteString(w, encryptMagic); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(nonce[:noncePrefixSize]); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:     w,
+		aead:  aead,
+		nonce: nonce,
+		buf:   make([]byte, 0, encryptChunkSize+1),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+
+	for len(p) > 0 {
+		m := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+m]
+		p = p[m:]
+
+		// Keep a byte back, so that the last chunk is never empty unless
+		// nothing was written at all, and is only sealed by Close.
+		if len(e.buf) == cap(e.buf) {
+			if err := e.seal(e.buf[:encryptChunkSize], false); err != nil {
+				return n - len(p), err
+			}
+
+			e.buf = append(e.buf[:0], e.buf[encryptChunkSize])
+		}
+	}
+
+	return n, nil
+}
+
+// Close seals and writes the last chunk.  It does not close the underlying
+// writer.
+func (e *encryptWriter) Close() error {
+	return e.seal(e.buf, true)
+}
+
+func (e *encryptWriter) seal(p []byte, last bool) error {
+	if e.index == ^uint32(0) {
+		return errors.New("backup too large to encrypt")
+	}
+
+	setChunkNonce(e.nonce, e.index, last)
+	e.index++
+
+	e.out = e.aead.Seal(e.out[:0], e.nonce, p, nil)
+
+	_, err := e.w.Write(e.out)
+
+	return err
+}
+
+func setChunkNonce(nonce []byte, index uint32, last bool) {
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], index)
+
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+}
+
+// decryptReader decrypts a backup encrypted by encryptWriter.
+type decryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	nonce  []byte
+	index  uint32
+	sealed []byte
+	buf    []byte
+	done   bool
+}
+
+// NewDecryptReader returns a reader decrypting a backup which was encrypted
+// with key, see EncryptKey.  Reading returns an error if the key is wrong or
+// the data was modified or truncated.
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, len(encryptMagic)+noncePrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("can't read encrypted backup header: %s", err)
+	}
+
+	if string(header[:len(encryptMagic)]) != encryptMagic {
+		return nil, errors.New("not an encrypted backup")
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[len(encryptMagic):])
+
+	return &decryptReader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		nonce:  nonce,
+		sealed: make([]byte, encryptChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+
+	return n, nil
+}
+
+// next reads and opens the next chunk.
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.sealed)
+
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		d.done = true
+	case err != nil:
+		return err
+	default:
+		// A full chunk is the last one if nothing follows.
+		if _, err := d.r.Peek(1); errors.Is(err, io.EOF) {
+			d.done = true
+		}
+	}
+
+	setChunkNonce(d.nonce, d.index, d.done)
+	d.index++
+
+	buf, err := d.aead.Open(d.sealed[:0], d.nonce, d.sealed[:n], nil)
+	if err != nil {
+		return errDecrypt
+	}
+
+	d.buf = buf
+
+	return nil
+}
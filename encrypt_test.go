@@ -0,0 +1,162 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+var testKey = bytes.Repeat([]byte("k"), encryptKeySize)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, encryptChunkSize - 1, encryptChunkSize, encryptChunkSize + 1, 3*encryptChunkSize + 5} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+
+		var buf bytes.Buffer
+
+		w, err := newEncryptWriter(&buf, testKey)
+		isNil(t, err)
+
+		// Write in odd pieces to exercise the chunking.
+		for p := data; len(p) > 0; {
+			n := 1000
+			if n > len(p) {
+				n = len(p)
+			}
+
+			_, err = w.Write(p[:n])
+			isNil(t, err)
+
+			p = p[n:]
+		}
+
+		isNil(t, w.Close())
+
+		sealed := buf.Bytes()
+		assert(t, size < 16 || !bytes.Contains(sealed, data), "data of size %d not encrypted", size)
+
+		r, err := NewDecryptReader(bytes.NewReader(sealed), testKey)
+		isNil(t, err)
+
+		got, err := io.ReadAll(r)
+		isNil(t, err)
+		equals(t, true, bytes.Equal(data, got))
+
+		// Truncating the data, even at a chunk boundary, is detected.
+		truncated := [][]byte{sealed[:len(sealed)-1]}
+		if size > encryptChunkSize {
+			truncated = append(truncated, sealed[:len(encryptMagic)+noncePrefixSize+encryptChunkSize+16])
+		}
+
+		for _, p := range truncated {
+			r, err = NewDecryptReader(bytes.NewReader(p), testKey)
+			isNil(t, err)
+
+			_, err = io.ReadAll(r)
+			equals(t, errDecrypt, err)
+		}
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := newEncryptWriter(&buf, testKey)
+	isNil(t, err)
+	_, err = w.Write([]byte("boo!"))
+	isNil(t, err)
+	isNil(t, w.Close())
+
+	r, err := NewDecryptReader(bytes.NewReader(buf.Bytes()), bytes.Repeat([]byte("x"), encryptKeySize))
+	isNil(t, err)
+
+	_, err = io.ReadAll(r)
+	equals(t, errDecrypt, err)
+
+	_, err = NewDecryptReader(bytes.NewReader([]byte("boo! not encrypted")), testKey)
+	notNil(t, err)
+}
+
+func TestEncryptBackups(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestEncryptBackups")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		Compress:   true,
+		EncryptKey: testKey,
+		MaxBackups: 1,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	// we need to wait a little bit since the files get encrypted on a
+	// different goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	backup := backupFile(dir) + compressSuffix + encryptSuffix
+	fileCount(t, dir, 2)
+
+	f, err := os.Open(backup)
+	isNil(t, err)
+	defer f.Close()
+
+	r, err := NewDecryptReader(f, testKey)
+	isNil(t, err)
+	gz, err := gzip.NewReader(r)
+	isNil(t, err)
+	got, err := io.ReadAll(gz)
+	isNil(t, err)
+	equals(t, b, got)
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 1, len(files))
+
+	info := l.backupInfo(files[0])
+	equals(t, backup, info.Path)
+	equals(t, true, info.Compressed)
+	equals(t, true, info.Encrypted)
+
+	// Encrypted backups count against MaxBackups.
+	newFakeTime()
+	isNil(t, l.Rotate())
+	<-time.After(300 * time.Millisecond)
+
+	notExist(t, backup)
+	exists(t, backupFile(dir)+compressSuffix+encryptSuffix)
+	fileCount(t, dir, 2)
+}
+
+func TestEncryptKeyInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestEncryptKeyInvalid")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		EncryptKey: []byte("short"),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, "EncryptKey must be 32 bytes, got 5", err.Error())
+	fileCount(t, dir, 0)
+}
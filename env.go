@@ -0,0 +1,315 @@
+package lumberjack
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultEnvPrefix is the prefix used by FromEnv when none is given.
+const defaultEnvPrefix = "LUMBERJACK"
+
+// FromEnv builds a Logger from environment variables, for deployments that are
+// configured through their environment rather than config files.  Each setting
+// is read from a variable named prefix + "_" + SETTING; if prefix is empty,
+// "LUMBERJACK" is used.  Unset or empty variables leave the default in place.
+//
+// The recognized settings are:
+//
+//	FILENAME                  Filename
+//	FILENAME_DATE_LAYOUT      FilenameDateLayout, as a time layout ("2006-01-02")
+//	BOOT_FILENAME             BootFilename
+//	MAX_BYTES                 MaxBytes, as accepted by ParseSize (e.g. "100MB")
+//	MAX_BACKUPS               MaxBackups
+//	MAX_UNCOMPRESSED_BACKUPS  MaxUncompressedBackups
+//	MAX_COMPRESSED_BACKUPS    MaxCompressedBackups
+//	MAX_TOTAL_BYTES           MaxTotalBytes, as accepted by ParseSize
+//	MIN_FREE_BYTES            MinFreeBytes, as accepted by ParseSize
+//	MIN_FREE_PERCENT          MinFreePercent
+//	DISK_CHECK_INTERVAL       DiskCheckInterval, as a duration ("30s", "5m")
+//	COMPRESS_ON_LOW_DISK      CompressOnLowDisk, as accepted by strconv.ParseBool
+//	JANITOR_INTERVAL          JanitorInterval, as a duration ("10m", "1h")
+//	MAX_AGE                   MaxAge, as days ("7") or a duration ("7d", "2w", "168h")
+//	COMPRESS                  Compress, as accepted by strconv.ParseBool
+//	COMPRESSION_FORMAT        CompressionFormat ("gzip", "zstd", "xz")
+//	COMPRESS_CONCURRENCY      CompressConcurrency
+//	COMPRESS_WORKERS          CompressWorkers
+//	COMPRESS_BUFFER_SIZE      CompressBufferSize, as accepted by ParseSize
+//	COMPRESS_ACTIVE           CompressActive, as accepted by strconv.ParseBool
+//	COMPRESS_AFTER            CompressAfter, as a duration ("1h", "1d")
+//	ENCRYPT_KEY               EncryptKey, base64 encoded
+//	LOCAL_TIME                LocalTime, as accepted by strconv.ParseBool
+//	LOCATION                  Location, as a zone name ("UTC", "Asia/Shanghai")
+//	ROTATION_INTERVAL         RotationInterval, as a duration ("1h", "1d")
+//	ROTATE_AT                 RotateAt, as "HH:MM"
+//	ROTATE_ON_START           RotateOnStart, as accepted by strconv.ParseBool
+//	OPEN_MODE                 OpenMode ("append", "truncate")
+//	BACKUP_DIR                BackupDir
+//	BUFFER_SIZE               BufferSize, as accepted by ParseSize
+//	FLUSH_INTERVAL            FlushInterval, as a duration ("500ms", "1s")
+//	ASYNC_BUFFER_SIZE         AsyncBufferSize, as accepted by ParseSize
+//	FALLBACK_RETRY_INTERVAL   FallbackRetryInterval, as a duration ("10s", "1m")
+//	WRITE_RETRIES             WriteRetries
+//	WRITE_RETRY_DELAY         WriteRetryDelay, as a duration ("10ms", "1s")
+//	PRESERVE_OWNER            PreserveOwner, as accepted by strconv.ParseBool
+//	SYNC_INTERVAL             SyncInterval, as a duration ("1s")
+//	SYNC_EVERY_WRITE          SyncEveryWrite, as accepted by strconv.ParseBool
+//	NAMING_SCHEME             NamingScheme ("timestamp", "sequence")
+//	BACKUP_NAME_TEMPLATE      BackupNameTemplate
+//	TIMESTAMP_PRECISION       TimestampPrecision ("second", "millisecond", "nanosecond")
+//	PREALLOCATE               Preallocate, as accepted by strconv.ParseBool
+//	LARGE_WRITE_POLICY        LargeWritePolicy ("error", "split", "allow")
+//	MAX_RECORD_BYTES          MaxRecordBytes, as accepted by ParseSize
+//	MAX_RECORD_POLICY         MaxRecordPolicy ("truncate", "reject")
+//	APPEND_NEWLINE            AppendNewline, as accepted by strconv.ParseBool
+//	STRIP_ANSI                StripANSI, as accepted by strconv.ParseBool
+//	MAX_BYTES_PER_SECOND      MaxBytesPerSecond, as accepted by ParseSize
+//	RATE_LIMIT_POLICY         RateLimitPolicy ("block", "drop")
+//	LOCK_MODE                 LockMode ("exclusive", "shared")
+//	SYMLINK_NAME              SymlinkName
+//	FOLLOW_NAME               FollowName, as accepted by strconv.ParseBool
+//	PREV_SYMLINK              PrevSymlink, as accepted by strconv.ParseBool
+//	ARCHIVE_ERROR_POLICY      ArchiveErrorPolicy ("retry", "retain")
+//
+// An error naming the offending variable is returned if any value is invalid.
+// The settings are then checked like Validate does, so that a Logger is only
+// returned if it can be written to.
+func FromEnv(prefix string) (*Logger, error) {
+	if prefix == "" {
+		prefix = defaultEnvPrefix
+	}
+
+	e := envReader{prefix: prefix}
+	l := &Logger{
+		Filename:               e.string("FILENAME"),
+		FilenameDateLayout:     e.string("FILENAME_DATE_LAYOUT"),
+		BootFilename:           e.string("BOOT_FILENAME"),
+		MaxBytes:               e.size("MAX_BYTES"),
+		MaxBackups:             e.int("MAX_BACKUPS"),
+		MaxUncompressedBackups: e.int("MAX_UNCOMPRESSED_BACKUPS"),
+		MaxCompressedBackups:   e.int("MAX_COMPRESSED_BACKUPS"),
+		MaxTotalBytes:          e.size("MAX_TOTAL_BYTES"),
+		MinFreeBytes:           e.size("MIN_FREE_BYTES"),
+		MinFreePercent:         e.int("MIN_FREE_PERCENT"),
+		DiskCheckInterval:      e.duration("DISK_CHECK_INTERVAL"),
+		CompressOnLowDisk:      e.bool("COMPRESS_ON_LOW_DISK"),
+		JanitorInterval:        e.duration("JANITOR_INTERVAL"),
+		MaxAge:                 e.days("MAX_AGE"),
+		Compress:               e.bool("COMPRESS"),
+		CompressionFormat:      CompressionFormat(e.string("COMPRESSION_FORMAT")),
+		CompressConcurrency:    e.int("COMPRESS_CONCURRENCY"),
+		CompressWorkers:        e.int("COMPRESS_WORKERS"),
+		CompressBufferSize:     e.intSize("COMPRESS_BUFFER_SIZE"),
+		CompressActive:         e.bool("COMPRESS_ACTIVE"),
+		CompressAfter:          e.duration("COMPRESS_AFTER"),
+		EncryptKey:             e.base64("ENCRYPT_KEY"),
+		LocalTime:              e.bool("LOCAL_TIME"),
+		Location:               e.location("LOCATION"),
+		RotationInterval:       e.duration("ROTATION_INTERVAL"),
+		RotateAt:               e.string("ROTATE_AT"),
+		RotateOnStart:          e.bool("ROTATE_ON_START"),
+		OpenMode:               OpenMode(e.string("OPEN_MODE")),
+		BackupDir:              e.string("BACKUP_DIR"),
+		BufferSize:             e.intSize("BUFFER_SIZE"),
+		FlushInterval:          e.duration("FLUSH_INTERVAL"),
+		AsyncBufferSize:        e.intSize("ASYNC_BUFFER_SIZE"),
+		FallbackRetryInterval:  e.duration("FALLBACK_RETRY_INTERVAL"),
+		WriteRetries:           e.int("WRITE_RETRIES"),
+		WriteRetryDelay:        e.duration("WRITE_RETRY_DELAY"),
+		PreserveOwner:          e.optionalBool("PRESERVE_OWNER"),
+		SyncInterval:           e.duration("SYNC_INTERVAL"),
+		SyncEveryWrite:         e.bool("SYNC_EVERY_WRITE"),
+		NamingScheme:           NamingScheme(e.string("NAMING_SCHEME")),
+		BackupNameTemplate:     e.string("BACKUP_NAME_TEMPLATE"),
+		TimestampPrecision:     TimestampPrecision(e.string("TIMESTAMP_PRECISION")),
+		Preallocate:            e.bool("PREALLOCATE"),
+		LargeWritePolicy:       LargeWritePolicy(e.string("LARGE_WRITE_POLICY")),
+		MaxRecordBytes:         e.intSize("MAX_RECORD_BYTES"),
+		MaxRecordPolicy:        MaxRecordPolicy(e.string("MAX_RECORD_POLICY")),
+		AppendNewline:          e.bool("APPEND_NEWLINE"),
+		StripANSI:              e.bool("STRIP_ANSI"),
+		MaxBytesPerSecond:      e.size("MAX_BYTES_PER_SECOND"),
+		RateLimitPolicy:        RateLimitPolicy(e.string("RATE_LIMIT_POLICY")),
+		LockMode:               LockMode(e.string("LOCK_MODE")),
+		SymlinkName:            e.string("SYMLINK_NAME"),
+		FollowName:             e.bool("FOLLOW_NAME"),
+		PrevSymlink:            e.bool("PREV_SYMLINK"),
+		ArchiveErrorPolicy:     ArchiveErrorPolicy(e.string("ARCHIVE_ERROR_POLICY")),
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// envReader reads prefixed environment variables, remembering the first error
+// so that FromEnv can check it once.
+type envReader struct {
+	prefix string
+	err    error
+}
+
+// lookup returns the value of the named variable, or "" if it is unset.
+func (e *envReader) lookup(name string) (key, value string) {
+	key = e.prefix + "_" + name
+	value, _ = os.LookupEnv(key)
+
+	return key, value
+}
+
+// fail records err for key unless an earlier error was already recorded.
+func (e *envReader) fail(key string, err error) {
+	if e.err == nil {
+		e.err = fmt.Errorf("invalid %s: %v", key, err)
+	}
+}
+
+func (e *envReader) string(name string) string {
+	_, v := e.lookup(name)
+
+	return v
+}
+
+func (e *envReader) int(name string) int {
+	key, v := e.lookup(name)
+	if v == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(v)
+	if err == nil && n < 0 {
+		err = fmt.Errorf("must not be negative")
+	}
+
+	if err != nil {
+		e.fail(key, err)
+	}
+
+	return n
+}
+
+func (e *envReader) size(name string) int64 {
+	key, v := e.lookup(name)
+	if v == "" {
+		return 0
+	}
+
+	n, err := ParseSize(v)
+	if err != nil {
+		e.fail(key, err)
+	}
+
+	return n
+}
+
+// intSize reads a size for a setting of type int, which is narrower than the
+// sizes ParseSize accepts on 32-bit platforms.
+func (e *envReader) intSize(name string) int {
+	n := e.size(name)
+	if n > math.MaxInt {
+		key, _ := e.lookup(name)
+		e.fail(key, fmt.Errorf("must be at most %d bytes", math.MaxInt))
+
+		return 0
+	}
+
+	return int(n)
+}
+
+func (e *envReader) days(name string) int {
+	key, v := e.lookup(name)
+	if v == "" {
+		return 0
+	}
+
+	n, err := parseDays(v)
+	if err == nil && n < 0 {
+		err = fmt.Errorf("must not be negative")
+	}
+
+	if err != nil {
+		e.fail(key, err)
+	}
+
+	return n
+}
+
+func (e *envReader) duration(name string) time.Duration {
+	key, v := e.lookup(name)
+	if v == "" {
+		return 0
+	}
+
+	d, err := parseDuration(v)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("must not be negative")
+	}
+
+	if err != nil {
+		e.fail(key, err)
+	}
+
+	return d
+}
+
+func (e *envReader) location(name string) *time.Location {
+	key, v := e.lookup(name)
+	if v == "" {
+		return nil
+	}
+
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		e.fail(key, err)
+	}
+
+	return loc
+}
+
+func (e *envReader) bool(name string) bool {
+	key, v := e.lookup(name)
+	if v == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.fail(key, err)
+	}
+
+	return b
+}
+
+func (e *envReader) optionalBool(name string) *bool {
+	_, v := e.lookup(name)
+	if v == "" {
+		return nil
+	}
+
+	b := e.bool(name)
+
+	return &b
+}
+
+func (e *envReader) base64(name string) []byte {
+	key, v := e.lookup(name)
+	if v == "" {
+		return nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		e.fail(key, err)
+	}
+
+	return b
+}
@@ -0,0 +1,79 @@
+package lumberjack
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("LUMBERJACK_FILENAME", filepath.Join(dir, "foo.log"))
+	t.Setenv("LUMBERJACK_BOOT_FILENAME", filepath.Join(dir, "foo-boot.log"))
+	t.Setenv("LUMBERJACK_MAX_BYTES", "1.5MiB")
+	t.Setenv("LUMBERJACK_MAX_BACKUPS", "3")
+	t.Setenv("LUMBERJACK_MAX_AGE", "2w")
+	t.Setenv("LUMBERJACK_COMPRESS", "true")
+	t.Setenv("LUMBERJACK_LOCAL_TIME", "")
+	t.Setenv("LUMBERJACK_ROTATION_INTERVAL", "1d")
+	t.Setenv("LUMBERJACK_ROTATE_AT", "00:00")
+	t.Setenv("LUMBERJACK_PRESERVE_OWNER", "false")
+
+	l, err := FromEnv("")
+	isNil(t, err)
+	equals(t, filepath.Join(dir, "foo.log"), l.Filename)
+	equals(t, filepath.Join(dir, "foo-boot.log"), l.BootFilename)
+	equals(t, int64(3<<19), l.MaxBytes)
+	equals(t, 3, l.MaxBackups)
+	equals(t, 14, l.MaxAge)
+	equals(t, true, l.Compress)
+	equals(t, false, l.LocalTime)
+	equals(t, 24*time.Hour, l.RotationInterval)
+	equals(t, "00:00", l.RotateAt)
+	notNil(t, l.PreserveOwner)
+	equals(t, false, *l.PreserveOwner)
+}
+
+func TestFromEnvPrefix(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("LUMBERJACK_FILENAME", filepath.Join(dir, "ignored.log"))
+	t.Setenv("APP_LOG_FILENAME", filepath.Join(dir, "app.log"))
+	t.Setenv("APP_LOG_MAX_AGE", "7")
+
+	l, err := FromEnv("APP_LOG")
+	isNil(t, err)
+	equals(t, filepath.Join(dir, "app.log"), l.Filename)
+	equals(t, 7, l.MaxAge)
+	equals(t, int64(0), l.MaxBytes)
+	equals(t, (*bool)(nil), l.PreserveOwner)
+}
+
+func TestFromEnvInvalid(t *testing.T) {
+	tests := []struct {
+		key, value, msg string
+	}{
+		{"LUMBERJACK_MAX_BYTES", "100XB", `invalid LUMBERJACK_MAX_BYTES: invalid size "100XB": unknown unit "xb"`},
+		{"LUMBERJACK_MAX_BACKUPS", "-1", "invalid LUMBERJACK_MAX_BACKUPS: must not be negative"},
+		{"LUMBERJACK_MAX_AGE", "36h", `invalid LUMBERJACK_MAX_AGE: invalid duration "36h": not a whole number of days`},
+		{"LUMBERJACK_ROTATION_INTERVAL", "-1h", "invalid LUMBERJACK_ROTATION_INTERVAL: must not be negative"},
+		{"LUMBERJACK_COMPRESS", "maybe", `invalid LUMBERJACK_COMPRESS: strconv.ParseBool: parsing "maybe": invalid syntax`},
+
+		// Values which parse but are invalid settings are reported like
+		// Validate does.
+		{"LUMBERJACK_ROTATE_AT", "25:00", `invalid RotateAt "25:00": must be HH:MM`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			t.Setenv("LUMBERJACK_FILENAME", filepath.Join(t.TempDir(), "foo.log"))
+			t.Setenv(test.key, test.value)
+
+			l, err := FromEnv("")
+			isNil(t, l)
+			notNil(t, err)
+			equals(t, test.msg, err.Error())
+		})
+	}
+}
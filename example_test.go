@@ -17,3 +17,18 @@ func Example() {
 		Compress:   true, // disabled by default
 	})
 }
+
+// New validates the settings up front instead of on the first write.
+func ExampleNew() {
+	l, err := lumberjack.New("/var/log/myapp/foo.log",
+		lumberjack.WithMaxBytes(500*1024*1024), // 500 MiB
+		lumberjack.WithMaxBackups(3),
+		lumberjack.WithMaxAge(28), // days
+		lumberjack.WithCompress(),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.SetOutput(l)
+}
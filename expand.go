@@ -0,0 +1,85 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	hostnameOnce sync.Once
+	hostname     string
+)
+
+// lookupHostname returns the host name for the placeholders of Filename,
+// looking it up once.  It is empty if it can't be determined.
+func lookupHostname() string {
+	hostnameOnce.Do(func() {
+		hostname, _ = os.Hostname()
+	})
+
+	return hostname
+}
+
+// expandFilename expands the placeholders of Filename in name: "{pid}" and
+// "{hostname}", and environment variables as "${NAME}".  "${HOSTNAME}" is the
+// host name if the variable is not set, as it usually is only set in shells.
+// Unset variables expand to nothing, and are returned.
+func expandFilename(name string) (string, []string) {
+	if !strings.Contains(name, "{") {
+		return name, nil
+	}
+
+	var (
+		b     strings.Builder
+		unset []string
+	)
+
+	for {
+		i := strings.Index(name, "${")
+		if i < 0 {
+			break
+		}
+
+		j := strings.IndexByte(name[i:], '}')
+		if j < 0 {
+			break
+		}
+
+		b.WriteString(name[:i])
+
+		key := name[i+2 : i+j]
+
+		value, ok := os.LookupEnv(key)
+		if !ok && key == "HOSTNAME" {
+			value, ok = lookupHostname(), true
+		}
+
+		if !ok {
+			unset = append(unset, key)
+		}
+
+		b.WriteString(value)
+
+		name = name[i+j+1:]
+	}
+
+	b.WriteString(name)
+
+	return strings.NewReplacer(
+		"{pid}", strconv.Itoa(os.Getpid()),
+		"{hostname}", lookupHostname(),
+	).Replace(b.String()), unset
+}
+
+// checkFilename reports an error if Filename refers to an unset environment
+// variable, which would silently be left out of the name.
+func (l *Logger) checkFilename() error {
+	if _, unset := expandFilename(l.Filename); len(unset) > 0 {
+		return fmt.Errorf("Filename refers to unset environment variable %s", unset[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestExpandFilename(t *testing.T) {
+	t.Setenv("POD_NAME", "web-1")
+	t.Setenv("EMPTY", "")
+	isNil(t, os.Unsetenv("LUMBERJACK_UNSET"))
+
+	host, err := os.Hostname()
+	isNil(t, err)
+
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := []struct {
+		name  string
+		exp   string
+		unset []string
+	}{
+		{"/logs/app.log", "/logs/app.log", nil},
+		{"/logs/app-${POD_NAME}.log", "/logs/app-web-1.log", nil},
+		{"/logs/${POD_NAME}/app-{pid}.log", "/logs/web-1/app-" + pid + ".log", nil},
+		{"/logs/app-{hostname}.log", "/logs/app-" + host + ".log", nil},
+		{"/logs/app${EMPTY}.log", "/logs/app.log", nil},
+		{"/logs/app-${LUMBERJACK_UNSET}.log", "/logs/app-.log", []string{"LUMBERJACK_UNSET"}},
+		{"/logs/app-{other}-${.log", "/logs/app-{other}-${.log", nil},
+	}
+
+	for _, tt := range tests {
+		name, unset := expandFilename(tt.name)
+		equals(t, tt.exp, name)
+		equals(t, tt.unset, unset)
+	}
+}
+
+func TestFilenamePlaceholders(t *testing.T) {
+	currentTime = fakeTime
+	t.Setenv("POD_NAME", "web-1")
+
+	dir := makeTempDir(t, "TestFilenamePlaceholders")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: filepath.Join(dir, "foobar-${POD_NAME}.log")}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	filename := filepath.Join(dir, "foobar-web-1.log")
+	existsWithContent(t, filename, b)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	existsWithContent(t, filepath.Join(dir, "foobar-web-1-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), b)
+	fileCount(t, dir, 2)
+
+	_, err = New(filepath.Join(dir, "foobar-${LUMBERJACK_UNSET}.log"))
+	notNil(t, err)
+	equals(t, "Filename refers to unset environment variable LUMBERJACK_UNSET", err.Error())
+}
@@ -0,0 +1,100 @@
+package lumberjack
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultFallbackRetryInterval is the time after which the log file is tried
+// again if FallbackRetryInterval is not set.
+const defaultFallbackRetryInterval = 10 * time.Second
+
+func (l *Logger) fallbackRetryInterval() time.Duration {
+	if l.FallbackRetryInterval > 0 {
+		return l.FallbackRetryInterval
+	}
+
+	return defaultFallbackRetryInterval
+}
+
+// fallingBack reports if writes are diverted to Fallback because the log file
+// failed recently.  It must be called with l.mu held, at least for reading.
+func (l *Logger) fallingBack() bool {
+	return !l.fallbackUntil.IsZero() && l.now().Before(l.fallbackUntil)
+}
+
+// divert handles the failure to open or write the log file with err: without
+// a Fallback, it returns err, and otherwise, it reports err like other
+// background errors and writes p to Fallback, which then receives all writes
+// until FallbackRetryInterval has passed.  It must be called with l.mu held.
+func (l *Logger) divert(p []byte, err error) (int, error) {
+	if l.Fallback == nil {
+		return 0, err
+	}
+
+	now := l.now()
+	l.fallbackUntil = now.Add(l.fallbackRetryInterval())
+
+	if l.fallbackSince.IsZero() {
+		l.fallbackSince = now
+	}
+
+	l.queueError(fmt.Errorf("writing to Fallback: %s", err))
+	l.mill()
+
+	return l.writeFallback(p)
+}
+
+// writeFallback writes p to Fallback.  It must be called with l.mu held.
+func (l *Logger) writeFallback(p []byte) (int, error) {
+	id := l.hooks.enter()
+	n, err := l.Fallback.Write(p)
+	l.hooks.exit(id)
+
+	if n > 0 {
+		l.recordFallback(n)
+	}
+
+	if err != nil {
+		return n, fmt.Errorf("can't write to Fallback: %s", err)
+	}
+
+	return n, nil
+}
+
+// recovered records that a record was written to the log file, and queues a
+// recovery for the mill goroutine to report to OnRecover if writes were
+// diverted to Fallback before.  It must be called with l.mu held.
+func (l *Logger) recovered() {
+	l.fallbackUntil = time.Time{}
+
+	if l.fallbackSince.IsZero() {
+		return
+	}
+
+	downtime := l.now().Sub(l.fallbackSince)
+	l.fallbackSince = time.Time{}
+
+	if l.OnRecover == nil {
+		return
+	}
+
+	l.hooksMu.Lock()
+	l.recoveries = append(l.recoveries, downtime)
+	l.hooksMu.Unlock()
+
+	l.mill()
+}
+
+// notifyRecoveries reports the queued recoveries to OnRecover.  It is run by
+// the mill goroutine.
+func (l *Logger) notifyRecoveries() {
+	l.hooksMu.Lock()
+	recoveries := l.recoveries
+	l.recoveries = nil
+	l.hooksMu.Unlock()
+
+	for _, d := range recoveries {
+		l.OnRecover(d)
+	}
+}
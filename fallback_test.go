@@ -0,0 +1,120 @@
+package lumberjack
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFallback(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFallback")
+	defer os.RemoveAll(dir)
+
+	// A file in place of the log directory makes the log file unavailable.
+	blocker := filepath.Join(dir, "logs")
+	isNil(t, os.WriteFile(blocker, nil, fileModeNew))
+
+	var fallback bytes.Buffer
+
+	l := &Logger{
+		Filename: filepath.Join(blocker, "foobar.log"),
+		Fallback: &fallback,
+	}
+	defer l.Close()
+
+	errs := l.Errors()
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+	equals(t, "boo!", fallback.String())
+	notNil(t, <-errs)
+
+	// The log file isn't tried again before the retry interval passed.
+	isNil(t, os.Remove(blocker))
+
+	b2 := []byte("foo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+	equals(t, "boo!foo!", fallback.String())
+	notExist(t, blocker)
+
+	newFakeTime()
+
+	b3 := []byte("baz!")
+	_, err = l.Write(b3)
+	isNil(t, err)
+	existsWithContent(t, l.Filename, b3)
+	equals(t, "boo!foo!", fallback.String())
+
+	s := l.Stats()
+	equals(t, int64(2), s.FallbackWrites)
+	equals(t, int64(8), s.FallbackBytes)
+}
+
+func TestFallbackFails(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFallbackFails")
+	defer os.RemoveAll(dir)
+
+	blocker := filepath.Join(dir, "logs")
+	isNil(t, os.WriteFile(blocker, nil, fileModeNew))
+
+	l := &Logger{
+		Filename: filepath.Join(blocker, "foobar.log"),
+		Fallback: failingWriter{},
+	}
+	defer l.Close()
+
+	n, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, "can't write to Fallback: broken pipe", err.Error())
+	equals(t, 0, n)
+}
+
+func TestFallbackRecover(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFallbackRecover")
+	defer os.RemoveAll(dir)
+
+	blocker := filepath.Join(dir, "logs")
+	isNil(t, os.WriteFile(blocker, nil, fileModeNew))
+
+	recovered := make(chan time.Duration, 1)
+
+	l := &Logger{
+		Filename:  filepath.Join(blocker, "foobar.log"),
+		Fallback:  &bytes.Buffer{},
+		OnRecover: func(downtime time.Duration) { recovered <- downtime },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	isNil(t, os.Remove(blocker))
+	newFakeTime()
+
+	_, err = l.Write([]byte("foo!"))
+	isNil(t, err)
+	existsWithContent(t, l.Filename, []byte("foo!"))
+	equals(t, 48*time.Hour, <-recovered)
+
+	// Writes which never failed are no recovery.
+	_, err = l.Write([]byte("baz!"))
+	isNil(t, err)
+	isNil(t, l.CloseAndWait())
+
+	select {
+	case d := <-recovered:
+		t.Fatalf("unexpected recovery after %v", d)
+	default:
+	}
+}
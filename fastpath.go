@@ -0,0 +1,61 @@
+package lumberjack
+
+import "sync/atomic"
+
+// writeFast writes p to the active file with l.mu only read-locked, so that
+// concurrent writers don't serialize on the size accounting and rotation
+// checks, but only on the write to the file itself.  The size is reserved
+// atomically before writing.  It reports false without writing anything if p
+// needs the exclusive path: when the file must be opened or rotated, or when
+// a setting needs exclusive access to the Logger.
+func (l *Logger) writeFast(p []byte) (int, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	writeLen := int64(len(p))
+	if !l.fastPath() || writeLen > l.max() {
+		return 0, false, nil
+	}
+
+	if atomic.AddInt64(&l.size, writeLen) > l.max() {
+		// The file is due for a rotation.
+		atomic.AddInt64(&l.size, -writeLen)
+
+		return 0, false, nil
+	}
+
+	n, err := l.file.Write(p)
+	if int64(n) < writeLen {
+		atomic.AddInt64(&l.size, int64(n)-writeLen)
+	}
+
+	if n > 0 {
+		l.unsynced.Store(true)
+		l.recordWrite(n)
+	}
+
+	if err == nil {
+		err = l.syncWrite()
+	}
+
+	return n, true, err
+}
+
+// fastPath reports whether writes may take the path of writeFast.  It must be
+// called with l.mu held, at least for reading.
+func (l *Logger) fastPath() bool {
+	return l.file != nil && !l.needsSlowPath && !l.rotationDue() && !l.dateChanged()
+}
+
+// updateSlowPath sets needsSlowPath, which keeps writes off the path of
+// writeFast if a setting needs them to take l.mu exclusively.  Buffering,
+// CompressActive, Transformers, VerifyTailBytes, Mirror and the boot file keep
+// state which needs exclusive access, LockShared and FollowName check the file
+// on every write, a Fallback and WriteRetries take over failed writes, and a
+// custom FS may not support concurrent writes.  It is called with l.mu held
+// when the active file is opened and when UpdateConfig changed the settings.
+func (l *Logger) updateSlowPath() {
+	l.needsSlowPath = l.buf != nil || l.FS != nil || l.VerifyTailBytes > 0 || l.CompressActive ||
+		len(l.Transformers) > 0 || l.Mirror != nil || l.Fallback != nil || l.WriteRetries > 0 ||
+		l.BootFilename != "" || l.LockMode == LockShared || l.FollowName
+}
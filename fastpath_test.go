@@ -0,0 +1,113 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentWrites(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestConcurrentWrites")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxBytes: 100,
+	}
+	defer l.Close()
+
+	const writers, records = 8, 50
+
+	record := []byte("boo!\n")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < records; j++ {
+				if _, err := l.Write(record); err != nil {
+					t.Error(err)
+
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	isNil(t, l.Close())
+
+	// No record was lost, and no file grew larger than MaxBytes.
+	files, err := os.ReadDir(dir)
+	isNil(t, err)
+
+	var total int64
+
+	for _, f := range files {
+		info, err := os.Stat(filepath.Join(dir, f.Name()))
+		isNil(t, err)
+
+		if info.Size() > 100 {
+			t.Errorf("%s has %d bytes", f.Name(), info.Size())
+		}
+
+		total += info.Size()
+	}
+
+	equals(t, int64(writers*records*len(record)), total)
+	equals(t, int64(writers*records), l.Stats().Writes)
+}
+
+func benchmarkWrite(b *testing.B, parallel bool) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(b, "BenchmarkWrite")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxBytes: 1 << 30,
+	}
+	defer l.Close()
+
+	record := []byte("level=info msg=\"request served\" status=200 duration=1.2ms\n")
+
+	b.SetBytes(int64(len(record)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	if !parallel {
+		for i := 0; i < b.N; i++ {
+			if _, err := l.Write(record); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		return
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := l.Write(record); err != nil {
+				b.Error(err)
+
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkWrite(b *testing.B) {
+	benchmarkWrite(b, false)
+}
+
+func BenchmarkWriteParallel(b *testing.B) {
+	benchmarkWrite(b, true)
+}
@@ -0,0 +1,427 @@
+package lumberjack
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configFlag describes a setting accepted by Config.Set.
+type configFlag struct {
+	name string
+
+	// isBool is set for settings which may be given without a value.
+	isBool bool
+
+	// get returns the formatted setting, or "" if it is unset.
+	get func(c *Config) string
+	set func(c *Config, value string) error
+}
+
+// configFlags are the settings accepted by Config.Set, named after their JSON
+// keys, in the order in which String lists them.  PostRotateCommand is
+// missing, as its arguments may contain commas.
+var configFlags = []configFlag{
+	stringFlag("filename", func(c *Config) *string { return &c.Filename }),
+	stringFlag("filenamedatelayout", func(c *Config) *string { return &c.FilenameDateLayout }),
+	sizeFlag("maxbytes", func(c *Config) *ByteSize { return &c.MaxBytes }),
+	intFlag("maxbackups", func(c *Config) *int { return &c.MaxBackups }),
+	intFlag("maxuncompressedbackups", func(c *Config) *int { return &c.MaxUncompressedBackups }),
+	intFlag("maxcompressedbackups", func(c *Config) *int { return &c.MaxCompressedBackups }),
+	{
+		name: "maxage",
+		get:  func(c *Config) string { return formatInt(int64(c.MaxAge)) },
+		set: func(c *Config, v string) (err error) {
+			c.MaxAge, err = parseDays(v)
+
+			return err
+		},
+	},
+	sizeFlag("maxtotalbytes", func(c *Config) *ByteSize { return &c.MaxTotalBytes }),
+	sizeFlag("minfreebytes", func(c *Config) *ByteSize { return &c.MinFreeBytes }),
+	intFlag("minfreepercent", func(c *Config) *int { return &c.MinFreePercent }),
+	durationFlag("diskcheckinterval", func(c *Config) *time.Duration { return &c.DiskCheckInterval }),
+	boolFlag("compressonlowdisk", func(c *Config) *bool { return &c.CompressOnLowDisk }),
+	durationFlag("janitorinterval", func(c *Config) *time.Duration { return &c.JanitorInterval }),
+	boolFlag("compress", func(c *Config) *bool { return &c.Compress }),
+	{
+		name: "compressionformat",
+		get:  func(c *Config) string { return string(c.CompressionFormat) },
+		set: func(c *Config, v string) error {
+			c.CompressionFormat = CompressionFormat(v)
+
+			return nil
+		},
+	},
+	intFlag("compressconcurrency", func(c *Config) *int { return &c.CompressConcurrency }),
+	intFlag("compressworkers", func(c *Config) *int { return &c.CompressWorkers }),
+	{
+		name: "compressbuffersize",
+		get:  func(c *Config) string { return formatInt(int64(c.CompressBufferSize)) },
+		set: func(c *Config, v string) error {
+			n, err := ParseSize(v)
+			c.CompressBufferSize = int(n)
+
+			return err
+		},
+	},
+	boolFlag("compressactive", func(c *Config) *bool { return &c.CompressActive }),
+	durationFlag("compressafter", func(c *Config) *time.Duration { return &c.CompressAfter }),
+	{
+		name: "encryptkey",
+		get:  func(c *Config) string { return base64.StdEncoding.EncodeToString(c.EncryptKey) },
+		set: func(c *Config, v string) (err error) {
+			c.EncryptKey, err = base64.StdEncoding.DecodeString(v)
+
+			return err
+		},
+	},
+	boolFlag("localtime", func(c *Config) *bool { return &c.LocalTime }),
+	{
+		name: "location",
+		get: func(c *Config) string {
+			if c.Location == nil {
+				return ""
+			}
+
+			return c.Location.String()
+		},
+		set: func(c *Config, v string) (err error) {
+			c.Location, err = time.LoadLocation(v)
+
+			return err
+		},
+	},
+	durationFlag("rotationinterval", func(c *Config) *time.Duration { return &c.RotationInterval }),
+	stringFlag("rotateat", func(c *Config) *string { return &c.RotateAt }),
+	boolFlag("rotateonstart", func(c *Config) *bool { return &c.RotateOnStart }),
+	{
+		name: "openmode",
+		get:  func(c *Config) string { return string(c.OpenMode) },
+		set: func(c *Config, v string) error {
+			c.OpenMode = OpenMode(v)
+
+			return nil
+		},
+	},
+	stringFlag("backupdir", func(c *Config) *string { return &c.BackupDir }),
+	{
+		name: "buffersize",
+		get:  func(c *Config) string { return formatInt(int64(c.BufferSize)) },
+		set: func(c *Config, v string) error {
+			n, err := ParseSize(v)
+			c.BufferSize = int(n)
+
+			return err
+		},
+	},
+	durationFlag("flushinterval", func(c *Config) *time.Duration { return &c.FlushInterval }),
+	{
+		name: "asyncbuffersize",
+		get:  func(c *Config) string { return formatInt(int64(c.AsyncBufferSize)) },
+		set: func(c *Config, v string) error {
+			n, err := ParseSize(v)
+			c.AsyncBufferSize = int(n)
+
+			return err
+		},
+	},
+	durationFlag("fallbackretryinterval", func(c *Config) *time.Duration { return &c.FallbackRetryInterval }),
+	intFlag("writeretries", func(c *Config) *int { return &c.WriteRetries }),
+	durationFlag("writeretrydelay", func(c *Config) *time.Duration { return &c.WriteRetryDelay }),
+	{
+		name:   "preserveowner",
+		isBool: true,
+		get: func(c *Config) string {
+			if c.PreserveOwner == nil {
+				return ""
+			}
+
+			return strconv.FormatBool(*c.PreserveOwner)
+		},
+		set: func(c *Config, v string) error {
+			b, err := strconv.ParseBool(v)
+			c.PreserveOwner = &b
+
+			return err
+		},
+	},
+	durationFlag("syncinterval", func(c *Config) *time.Duration { return &c.SyncInterval }),
+	boolFlag("synceverywrite", func(c *Config) *bool { return &c.SyncEveryWrite }),
+	{
+		name: "namingscheme",
+		get:  func(c *Config) string { return string(c.NamingScheme) },
+		set: func(c *Config, v string) error {
+			c.NamingScheme = NamingScheme(v)
+
+			return nil
+		},
+	},
+	stringFlag("backupnametemplate", func(c *Config) *string { return &c.BackupNameTemplate }),
+	{
+		name: "timestampprecision",
+		get:  func(c *Config) string { return string(c.TimestampPrecision) },
+		set: func(c *Config, v string) error {
+			c.TimestampPrecision = TimestampPrecision(v)
+
+			return nil
+		},
+	},
+	boolFlag("preallocate", func(c *Config) *bool { return &c.Preallocate }),
+	{
+		name: "largewritepolicy",
+		get:  func(c *Config) string { return string(c.LargeWritePolicy) },
+		set: func(c *Config, v string) error {
+			c.LargeWritePolicy = LargeWritePolicy(v)
+
+			return nil
+		},
+	},
+	{
+		name: "maxrecordbytes",
+		get:  func(c *Config) string { return formatInt(int64(c.MaxRecordBytes)) },
+		set: func(c *Config, v string) error {
+			n, err := ParseSize(v)
+			c.MaxRecordBytes = int(n)
+
+			return err
+		},
+	},
+	{
+		name: "maxrecordpolicy",
+		get:  func(c *Config) string { return string(c.MaxRecordPolicy) },
+		set: func(c *Config, v string) error {
+			c.MaxRecordPolicy = MaxRecordPolicy(v)
+
+			return nil
+		},
+	},
+	boolFlag("appendnewline", func(c *Config) *bool { return &c.AppendNewline }),
+	boolFlag("stripansi", func(c *Config) *bool { return &c.StripANSI }),
+	sizeFlag("maxbytespersecond", func(c *Config) *ByteSize { return &c.MaxBytesPerSecond }),
+	{
+		name: "ratelimitpolicy",
+		get:  func(c *Config) string { return string(c.RateLimitPolicy) },
+		set: func(c *Config, v string) error {
+			c.RateLimitPolicy = RateLimitPolicy(v)
+
+			return nil
+		},
+	},
+	{
+		name: "lockmode",
+		get:  func(c *Config) string { return string(c.LockMode) },
+		set: func(c *Config, v string) error {
+			c.LockMode = LockMode(v)
+
+			return nil
+		},
+	},
+	stringFlag("symlinkname", func(c *Config) *string { return &c.SymlinkName }),
+	boolFlag("followname", func(c *Config) *bool { return &c.FollowName }),
+	boolFlag("prevsymlink", func(c *Config) *bool { return &c.PrevSymlink }),
+	durationFlag("postrotatetimeout", func(c *Config) *time.Duration { return &c.PostRotateTimeout }),
+	{
+		name: "archiveerrorpolicy",
+		get:  func(c *Config) string { return string(c.ArchiveErrorPolicy) },
+		set: func(c *Config, v string) error {
+			c.ArchiveErrorPolicy = ArchiveErrorPolicy(v)
+
+			return nil
+		},
+	},
+}
+
+// configFlagAliases are shorter names accepted by Config.Set.
+var configFlagAliases = map[string]string{
+	"file":    "filename",
+	"backups": "maxbackups",
+	"age":     "maxage",
+}
+
+// String implements flag.Value, formatting the settings which are set like
+// Set accepts them.
+func (c *Config) String() string {
+	if c == nil {
+		return ""
+	}
+
+	var settings []string
+
+	for _, f := range configFlags {
+		v := f.get(c)
+
+		switch {
+		case v == "":
+		case f.isBool && v == "true":
+			settings = append(settings, f.name)
+		default:
+			settings = append(settings, f.name+"="+v)
+		}
+	}
+
+	return strings.Join(settings, ",")
+}
+
+// Set implements flag.Value, so that a single command line flag can hold all
+// settings of a Logger:
+//
+//	var cfg lumberjack.Config
+//	flag.Var(&cfg, "log-rotate", "log rotation settings")
+//	flag.Parse()
+//	l := cfg.NewLogger()
+//
+// The value is a comma-separated list of settings, such as
+// "file=/var/log/app.log,maxbytes=100MB,backups=5,compress".  Settings are
+// named after their JSON keys, or the lowercase field name for Location, and
+// "file", "backups" and "age" are accepted for filename, maxbackups and
+// maxage.  Sizes are parsed by ParseSize, MaxAge and durations like FromEnv
+// does, Location is a zone name and EncryptKey is base64 encoded.  Boolean
+// settings may be given without a value to enable them.  Settings which are
+// not given keep their current value, so defaults may be assigned before the
+// flags are parsed.  Values can't contain commas.
+func (c *Config) Set(s string) error {
+	for _, setting := range strings.Split(s, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(setting, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if alias, ok := configFlagAliases[name]; ok {
+			name = alias
+		}
+
+		f, ok := findConfigFlag(name)
+		if !ok {
+			return fmt.Errorf("unknown setting %q, must be one of %s", name, configFlagNames())
+		}
+
+		if !hasValue {
+			if !f.isBool {
+				return fmt.Errorf("missing value for %s", name)
+			}
+
+			value = "true"
+		}
+
+		if err := f.set(c, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Type returns the type name of the flag, for use with the pflag package.
+func (c *Config) Type() string {
+	return "settings"
+}
+
+// findConfigFlag returns the setting with the given name.
+func findConfigFlag(name string) (configFlag, bool) {
+	for _, f := range configFlags {
+		if f.name == name {
+			return f, true
+		}
+	}
+
+	return configFlag{}, false
+}
+
+// configFlagNames returns the sorted names of the settings accepted by Set.
+func configFlagNames() string {
+	names := make([]string, 0, len(configFlags))
+	for _, f := range configFlags {
+		names = append(names, f.name)
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+func stringFlag(name string, field func(c *Config) *string) configFlag {
+	return configFlag{
+		name: name,
+		get:  func(c *Config) string { return *field(c) },
+		set: func(c *Config, v string) error {
+			*field(c) = v
+
+			return nil
+		},
+	}
+}
+
+func boolFlag(name string, field func(c *Config) *bool) configFlag {
+	return configFlag{
+		name:   name,
+		isBool: true,
+		get: func(c *Config) string {
+			if !*field(c) {
+				return ""
+			}
+
+			return "true"
+		},
+		set: func(c *Config, v string) (err error) {
+			*field(c), err = strconv.ParseBool(v)
+
+			return err
+		},
+	}
+}
+
+func intFlag(name string, field func(c *Config) *int) configFlag {
+	return configFlag{
+		name: name,
+		get:  func(c *Config) string { return formatInt(int64(*field(c))) },
+		set: func(c *Config, v string) (err error) {
+			*field(c), err = strconv.Atoi(v)
+
+			return err
+		},
+	}
+}
+
+func sizeFlag(name string, field func(c *Config) *ByteSize) configFlag {
+	return configFlag{
+		name: name,
+		get:  func(c *Config) string { return formatInt(int64(*field(c))) },
+		set: func(c *Config, v string) error {
+			return field(c).UnmarshalText([]byte(v))
+		},
+	}
+}
+
+func durationFlag(name string, field func(c *Config) *time.Duration) configFlag {
+	return configFlag{
+		name: name,
+		get: func(c *Config) string {
+			if *field(c) == 0 {
+				return ""
+			}
+
+			return field(c).String()
+		},
+		set: func(c *Config, v string) (err error) {
+			*field(c), err = parseDuration(v)
+
+			return err
+		},
+	}
+}
+
+// formatInt formats n, or returns "" if it is zero.
+func formatInt(n int64) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(n, 10)
+}
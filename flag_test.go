@@ -0,0 +1,60 @@
+package lumberjack
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigFlag(t *testing.T) {
+	cfg := Config{MaxAge: 7}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&cfg, "log-rotate", "log rotation settings")
+
+	err := fs.Parse([]string{
+		"-log-rotate", "file=/var/log/app.log,maxbytes=100MB,backups=5,compress",
+		"-log-rotate", "rotationinterval=1d, preserveowner=false",
+	})
+	isNil(t, err)
+
+	f := false
+	equals(t, Config{
+		Filename:         "/var/log/app.log",
+		MaxBytes:         100 << 20,
+		MaxBackups:       5,
+		MaxAge:           7,
+		Compress:         true,
+		RotationInterval: 24 * time.Hour,
+		PreserveOwner:    &f,
+	}, cfg)
+
+	equals(t, "filename=/var/log/app.log,maxbytes=104857600,maxbackups=5,maxage=7,compress,"+
+		"rotationinterval=24h0m0s,preserveowner=false", cfg.String())
+
+	// The formatted settings are parsed back to the same Config.
+	var parsed Config
+	isNil(t, parsed.Set(cfg.String()))
+	equals(t, cfg, parsed)
+}
+
+func TestConfigFlagInvalid(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"maxbytes=10XB", `invalid maxbytes: invalid size "10XB": unknown unit "xb"`},
+		{"filename", "missing value for filename"},
+		{"compress=maybe", `invalid compress: strconv.ParseBool: parsing "maybe": invalid syntax`},
+		{"colour=red", `unknown setting "colour", must be one of `},
+	}
+
+	for _, test := range tests {
+		var cfg Config
+
+		err := cfg.Set(test.in)
+		notNil(t, err)
+		equals(t, true, strings.HasPrefix(err.Error(), test.want))
+	}
+}
@@ -0,0 +1,154 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// followInterval is the interval at which a reader returned by Follow checks
+// for new data once it has read everything.  It is a variable so tests can
+// shorten it.
+var followInterval = 100 * time.Millisecond
+
+// Follow returns a reader of the active log file which, like tail -F, keeps
+// waiting for new data instead of ending, for streaming the log in-process,
+// e.g. from an HTTP endpoint.  It starts at the beginning of the active file,
+// and after a rotation it continues at the beginning of the new active file
+// once it read the rest of the old one.  Data held in the buffer of
+// BufferSize is read once it is flushed.
+//
+// Read blocks until there is new data, returning ctx's error once ctx is
+// canceled.  Close the reader to stop following and release the file.
+// Follow can't be used with CompressActive.
+func (l *Logger) Follow(ctx context.Context) (io.ReadCloser, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.CompressActive {
+		return nil, errors.New("Follow can't be used with CompressActive")
+	}
+
+	f := &follower{l: l, ctx: ctx, done: make(chan struct{})}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// follower is the reader returned by Follow.
+type follower struct {
+	l    *Logger
+	ctx  context.Context
+	done chan struct{}
+
+	// mu guards the fields below and is held while reading from file, but
+	// not while waiting for new data, so that Close doesn't block.
+	mu     sync.Mutex
+	file   File
+	closed bool
+
+	// generation is the generation of the Logger when file was opened.
+	generation uint64
+}
+
+// open opens the active file, if it exists.  It must be called with l.mu
+// read-locked, so that the file matches the generation.
+func (f *follower) open() error {
+	f.generation = f.l.generation.Load()
+
+	file, err := f.l.fs().OpenFile(f.l.activeName(), os.O_RDONLY, 0)
+	if os.IsNotExist(err) {
+		// Nothing was written yet, or the new active file isn't created
+		// until the next write.
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	f.file = file
+
+	return nil
+}
+
+func (f *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.read(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+
+		select {
+		case <-f.ctx.Done():
+			return 0, f.ctx.Err()
+		case <-f.done:
+			return 0, os.ErrClosed
+		case <-time.After(followInterval):
+		}
+	}
+}
+
+// read reads from the active file, moving on to the new active file after a
+// rotation.  It returns 0 and no error if there is no new data yet.
+func (f *follower) read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	for {
+		// The old file has all its data once the Logger moved on, as it
+		// writes to the file with mu held.
+		generation := f.l.generation.Load()
+
+		if f.file != nil {
+			n, err := f.file.Read(p)
+			if n > 0 || (err != nil && err != io.EOF) {
+				return n, err
+			}
+		}
+
+		if f.file != nil && generation == f.generation {
+			return 0, nil
+		}
+
+		if f.file != nil {
+			f.file.Close()
+			f.file = nil
+		}
+
+		f.l.mu.RLock()
+		err := f.open()
+		f.l.mu.RUnlock()
+
+		if err != nil || f.file == nil {
+			return 0, err
+		}
+	}
+}
+
+func (f *follower) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	f.closed = true
+	close(f.done)
+
+	if f.file != nil {
+		return f.file.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// readFollowed reads len(want) bytes from r and compares them to want.
+func readFollowed(t *testing.T, r io.Reader, want string) {
+	t.Helper()
+
+	b := make([]byte, len(want))
+	_, err := io.ReadFull(r, b)
+	isNil(t, err)
+	equals(t, want, string(b))
+}
+
+func TestFollow(t *testing.T) {
+	currentTime = fakeTime
+
+	defer func(d time.Duration) { followInterval = d }(followInterval)
+	followInterval = time.Millisecond
+
+	dir := makeTempDir(t, "TestFollow")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The active file doesn't exist yet.
+	r, err := l.Follow(ctx)
+	isNil(t, err)
+
+	_, err = l.Write([]byte("one\n"))
+	isNil(t, err)
+	readFollowed(t, r, "one\n")
+
+	_, err = l.Write([]byte("two\n"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	_, err = l.Write([]byte("three\n"))
+	isNil(t, err)
+
+	// The rest of the old file comes before the new one.
+	readFollowed(t, r, "two\nthree\n")
+
+	// Reopening the same file isn't a rotation.
+	isNil(t, l.Close())
+
+	_, err = l.Write([]byte("four\n"))
+	isNil(t, err)
+	readFollowed(t, r, "four\n")
+
+	isNil(t, r.Close())
+
+	_, err = r.Read(make([]byte, 1))
+	equals(t, os.ErrClosed, err)
+}
+
+func TestFollowCanceled(t *testing.T) {
+	currentTime = fakeTime
+
+	defer func(d time.Duration) { followInterval = d }(followInterval)
+	followInterval = time.Millisecond
+
+	dir := makeTempDir(t, "TestFollowCanceled")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r, err := l.Follow(ctx)
+	isNil(t, err)
+
+	defer r.Close()
+
+	readFollowed(t, r, "boo!")
+
+	cancel()
+
+	_, err = r.Read(make([]byte, 1))
+	equals(t, context.Canceled, err)
+}
+
+func TestFollowCompressActive(t *testing.T) {
+	dir := makeTempDir(t, "TestFollowCompressActive")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), CompressActive: true}
+	defer l.Close()
+
+	_, err := l.Follow(context.Background())
+	notNil(t, err)
+}
@@ -0,0 +1,89 @@
+package lumberjack
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// FS is the file system holding the log files and backups of a Logger, so that
+// a Logger can run against an in-memory file system in tests or against a
+// custom storage layer.  Its methods behave like the functions of the same
+// name in package os, and an adapter for afero.Fs only needs to wrap OpenFile.
+type FS interface {
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	Stat(name string) (os.FileInfo, error)
+	Chown(name string, uid, gid int) error
+	ReadDir(name string) ([]os.DirEntry, error)
+	MkdirAll(path string, perm os.FileMode) error
+}
+
+// File is a file opened by an FS.  *os.File implements it.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.Closer
+	Stat() (os.FileInfo, error)
+	Sync() error
+}
+
+// osChown is a var so we can mock it out during tests.
+var osChown = os.Chown
+
+// osFS is the FS of the operating system.
+type osFS struct{}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		// Don't return a typed nil.
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Rename and Remove are retried while the file is busy, as on Windows, virus
+// scanners and the search indexer open files which were just written.
+func (osFS) Rename(oldpath, newpath string) error {
+	return retryBusy(func() error { return os.Rename(oldpath, newpath) })
+}
+
+func (osFS) Remove(name string) error {
+	return retryBusy(func() error { return os.Remove(name) })
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return osStat(name) }
+func (osFS) Chown(name string, uid, gid int) error        { return osChown(name, uid, gid) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+// fs returns the file system of the Logger, see FS.
+func (l *Logger) fs() FS {
+	if l.FS != nil {
+		return l.FS
+	}
+
+	return osFS{}
+}
+
+// checkFS reports an error if a setting which relies on the file system of
+// the operating system is used with a custom FS.  LockMode is checked by
+// checkLock, before the lock is taken.
+func (l *Logger) checkFS() error {
+	if l.FS == nil {
+		return nil
+	}
+
+	switch {
+	case l.SymlinkName != "" || l.PrevSymlink:
+		return errors.New("SymlinkName and PrevSymlink can't be used with a custom FS")
+	case l.FollowName:
+		return errors.New("FollowName can't be used with a custom FS")
+	}
+
+	return nil
+}
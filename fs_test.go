@@ -0,0 +1,117 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// recordingFS is the FS of the operating system, recording the operations
+// made through it.
+type recordingFS struct {
+	osFS
+
+	mu  sync.Mutex
+	ops []string
+}
+
+func (r *recordingFS) record(op, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ops = append(r.ops, op+" "+filepath.Base(name))
+}
+
+func (r *recordingFS) recorded(op string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, o := range r.ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *recordingFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	r.record("open", name)
+
+	return r.osFS.OpenFile(name, flag, perm)
+}
+
+func (r *recordingFS) Rename(oldpath, newpath string) error {
+	r.record("rename", newpath)
+
+	return r.osFS.Rename(oldpath, newpath)
+}
+
+func (r *recordingFS) Remove(name string) error {
+	r.record("remove", name)
+
+	return r.osFS.Remove(name)
+}
+
+func TestFS(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFS")
+	defer os.RemoveAll(dir)
+
+	fs := &recordingFS{}
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Compress: true,
+		FS:       fs,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	backup := filepath.Base(backupFile(dir))
+	equals(t, true, fs.recorded("open "+filepath.Base(filename)))
+	equals(t, true, fs.recorded("rename "+backup))
+	equals(t, true, fs.recorded("open "+backup+compressSuffix))
+	equals(t, true, fs.recorded("remove "+backup))
+
+	exists(t, backupFile(dir)+compressSuffix)
+	notExist(t, backupFile(dir))
+}
+
+func TestFSInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFSInvalid")
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		l    *Logger
+		want string
+	}{
+		{&Logger{LockMode: LockExclusive}, "LockMode can't be used with a custom FS"},
+		{&Logger{PrevSymlink: true}, "SymlinkName and PrevSymlink can't be used with a custom FS"},
+		{&Logger{FollowName: true}, "FollowName can't be used with a custom FS"},
+	}
+
+	for _, test := range tests {
+		test.l.Filename = logFile(dir)
+		test.l.FS = &recordingFS{}
+
+		_, err := test.l.Write([]byte("boo!"))
+		notNil(t, err)
+		equals(t, test.want, err.Error())
+	}
+
+	fileCount(t, dir, 0)
+}
@@ -0,0 +1,179 @@
+// Package gcsarchive archives lumberjack backups to Google Cloud Storage,
+// using the JSON API directly so that no client library is needed:
+//
+//	l := &lumberjack.Logger{
+//		Filename:   "/var/log/myapp/foo.log",
+//		MaxBackups: 3,
+//		Compress:   true,
+//		Archiver: &gcsarchive.Archiver{
+//			Bucket: "my-logs",
+//			Prefix: "myapp/",
+//			Key:    "{yyyy}/{mm}/{dd}/{file}",
+//		},
+//	}
+//
+// On GKE and GCE, credentials are obtained from the metadata server, e.g. for
+// the Kubernetes service account bound with Workload Identity.  Elsewhere, an
+// authenticated Client, such as the one returned by DefaultClient of
+// golang.org/x/oauth2/google, must be set.
+package gcsarchive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+const defaultEndpoint = "https://storage.googleapis.com"
+
+// metadataHost is the host of the metadata server, which can be overridden
+// with the GCE_METADATA_HOST environment variable as in Google's libraries.
+const metadataHost = "metadata.google.internal"
+
+// Archiver is a lumberjack.Archiver uploading backups to a Google Cloud
+// Storage bucket.
+type Archiver struct {
+	// Bucket is the name of the bucket to upload to.
+	Bucket string
+
+	// Prefix is prepended to the object names, e.g. "myapp/".
+	Prefix string
+
+	// Key is the template for the object names, following Prefix.  The
+	// default is the base name of the backup.
+	Key lumberjack.KeyTemplate
+
+	// Vars supplies the custom placeholders of Key.
+	Vars map[string]string
+
+	// Client is the HTTP client used for requests, which must add the
+	// credentials.  The default is to use a client adding access tokens of
+	// the default service account, obtained from the metadata server.
+	Client *http.Client
+
+	// Endpoint is the base URL of the Cloud Storage API.  The default is
+	// "https://storage.googleapis.com".
+	Endpoint string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Archive implements lumberjack.Archiver.
+func (a *Archiver) Archive(ctx context.Context, b lumberjack.BackupInfo) error {
+	if a.Bucket == "" {
+		return errors.New("gcsarchive: no bucket")
+	}
+
+	key, err := a.Key.Key(b, a.Vars)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(b.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+
+	u := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		endpoint, url.PathEscape(a.Bucket), url.QueryEscape(a.Prefix+key))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, f)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = b.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	client := a.Client
+	if client == nil {
+		token, err := a.metadataToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("gcsarchive: uploading %s: %s: %s", key, resp.Status, msg)
+	}
+
+	return nil
+}
+
+// metadataToken returns an access token of the default service account from
+// the metadata server, reusing it until shortly before it expires.
+func (a *Archiver) metadataToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Before(a.expiry) {
+		return a.token, nil
+	}
+
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = metadataHost
+	}
+
+	u := "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gcsarchive: can't get token from metadata server: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gcsarchive: can't get token from metadata server: %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("gcsarchive: invalid token from metadata server: %v", err)
+	}
+
+	a.token = token.AccessToken
+	a.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+
+	return a.token, nil
+}
@@ -0,0 +1,97 @@
+package gcsarchive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+func TestArchive(t *testing.T) {
+	var (
+		tokens  int
+		uploads = make(map[string]string)
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			tokens++
+			_, _ = io.WriteString(w, `{"access_token":"secret","expires_in":3600,"token_type":"Bearer"}`)
+		case "/upload/storage/v1/b/my-logs/o":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			if r.URL.Query().Get("uploadType") != "media" {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			b, _ := io.ReadAll(r.Body)
+			uploads[r.URL.Query().Get("name")] = string(b)
+			_, _ = io.WriteString(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(srv.URL, "http://"))
+
+	path := filepath.Join(t.TempDir(), "foo-2014-05-04T09-44-33.555.log")
+	if err := os.WriteFile(path, []byte("boo!"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Archiver{
+		Bucket:   "my-logs",
+		Prefix:   "myapp/",
+		Key:      "{yyyy}/{mm}/{file}",
+		Endpoint: srv.URL,
+	}
+
+	b := lumberjack.BackupInfo{
+		Path:      path,
+		Timestamp: time.Date(2014, 5, 4, 9, 44, 33, 555000000, time.UTC),
+		Size:      4,
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := a.Archive(context.Background(), b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := uploads["myapp/2014/05/foo-2014-05-04T09-44-33.555.log"]; got != "boo!" {
+		t.Fatalf("unexpected uploads %v", uploads)
+	}
+
+	// The token is reused.
+	if tokens != 1 {
+		t.Fatalf("got %d tokens, want 1", tokens)
+	}
+
+	a.Bucket = "other"
+
+	err := a.Archive(context.Background(), b)
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package lumberjack
+
+import "fmt"
+
+// writeHeader writes the Header, if any, at the start of a new log file.  It
+// must be called with l.mu held.
+func (l *Logger) writeHeader() error {
+	if l.Header == nil {
+		return nil
+	}
+
+	id := l.hooks.enter()
+	header := l.Header()
+	l.hooks.exit(id)
+
+	n, err := l.writeFile(header)
+	l.size += l.grown(n)
+	l.headerLen = l.size
+
+	l.shadow.write(header[:n], l.VerifyTailBytes)
+
+	if err != nil {
+		return fmt.Errorf("can't write header: %s", err)
+	}
+
+	return nil
+}
+
+// writeFooter writes the Footer, if any, at the end of the active file before
+// it is rotated.  It must be called with l.mu held.
+func (l *Logger) writeFooter() error {
+	if l.Footer == nil || l.file == nil {
+		return nil
+	}
+
+	id := l.hooks.enter()
+	footer := l.Footer()
+	l.hooks.exit(id)
+
+	n, err := l.writeFile(footer)
+	l.size += l.grown(n)
+
+	if err != nil {
+		return fmt.Errorf("can't write footer: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHeader(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestHeader")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxBytes: 20,
+		Header:   func() []byte { return []byte("time,msg\n") },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("1,foo\n"))
+	isNil(t, err)
+
+	existsWithContent(t, filename, []byte("time,msg\n1,foo\n"))
+
+	// The header counts towards MaxBytes.
+	_, err = l.Write([]byte("2,bar\n"))
+	isNil(t, err)
+
+	existsWithContent(t, backupFile(dir), []byte("time,msg\n1,foo\n"))
+	existsWithContent(t, filename, []byte("time,msg\n2,bar\n"))
+
+	// No header is written to an existing file.
+	isNil(t, l.Close())
+
+	_, err = l.Write([]byte("3\n"))
+	isNil(t, err)
+
+	existsWithContent(t, filename, []byte("time,msg\n2,bar\n3\n"))
+}
+
+func TestHeaderOnlyNotRotated(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestHeaderOnlyNotRotated")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxBytes: 10,
+		Header:   func() []byte { return []byte("HDR\n") },
+	}
+	defer l.Close()
+
+	// A file holding only its header isn't rotated for a record which
+	// doesn't fit after it, which would leave a backup of just the header.
+	_, err := l.Write([]byte("12345678"))
+	isNil(t, err)
+
+	existsWithContent(t, filename, []byte("HDR\n12345678"))
+	fileCount(t, dir, 1)
+
+	newFakeTime()
+
+	_, err = l.Write([]byte("9\n"))
+	isNil(t, err)
+
+	existsWithContent(t, backupFile(dir), []byte("HDR\n12345678"))
+	existsWithContent(t, filename, []byte("HDR\n9\n"))
+	fileCount(t, dir, 2)
+}
+
+func TestHeaderOnlyNotRotatedOnTime(t *testing.T) {
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir(t, "TestHeaderOnlyNotRotatedOnTime")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		RotationInterval: 50 * time.Millisecond,
+		Header:           func() []byte { return []byte("HDR\n") },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(t, err)
+
+	// The rotation happens in the background without any further writes.
+	<-time.After(200 * time.Millisecond)
+
+	existsWithContent(t, filename, []byte("HDR\n"))
+	fileCount(t, dir, 2)
+
+	// An idle file holding only its header is not rotated, neither by the
+	// timer nor by the next write.
+	<-time.After(300 * time.Millisecond)
+
+	fileCount(t, dir, 2)
+
+	_, err = l.Write([]byte("foo!\n"))
+	isNil(t, err)
+
+	existsWithContent(t, filename, []byte("HDR\nfoo!\n"))
+	fileCount(t, dir, 2)
+}
+
+func TestFooter(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFooter")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxBytes: 10,
+		Footer:   func() []byte { return []byte("--end--\n") },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("foo\n"))
+	isNil(t, err)
+
+	_, err = l.Write([]byte("barbaz\n"))
+	isNil(t, err)
+
+	existsWithContent(t, backupFile(dir), []byte("foo\n--end--\n"))
+	existsWithContent(t, filename, []byte("barbaz\n"))
+
+	// No footer is written on Close.
+	isNil(t, l.Close())
+	existsWithContent(t, filename, []byte("barbaz\n"))
+}
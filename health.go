@@ -0,0 +1,105 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// millStallTimeout is the time after which a run of the mill is
+	// considered stuck by Healthy.  It is generous, as compressing and
+	// archiving large backups takes a while.
+	millStallTimeout = 15 * time.Minute
+
+	// asyncStallTimeout is the time after which the goroutine writing the
+	// queue of AsyncBufferSize is considered stuck by Healthy if it didn't
+	// write a record.
+	asyncStallTimeout = time.Minute
+
+	// recursiveWriteWindow is the time for which Healthy reports a write from
+	// within a hook of the Logger which was dropped.
+	recursiveWriteWindow = time.Minute
+)
+
+// Healthy reports an error if the Logger can't be relied on to keep records,
+// so that services can include the log sink in their readiness probes: if
+// writes go to Fallback, if the active file was removed or the settings are
+// invalid, if the free disk space is below MinFreeBytes or MinFreePercent, or
+// if the goroutine cleaning up old log files or the one writing the queue of
+// AsyncBufferSize seems stuck, or if a write from within a hook of the Logger
+// was dropped in the last minute, see Stats.RecursiveWrites.  It doesn't
+// write anything, so a log file which hasn't been opened yet is only checked
+// when it is first written.
+func (l *Logger) Healthy() error {
+	if err := l.fileHealthy(); err != nil {
+		return err
+	}
+
+	if l.watchesDisk() {
+		low, err := l.lowOnDisk()
+		if err != nil {
+			return err
+		}
+
+		if low {
+			return errors.New("free disk space is below MinFreeBytes or MinFreePercent")
+		}
+	}
+
+	now := l.now()
+
+	l.hooksMu.Lock()
+	started := l.millStarted
+	l.hooksMu.Unlock()
+
+	if !started.IsZero() && now.Sub(started) > millStallTimeout {
+		return fmt.Errorf("cleanup of old log files has been running since %s", started.Format(time.RFC3339))
+	}
+
+	q := &l.async
+
+	q.mu.Lock()
+	stalled := q.running && q.size > 0 && now.Sub(q.progress) > asyncStallTimeout
+	progress := q.progress
+	q.mu.Unlock()
+
+	if stalled {
+		return fmt.Errorf("queued records haven't been written since %s", progress.Format(time.RFC3339))
+	}
+
+	l.statsMu.Lock()
+	recursive := l.lastRecursive
+	l.statsMu.Unlock()
+
+	if !recursive.IsZero() && now.Sub(recursive) < recursiveWriteWindow {
+		return fmt.Errorf("a write from within a hook of the Logger was dropped at %s", recursive.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// fileHealthy reports an error if the log file can't be written.
+func (l *Logger) fileHealthy() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.fallingBack() {
+		return fmt.Errorf("log file is unavailable, writing to Fallback until %s",
+			l.fallbackUntil.Format(time.RFC3339))
+	}
+
+	if err := l.checkSettings(); err != nil {
+		return err
+	}
+
+	if l.file == nil {
+		return nil
+	}
+
+	if _, err := l.fs().Stat(l.activeName()); err != nil {
+		return fmt.Errorf("can't find active log file: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHealthy(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestHealthy")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	// A Logger which hasn't written yet is healthy.
+	isNil(t, l.Healthy())
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+	isNil(t, l.Healthy())
+
+	// The active file was removed behind the Logger's back.
+	isNil(t, os.Remove(logFile(dir)))
+	notNil(t, l.Healthy())
+
+	isNil(t, l.CloseAndWait())
+	isNil(t, l.Healthy())
+
+	// A cleanup run which doesn't finish is reported.
+	l.hooksMu.Lock()
+	l.millStarted = fakeTime()
+	l.hooksMu.Unlock()
+
+	isNil(t, l.Healthy())
+
+	newFakeTime()
+	notNil(t, l.Healthy())
+}
+
+func TestHealthyFallback(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestHealthyFallback")
+	defer os.RemoveAll(dir)
+
+	blocker := filepath.Join(dir, "logs")
+	isNil(t, os.WriteFile(blocker, nil, fileModeNew))
+
+	l := &Logger{
+		Filename:              filepath.Join(blocker, "foobar.log"),
+		Fallback:              failingWriter{},
+		FallbackRetryInterval: time.Hour,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+
+	err = l.Healthy()
+	notNil(t, err)
+	equals(t, "log file is unavailable, writing to Fallback until "+
+		fakeTime().Add(time.Hour).Format(time.RFC3339), err.Error())
+}
+
+func TestHealthyDiskSpace(t *testing.T) {
+	currentTime = fakeTime
+	defer fakeDiskSpace(1000)()
+
+	dir := makeTempDir(t, "TestHealthyDiskSpace")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		MaxBytes:     1000,
+		MinFreeBytes: 500,
+	}
+	defer l.Close()
+
+	_, err := l.Write(make([]byte, 400))
+	isNil(t, err)
+	isNil(t, l.Healthy())
+
+	_, err = l.Write(make([]byte, 200))
+	isNil(t, err)
+
+	err = l.Healthy()
+	notNil(t, err)
+	equals(t, "free disk space is below MinFreeBytes or MinFreePercent", err.Error())
+
+	isNil(t, l.CloseAndWait())
+}
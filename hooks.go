@@ -0,0 +1,167 @@
+package lumberjack
+
+import (
+	"path/filepath"
+)
+
+// errorsBuffer is the number of errors buffered by the channel returned by
+// Errors.
+const errorsBuffer = 64
+
+// RotationReason tells what triggered a rotation.
+type RotationReason string
+
+const (
+	// RotationSize is a rotation because the log file reached MaxBytes.
+	RotationSize RotationReason = "size"
+
+	// RotationTime is a rotation because RotationInterval elapsed or RotateAt
+	// was reached.
+	RotationTime RotationReason = "time"
+
+	// RotationManual is a rotation requested by Rotate or RotateContext.
+	RotationManual RotationReason = "manual"
+
+	// RotationOpen is a rotation because the existing log file couldn't be
+	// opened for appending.
+	RotationOpen RotationReason = "open"
+
+	// RotationStart is a rotation of the existing log file when the Logger
+	// first opens it, see RotateOnStart.
+	RotationStart RotationReason = "start"
+)
+
+// rotation records a rotated log file for OnRotate and PostRotateCommand.
+type rotation struct {
+	oldPath string
+	newPath string
+	reason  RotationReason
+}
+
+// renameBackup moves the log file at oldPath to the backup at newPath and
+// records the rotation for the mill goroutine to report to OnRotate and
+// PostRotateCommand.  Both happen under hooksMu, so that the mill never finds
+// the backup without the pending rotation and compresses it before it was
+// reported.  It must be called with l.mu held.
+func (l *Logger) renameBackup(oldPath, newPath string, reason RotationReason) error {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	if err := l.fs().Rename(oldPath, newPath); err != nil {
+		return err
+	}
+
+	if l.OnRotate != nil || len(l.PostRotateCommand) > 0 {
+		l.rotations = append(l.rotations, rotation{oldPath, newPath, reason})
+	}
+
+	return nil
+}
+
+// unreported returns the given backups except those whose rotation has not
+// been reported yet, which are left for the next run of the mill.  It must be
+// called from the mill goroutine after the backups were listed.
+func (l *Logger) unreported(files []logInfo) []logInfo {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	if len(l.rotations) == 0 {
+		return files
+	}
+
+	pending := make(map[string]bool, len(l.rotations))
+	for _, r := range l.rotations {
+		pending[filepath.Base(r.newPath)] = true
+	}
+
+	var reported []logInfo
+
+	for _, f := range files {
+		if !pending[f.Name()] {
+			reported = append(reported, f)
+		}
+	}
+
+	return reported
+}
+
+// notifyRotations reports the queued rotations to OnRotate and runs
+// PostRotateCommand for them.  It is run by the mill goroutine, before the new
+// backups are compressed, so that the callback never runs with l.mu held.
+func (l *Logger) notifyRotations() {
+	l.hooksMu.Lock()
+	rotations := l.rotations
+	l.rotations = nil
+	l.hooksMu.Unlock()
+
+	for _, r := range rotations {
+		if l.OnRotate != nil {
+			l.OnRotate(r.oldPath, r.newPath, r.reason)
+		}
+
+		if len(l.PostRotateCommand) > 0 {
+			l.queueError(l.runPostRotateCommand(r.newPath))
+		}
+	}
+}
+
+// Errors returns a channel delivering the same errors as OnError, for
+// programs which prefer to collect them from a channel.  Errors occurring
+// before the first call are not delivered.  The channel buffers a limited
+// number of errors; if it is full, further errors are dropped and counted by
+// DroppedErrors.  The channel is never closed.
+func (l *Logger) Errors() <-chan error {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	if l.errCh == nil {
+		l.errCh = make(chan error, errorsBuffer)
+	}
+
+	return l.errCh
+}
+
+// DroppedErrors returns the number of errors which were not delivered on the
+// channel returned by Errors because it was full.
+func (l *Logger) DroppedErrors() int64 {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	return l.droppedErrs
+}
+
+// queueError records an error of a background operation for the mill
+// goroutine to report to OnError, and delivers it on the Errors channel.
+func (l *Logger) queueError(err error) {
+	if err == nil {
+		return
+	}
+
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	if l.errCh != nil {
+		select {
+		case l.errCh <- err:
+		default:
+			l.droppedErrs++
+		}
+	}
+
+	if l.OnError != nil {
+		l.errs = append(l.errs, err)
+	}
+}
+
+// notifyErrors reports the queued errors to OnError.  It is run by the mill
+// goroutine.
+func (l *Logger) notifyErrors() {
+	l.hooksMu.Lock()
+	errs := l.errs
+	l.errs = nil
+	l.hooksMu.Unlock()
+
+	for _, err := range errs {
+		l.OnError(err)
+	}
+}
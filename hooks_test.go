@@ -0,0 +1,214 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnRotate(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestOnRotate")
+	defer os.RemoveAll(dir)
+
+	type rotation struct {
+		oldPath, newPath string
+		reason           RotationReason
+		existed          bool
+	}
+
+	rotated := make(chan rotation, 2)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Compress: true,
+		OnRotate: func(oldPath, newPath string, reason RotationReason) {
+			_, err := os.Stat(newPath)
+			rotated <- rotation{oldPath, newPath, reason, err == nil}
+		},
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+
+	isNil(t, l.Rotate())
+
+	select {
+	case r := <-rotated:
+		equals(t, filename, r.oldPath)
+		equals(t, backupFile(dir), r.newPath)
+		equals(t, RotationManual, r.reason)
+
+		// The backup wasn't compressed yet.
+		equals(t, true, r.existed)
+	case <-time.After(time.Second):
+		t.Fatal("OnRotate wasn't called")
+	}
+
+	// we need to wait a little bit since the files get compressed on a
+	// different goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	exists(t, backupFile(dir)+compressSuffix)
+	equals(t, 0, len(rotated))
+}
+
+func TestOnError(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestOnError")
+	defer os.RemoveAll(dir)
+
+	// A dangling symlink looks like a backup, but can't be compressed.
+	err := os.Symlink(filepath.Join(dir, "missing"), backupFile(dir))
+	isNil(t, err)
+
+	errs := make(chan error, 2)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+		OnError: func(err error) {
+			errs <- err
+		},
+	}
+	defer l.Close()
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	select {
+	case err := <-errs:
+		equals(t, true, strings.HasPrefix(err.Error(), "failed to open log file: "))
+	case <-time.After(time.Second):
+		t.Fatal("OnError wasn't called")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestErrors")
+	defer os.RemoveAll(dir)
+
+	// A dangling symlink looks like a backup, but can't be compressed.
+	err := os.Symlink(filepath.Join(dir, "missing"), backupFile(dir))
+	isNil(t, err)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+	}
+	defer l.Close()
+
+	errs := l.Errors()
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	select {
+	case err := <-errs:
+		equals(t, true, strings.HasPrefix(err.Error(), "failed to open log file: "))
+	case <-time.After(time.Second):
+		t.Fatal("no error was delivered")
+	}
+
+	equals(t, int64(0), l.DroppedErrors())
+}
+
+func TestErrorsDropped(t *testing.T) {
+	l := &Logger{}
+
+	// Errors are only delivered once the channel was requested.
+	l.queueError(errors.New("lost"))
+
+	errs := l.Errors()
+
+	for i := 0; i < errorsBuffer+2; i++ {
+		l.queueError(fmt.Errorf("error %d", i))
+	}
+
+	equals(t, int64(2), l.DroppedErrors())
+	equals(t, errorsBuffer, len(errs))
+	equals(t, "error 0", (<-errs).Error())
+}
+
+func TestPostRotateCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell available")
+	}
+
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestPostRotateCommand")
+	defer os.RemoveAll(dir)
+
+	errs := make(chan error, 10)
+	out := filepath.Join(dir, "rotated.txt")
+
+	// The script copies the backup, unless told otherwise by its content.
+	script := `case $(cat "$1") in
+fail) echo oops >&2; exit 3;;
+slow) exec sleep 10;;
+esac
+cp "$1" "$0"`
+
+	l := &Logger{
+		Filename:          logFile(dir),
+		PostRotateCommand: []string{"sh", "-c", script, out},
+		PostRotateTimeout: 200 * time.Millisecond,
+		OnError:           func(err error) { errs <- err },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	// we need to wait a little bit since the command runs on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	existsWithContent(t, out, []byte("boo!"))
+	equals(t, 0, len(errs))
+
+	// A failure is reported with the command's standard error.
+	_, err = l.Write([]byte("fail"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	select {
+	case err := <-errs:
+		equals(t, "post-rotate command sh: exit status 3: oops", err.Error())
+	case <-time.After(time.Second):
+		t.Fatal("no error reported")
+	}
+
+	_, err = l.Write([]byte("slow"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	select {
+	case err := <-errs:
+		equals(t, "post-rotate command sh: timed out after 200ms", err.Error())
+	case <-time.After(time.Second):
+		t.Fatal("no error reported")
+	}
+}
@@ -0,0 +1,48 @@
+package lumberjack
+
+import "context"
+
+// Operation is an operation of a Logger reported to its Instrumentation.
+type Operation string
+
+const (
+	// OperationRotate is the rotation of the log file, for any
+	// RotationReason.
+	OperationRotate Operation = "rotate"
+
+	// OperationCompress is the compression or encryption of a backup.
+	OperationCompress Operation = "compress"
+)
+
+// Instrumentation observes the rotations and compressions of a Logger, to
+// trace them in an observability stack.  Package lumberjackotel implements it
+// with OpenTelemetry spans and metrics.
+type Instrumentation interface {
+	// Start is called when op begins on the file at path, and returns a
+	// function which is called with the error of op once it ended.  ctx is
+	// the context of op: the one canceled by Shutdown for compressions, and
+	// context.Background for rotations.  Rotations are reported with the
+	// Logger locked, so neither function may call its methods; writes from
+	// them to the Logger are dropped, see Stats.RecursiveWrites.  Backups may
+	// be compressed concurrently, see CompressWorkers.
+	Start(ctx context.Context, op Operation, path string) (end func(err error))
+}
+
+// instrument reports the start of op on the file at path to Instrumentation,
+// and returns the function to report its end.  Both are called as hooks, as
+// they may be called with the Logger locked.
+func (l *Logger) instrument(ctx context.Context, op Operation, path string) func(err error) {
+	if l.Instrumentation == nil {
+		return func(error) {}
+	}
+
+	id := l.hooks.enter()
+	end := l.Instrumentation.Start(ctx, op, path)
+	l.hooks.exit(id)
+
+	return func(err error) {
+		defer l.hooks.exit(l.hooks.enter())
+
+		end(err)
+	}
+}
@@ -0,0 +1,152 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// recordingInstrumentation records the operations reported to it.
+type recordingInstrumentation struct {
+	mu  sync.Mutex
+	ops []string
+}
+
+func (r *recordingInstrumentation) Start(_ context.Context, op Operation, path string) func(err error) {
+	return func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		s := string(op) + " " + filepath.Base(path)
+		if err != nil {
+			s += ": failed"
+		}
+
+		r.ops = append(r.ops, s)
+	}
+}
+
+func TestInstrumentation(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestInstrumentation")
+	defer os.RemoveAll(dir)
+
+	var rec recordingInstrumentation
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		Compress:        true,
+		Instrumentation: &rec,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	// The mill started by the first write may compress the backup before
+	// the rotation has been reported, so the order isn't checked.
+	sort.Strings(rec.ops)
+	equals(t, []string{
+		"compress " + filepath.Base(backupFile(dir)),
+		"rotate foobar.log",
+	}, rec.ops)
+}
+
+func TestInstrumentationError(t *testing.T) {
+	dir := makeTempDir(t, "TestInstrumentationError")
+	defer os.RemoveAll(dir)
+
+	var rec recordingInstrumentation
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		Instrumentation: &rec,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	// A file in place of the log directory makes the rotation fail.
+	isNil(t, l.Close())
+	isNil(t, os.RemoveAll(dir))
+	isNil(t, os.WriteFile(dir, nil, fileModeNew))
+
+	notNil(t, l.Rotate())
+	equals(t, []string{"rotate foobar.log: failed"}, rec.ops)
+}
+
+func TestInstrumentationPeriod(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestInstrumentationPeriod")
+	defer os.RemoveAll(dir)
+
+	var rec recordingInstrumentation
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		FilenameDateLayout: "2006-01-02",
+		Instrumentation:    &rec,
+	}
+	defer l.Close()
+
+	first := "foobar-" + fakeTime().UTC().Format("2006-01-02") + ".log"
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	// Moving on to the file of the next period is a rotation.
+	newFakeTime()
+
+	_, err = l.Write([]byte("foo!"))
+	isNil(t, err)
+	equals(t, []string{"rotate " + first}, rec.ops)
+}
+
+// appendFailingFS is the FS of the operating system, failing to open files
+// for appending.
+type appendFailingFS struct {
+	osFS
+}
+
+func (appendFailingFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&os.O_APPEND != 0 {
+		return nil, os.ErrPermission
+	}
+
+	return osFS{}.OpenFile(name, flag, perm)
+}
+
+func TestInstrumentationOpen(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestInstrumentationOpen")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	isNil(t, os.WriteFile(filename, []byte("old!"), fileModeNew))
+
+	var rec recordingInstrumentation
+
+	l := &Logger{
+		Filename:        filename,
+		FS:              appendFailingFS{},
+		Instrumentation: &rec,
+	}
+	defer l.Close()
+
+	// The existing file which can't be opened is moved aside.
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+	existsWithContent(t, backupFile(dir), []byte("old!"))
+	equals(t, []string{"rotate foobar.log"}, rec.ops)
+}
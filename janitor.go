@@ -0,0 +1,38 @@
+package lumberjack
+
+import "time"
+
+// startJanitor starts the timer cleaning up old log files every
+// JanitorInterval, if it is set, replacing a running one.  It must be called
+// with l.mu held.
+func (l *Logger) startJanitor() {
+	l.stopJanitor()
+
+	if l.JanitorInterval > 0 {
+		l.janitor = time.AfterFunc(l.JanitorInterval, l.timedCleanup)
+	}
+}
+
+// stopJanitor stops the janitor timer, if any.  It must be called with l.mu
+// held.
+func (l *Logger) stopJanitor() {
+	if l.janitor != nil {
+		l.janitor.Stop()
+		l.janitor = nil
+	}
+}
+
+// timedCleanup is run by the janitor timer.  It has the mill clean up old log
+// files, and rearms the timer.
+func (l *Logger) timedCleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil || l.janitor == nil {
+		return
+	}
+
+	l.mill()
+
+	l.janitor = time.AfterFunc(l.JanitorInterval, l.timedCleanup)
+}
@@ -0,0 +1,47 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestJanitorInterval(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestJanitorInterval")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		MaxAge:          1,
+		JanitorInterval: 10 * time.Millisecond,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	// A backup expiring while the Logger is idle is removed without a
+	// rotation.
+	backup := backupFile(dir)
+	isNil(t, os.WriteFile(backup, []byte("foo!"), fileModeNew))
+
+	// The mill reads the time with millMu held.
+	l.millMu.Lock()
+	fakeCurrentTime = fakeCurrentTime.Add(2 * 24 * time.Hour)
+	l.millMu.Unlock()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the expired backup wasn't removed")
+		}
+	}
+
+	isNil(t, l.CloseAndWait())
+	fileCount(t, dir, 1)
+}
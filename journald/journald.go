@@ -0,0 +1,122 @@
+// Package journald sends log records to the systemd journal, to keep them
+// while the log file of a lumberjack.Logger can't be written, e.g. because
+// the disk is full:
+//
+//	j, err := journald.New("myapp")
+//	if err != nil {
+//		panic(err)
+//	}
+//
+//	l := &lumberjack.Logger{
+//		Filename: "/var/log/myapp/foo.log",
+//		Fallback: j,
+//		OnRecover: func(downtime time.Duration) {
+//			fmt.Fprintf(j, "log file is writable again after %v\n", downtime)
+//		},
+//	}
+//
+// Records are sent over the native protocol of journald, one journal entry per
+// record, so that multi-line records stay together.
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// socketPath is the socket of the native protocol of journald.
+var socketPath = "/run/systemd/journal/socket"
+
+// Priorities of journal entries, as defined by syslog.
+const (
+	PriorityErr     = 3
+	PriorityWarning = 4
+	PriorityNotice  = 5
+	PriorityInfo    = 6
+)
+
+// Writer is an io.Writer sending every record written to it to the journal as
+// a separate entry.  It is safe for concurrent use.
+type Writer struct {
+	// Priority is the syslog priority of the entries, PriorityInfo by
+	// default.  It must not be changed concurrently with Write.
+	Priority int
+
+	identifier string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+	buf  bytes.Buffer
+}
+
+// New returns a Writer sending entries with the SYSLOG_IDENTIFIER identifier,
+// or an error if journald can't be reached, e.g. on a host without systemd.
+func New(identifier string) (*Writer, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to journald: %v", err)
+	}
+
+	return &Writer{Priority: PriorityInfo, identifier: identifier, conn: conn}, nil
+}
+
+// Write sends p as the message of one journal entry, without its trailing
+// newline.  Records larger than the maximum datagram size of the socket, a
+// few hundred kilobytes by default, fail.
+func (w *Writer) Write(p []byte) (int, error) {
+	msg := bytes.TrimSuffix(p, []byte("\n"))
+	if len(msg) == 0 {
+		return len(p), nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Reset()
+	writeField(&w.buf, "PRIORITY", []byte(strconv.Itoa(w.Priority)))
+
+	if w.identifier != "" {
+		writeField(&w.buf, "SYSLOG_IDENTIFIER", []byte(w.identifier))
+	}
+
+	writeField(&w.buf, "MESSAGE", msg)
+
+	if _, err := w.conn.Write(w.buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("can't send to journald: %v", err)
+	}
+
+	return len(p), nil
+}
+
+// Close closes the connection to journald.
+func (w *Writer) Close() error {
+	return w.conn.Close()
+}
+
+// writeField appends a field to an entry in the native protocol: a value
+// without newlines follows the name after "=", and any other value follows
+// it on the next line, prefixed by its length as a little-endian uint64.
+func writeField(buf *bytes.Buffer, name string, value []byte) {
+	buf.WriteString(name)
+
+	if bytes.IndexByte(value, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.Write(value)
+		buf.WriteByte('\n')
+
+		return
+	}
+
+	var size [8]byte
+
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+
+	buf.WriteByte('\n')
+	buf.Write(size[:])
+	buf.Write(value)
+	buf.WriteByte('\n')
+}
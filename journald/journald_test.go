@@ -0,0 +1,121 @@
+package journald
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+// listen replaces the journald socket with one in a temporary directory and
+// returns it.
+func listen(t *testing.T) *net.UnixConn {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "socket")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets are unavailable: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	old := socketPath
+	socketPath = path
+
+	t.Cleanup(func() { socketPath = old })
+
+	return conn
+}
+
+func receive(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 64*1024)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(buf[:n])
+}
+
+func TestWrite(t *testing.T) {
+	conn := listen(t)
+
+	w, err := New("myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	n, err := w.Write([]byte("boo!\n"))
+	if err != nil || n != 5 {
+		t.Fatalf("Write returned %d, %v", n, err)
+	}
+
+	if got, exp := receive(t, conn), "PRIORITY=6\nSYSLOG_IDENTIFIER=myapp\nMESSAGE=boo!\n"; got != exp {
+		t.Fatalf("expected %q, got %q", exp, got)
+	}
+
+	w.Priority = PriorityWarning
+
+	if _, err := w.Write([]byte("foo\nbar\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "PRIORITY=4\nSYSLOG_IDENTIFIER=myapp\nMESSAGE\n\x07\x00\x00\x00\x00\x00\x00\x00foo\nbar\n"
+	if got := receive(t, conn); got != exp {
+		t.Fatalf("expected %q, got %q", exp, got)
+	}
+}
+
+func TestNewUnavailable(t *testing.T) {
+	old := socketPath
+	socketPath = filepath.Join(t.TempDir(), "missing")
+
+	defer func() { socketPath = old }()
+
+	if _, err := New("myapp"); err == nil {
+		t.Fatal("expected an error without journald")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	conn := listen(t)
+
+	w, err := New("myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// A file in place of the log directory makes the log file unavailable.
+	blocker := filepath.Join(t.TempDir(), "logs")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l := &lumberjack.Logger{
+		Filename: filepath.Join(blocker, "foo.log"),
+		Fallback: w,
+	}
+	defer l.Close()
+
+	if _, err := l.Write([]byte("boo!\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, exp := receive(t, conn), "PRIORITY=6\nSYSLOG_IDENTIFIER=myapp\nMESSAGE=boo!\n"; got != exp {
+		t.Fatalf("expected %q, got %q", exp, got)
+	}
+}
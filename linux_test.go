@@ -5,6 +5,7 @@
 package lumberjack
 
 import (
+	"context"
 	"os"
 	"syscall"
 	"testing"
@@ -177,6 +178,58 @@ func TestCompressMaintainOwner(t *testing.T) {
 	equals(t, 666, fakeFS.files[filename2+compressSuffix].gid)
 }
 
+func TestPreserveOwnerDisabled(t *testing.T) {
+	// The stubs are passed through FS rather than osChown and osStat, which
+	// the mill of an earlier test may still be using.
+	fakeFS := newFakeFS()
+	currentTime = fakeTime
+	dir := makeTempDir(t, "TestPreserveOwnerDisabled")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0644)
+	isNil(t, err)
+	f.Close()
+
+	preserve := false
+	l := &Logger{
+		Compress:      true,
+		Filename:      filename,
+		PreserveOwner: &preserve,
+		FS:            ownerFS{fake: fakeFS},
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	newFakeTime()
+
+	err = l.Rotate()
+	isNil(t, err)
+	isNil(t, l.waitMill(context.Background()))
+
+	exists(t, backupFile(dir)+compressSuffix)
+	equals(t, 0, len(fakeFS.files))
+}
+
+// ownerFS is the file system of the OS, with the ownership of files faked by
+// fake.
+type ownerFS struct {
+	osFS
+	fake *fakeFS
+}
+
+func (fs ownerFS) Stat(name string) (os.FileInfo, error) {
+	return fs.fake.Stat(name)
+}
+
+func (fs ownerFS) Chown(name string, uid, gid int) error {
+	return fs.fake.Chown(name, uid, gid)
+}
+
 type fakeFile struct {
 	uid int
 	gid int
@@ -205,3 +258,40 @@ func (fs *fakeFS) Stat(name string) (os.FileInfo, error) {
 	stat.Gid = 666
 	return info, nil
 }
+
+func TestPreallocate(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t, "TestPreallocate")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		MaxBytes:    1 << 20,
+		Preallocate: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	allocated := func(name string) int64 {
+		info, err := os.Stat(name)
+		isNil(t, err)
+
+		return info.Sys().(*syscall.Stat_t).Blocks * 512
+	}
+
+	if allocated(filename) < 1<<20 {
+		t.Skip("filesystem doesn't support fallocate")
+	}
+
+	// The size is unchanged, and the reserved space is released on rotation.
+	existsWithContent(t, filename, []byte("boo!"))
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	equals(t, true, allocated(backupFile(dir)) < 1<<20)
+	equals(t, true, allocated(filename) >= 1<<20)
+}
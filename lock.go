@@ -0,0 +1,159 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// LockMode selects how a Logger coordinates with other processes using the
+// same Filename.
+type LockMode string
+
+const (
+	// LockExclusive makes a Logger hold a lock while its log file is open, so
+	// that another process using the same Filename fails to write instead of
+	// clobbering the log file and its backups.
+	LockExclusive LockMode = "exclusive"
+
+	// LockShared lets several processes append to the same log file.  Every
+	// write and rotation takes a lock, reopens the log file if another process
+	// rotated it meanwhile, and measures its actual size, so that exactly one
+	// process rotates it when it is full.  Compression and removal of backups
+	// are serialized with a second lock.  It can't be used with BufferSize.
+	LockShared LockMode = "shared"
+)
+
+// Suffixes appended to the log filename to name the lock files.
+const (
+	lockSuffix     = ".lock"
+	millLockSuffix = ".mill.lock"
+)
+
+// errLocked is returned by lockFile if the file is locked by another process.
+var errLocked = errors.New("locked by another process")
+
+// checkLock reports an error if LockMode is unknown or used with incompatible
+// settings.
+func (l *Logger) checkLock() error {
+	if l.LockMode != "" && l.FS != nil {
+		// The lock files live on the file system of the operating system.
+		return errors.New("LockMode can't be used with a custom FS")
+	}
+
+	switch l.LockMode {
+	case "", LockExclusive:
+		return nil
+	case LockShared:
+		if l.BufferSize > 0 {
+			return errors.New("BufferSize can't be used with LockShared")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown LockMode %q", l.LockMode)
+}
+
+// lock acquires the lock selected by LockMode before the log file is written
+// to or rotated.  In LockShared mode, it then catches up with rotations and
+// writes of other processes.  It must be called with l.mu held, and be
+// followed by unlock.
+func (l *Logger) lock() error {
+	if l.LockMode == "" {
+		return nil
+	}
+
+	if err := l.checkLock(); err != nil {
+		return err
+	}
+
+	if l.lockFile == nil {
+		if err := os.MkdirAll(l.dir(), dirMode); err != nil {
+			return fmt.Errorf("can't make directories for new logfile: %s", err)
+		}
+
+		f, err := os.OpenFile(l.filename()+lockSuffix, os.O_CREATE|os.O_RDWR, fileModeNew)
+		if err != nil {
+			return fmt.Errorf("can't open lock file: %s", err)
+		}
+
+		// The exclusive lock is held until the Logger is closed.
+		if l.LockMode == LockExclusive {
+			if err := lockFile(f, false); err != nil {
+				f.Close()
+
+				return fmt.Errorf("can't lock log file %s: %s", l.filename(), err)
+			}
+		}
+
+		l.lockFile = f
+	}
+
+	if l.LockMode != LockShared {
+		return nil
+	}
+
+	if err := lockFile(l.lockFile, true); err != nil {
+		return fmt.Errorf("can't lock log file %s: %s", l.filename(), err)
+	}
+
+	if l.file == nil {
+		return nil
+	}
+
+	if l.fileMoved() {
+		return l.reopen()
+	}
+
+	info, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("error getting log file info: %s", err)
+	}
+
+	l.size = info.Size()
+
+	return nil
+}
+
+// unlock releases the lock acquired by lock in LockShared mode.  It must be
+// called with l.mu held.
+func (l *Logger) unlock() {
+	if l.LockMode == LockShared && l.lockFile != nil {
+		_ = unlockFile(l.lockFile)
+	}
+}
+
+// closeLock releases the lock file.  It must be called with l.mu held.
+func (l *Logger) closeLock() error {
+	if l.lockFile == nil {
+		return nil
+	}
+
+	err := l.lockFile.Close()
+
+	l.lockFile = nil
+
+	return err
+}
+
+// lockMill serializes the mill with those of other processes in LockShared
+// mode.  It returns a function releasing the lock.
+func (l *Logger) lockMill() (func(), error) {
+	if l.LockMode != LockShared {
+		return func() {}, nil
+	}
+
+	f, err := os.OpenFile(l.filename()+millLockSuffix, os.O_CREATE|os.O_RDWR, fileModeNew)
+	if err != nil {
+		return nil, fmt.Errorf("can't open lock file: %s", err)
+	}
+
+	if err := lockFile(f, true); err != nil {
+		f.Close()
+
+		return nil, fmt.Errorf("can't lock backups of %s: %s", l.filename(), err)
+	}
+
+	return func() { f.Close() }, nil
+}
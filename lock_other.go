@@ -0,0 +1,17 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+)
+
+func lockFile(_ *os.File, _ bool) error {
+	return errors.New("file locking is not supported on this platform")
+}
+
+func unlockFile(_ *os.File) error {
+	return nil
+}
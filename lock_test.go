@@ -0,0 +1,83 @@
+package lumberjack
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLockExclusive(t *testing.T) {
+	if runtime.GOOS == "solaris" || runtime.GOOS == "aix" || runtime.GOOS == "illumos" {
+		t.Skip("file locking is not supported")
+	}
+
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestLockExclusive")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l1 := &Logger{Filename: filename, LockMode: LockExclusive}
+	defer l1.Close()
+
+	l2 := &Logger{Filename: filename, LockMode: LockExclusive}
+	defer l2.Close()
+
+	_, err := l1.Write([]byte("boo!"))
+	isNil(t, err)
+
+	_, err = l2.Write([]byte("bar"))
+	notNil(t, err)
+	equals(t, true, strings.HasSuffix(err.Error(), errLocked.Error()))
+
+	// The lock is released on Close.
+	isNil(t, l1.Close())
+
+	_, err = l2.Write([]byte("bar"))
+	isNil(t, err)
+
+	existsWithContent(t, filename, []byte("boo!bar"))
+}
+
+func TestLockShared(t *testing.T) {
+	if runtime.GOOS == "solaris" || runtime.GOOS == "aix" || runtime.GOOS == "illumos" {
+		t.Skip("file locking is not supported")
+	}
+
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestLockShared")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l1 := &Logger{Filename: filename, MaxBytes: 10, LockMode: LockShared}
+	defer l1.Close()
+
+	l2 := &Logger{Filename: filename, MaxBytes: 10, LockMode: LockShared}
+	defer l2.Close()
+
+	for _, w := range []struct {
+		l *Logger
+		s string
+	}{
+		{l1, "12345"},
+		{l2, "6789"},
+		// The file is full, counting the writes of both Loggers.
+		{l1, "abc"},
+		// The other Logger follows the rotation.
+		{l2, "de"},
+	} {
+		_, err := w.l.Write([]byte(w.s))
+		isNil(t, err)
+	}
+
+	existsWithContent(t, backupFile(dir), []byte("123456789"))
+	existsWithContent(t, filename, []byte("abcde"))
+
+	// The log file, the backup and the lock file.
+	fileCount(t, dir, 3)
+
+	_, err := New(filename, WithLockMode(LockShared), WithBuffer(64, 0))
+	equals(t, "BufferSize can't be used with LockShared", err.Error())
+}
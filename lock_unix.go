@@ -0,0 +1,37 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile locks f exclusively with flock(2), waiting for the lock if block is
+// set, and failing with errLocked otherwise.
+func lockFile(f *os.File, block bool) error {
+	how := syscall.LOCK_EX
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if errors.Is(err, syscall.EINTR) {
+			continue
+		}
+
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return errLocked
+		}
+
+		return err
+	}
+}
+
+// unlockFile releases the lock taken by lockFile.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
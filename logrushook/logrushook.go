@@ -0,0 +1,132 @@
+// Package logrushook provides a logrus hook writing entries to rotating log
+// files, chosen by the level of the entry, e.g. to keep errors apart from the
+// access log:
+//
+//	hook := logrushook.NewShared(lumberjack.Config{
+//		MaxBytes:   100 << 20,
+//		MaxBackups: 3,
+//		Compress:   true,
+//	}, map[string][]logrus.Level{
+//		"/var/log/myapp/error.log":  {logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel},
+//		"/var/log/myapp/access.log": {logrus.InfoLevel},
+//	}, nil)
+//	defer hook.Close()
+//
+//	log := logrus.New()
+//	log.SetOutput(io.Discard)
+//	log.AddHook(hook)
+package logrushook
+
+import (
+	"sort"
+
+	"github.com/saucelabs/lumberjack/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// Hook is a logrus.Hook writing entries to the Logger routed to their level.
+// Entries of other levels are ignored.
+type Hook struct {
+	formatter logrus.Formatter
+	loggers   map[logrus.Level]*lumberjack.Logger
+}
+
+// New returns a Hook writing entries to the Logger of their level in loggers.
+// A Logger may be used for several levels.  The entries are formatted with
+// formatter, or with the Formatter of the logrus.Logger if it is nil.
+func New(loggers map[logrus.Level]*lumberjack.Logger, formatter logrus.Formatter) *Hook {
+	h := &Hook{
+		formatter: formatter,
+		loggers:   make(map[logrus.Level]*lumberjack.Logger, len(loggers)),
+	}
+
+	for level, l := range loggers {
+		h.loggers[level] = l
+	}
+
+	return h
+}
+
+// NewShared returns a Hook writing to one Logger per filename in files, for
+// the levels given with it.  All Loggers use the rotation and retention
+// settings of cfg; its Filename is ignored.
+func NewShared(cfg lumberjack.Config, files map[string][]logrus.Level, formatter logrus.Formatter) *Hook {
+	loggers := make(map[logrus.Level]*lumberjack.Logger)
+
+	for filename, levels := range files {
+		cfg.Filename = filename
+		l := cfg.NewLogger()
+
+		for _, level := range levels {
+			loggers[level] = l
+		}
+	}
+
+	return New(loggers, formatter)
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	levels := make([]logrus.Level, 0, len(h.loggers))
+	for level := range h.loggers {
+		levels = append(levels, level)
+	}
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+
+	return levels
+}
+
+// Fire implements logrus.Hook.
+func (h *Hook) Fire(e *logrus.Entry) error {
+	l, ok := h.loggers[e.Level]
+	if !ok {
+		return nil
+	}
+
+	formatter := h.formatter
+	if formatter == nil {
+		formatter = e.Logger.Formatter
+	}
+
+	b, err := formatter.Format(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.Write(b)
+
+	return err
+}
+
+// Rotate rotates all Loggers of the Hook, returning the first error.
+func (h *Hook) Rotate() error {
+	return h.each((*lumberjack.Logger).Rotate)
+}
+
+// Close closes all Loggers of the Hook, returning the first error.
+func (h *Hook) Close() error {
+	return h.each((*lumberjack.Logger).Close)
+}
+
+// each calls fn once for every distinct Logger, returning the first error.
+func (h *Hook) each(fn func(*lumberjack.Logger) error) error {
+	var err error
+
+	seen := make(map[*lumberjack.Logger]bool, len(h.loggers))
+
+	for _, level := range h.Levels() {
+		l := h.loggers[level]
+		if seen[l] {
+			continue
+		}
+
+		seen[l] = true
+
+		if errFn := fn(l); err == nil {
+			err = errFn
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,106 @@
+package logrushook
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/saucelabs/lumberjack/v3"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHook(t *testing.T) {
+	dir := t.TempDir()
+	errorLog := filepath.Join(dir, "error.log")
+	accessLog := filepath.Join(dir, "access.log")
+
+	hook := NewShared(lumberjack.Config{
+		MaxBytes:   1 << 20,
+		MaxBackups: 1,
+	}, map[string][]logrus.Level{
+		errorLog:  {logrus.ErrorLevel, logrus.WarnLevel},
+		accessLog: {logrus.InfoLevel},
+	}, &logrus.TextFormatter{DisableTimestamp: true})
+	defer hook.Close()
+
+	want := []logrus.Level{logrus.ErrorLevel, logrus.WarnLevel, logrus.InfoLevel}
+	if got := hook.Levels(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got levels %v, want %v", got, want)
+	}
+
+	if hook.loggers[logrus.ErrorLevel] != hook.loggers[logrus.WarnLevel] {
+		t.Fatal("levels routed to the same file must share a Logger")
+	}
+
+	if hook.loggers[logrus.ErrorLevel].MaxBackups != 1 {
+		t.Fatal("Loggers must use the shared settings")
+	}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	log.AddHook(hook)
+
+	log.Info("GET /")
+	log.Warn("slow")
+	log.Error("boom")
+	log.Debug("ignored")
+
+	content(t, errorLog, "level=warning msg=slow\nlevel=error msg=boom\n")
+	content(t, accessLog, "level=info msg=\"GET /\"\n")
+
+	if err := hook.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	content(t, accessLog, "")
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 4 {
+		t.Fatalf("expected 4 files after rotating, got %d", len(files))
+	}
+}
+
+func TestHookLoggerFormatter(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "error.log")
+
+	l := &lumberjack.Logger{Filename: filename}
+	hook := New(map[logrus.Level]*lumberjack.Logger{logrus.ErrorLevel: l}, nil)
+	defer hook.Close()
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	log.SetFormatter(&logrus.JSONFormatter{DisableTimestamp: true})
+	log.AddHook(hook)
+
+	log.Error("boom")
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"msg":"boom"`) {
+		t.Fatalf("unexpected log file content %q", b)
+	}
+}
+
+func content(tb testing.TB, path, want string) {
+	tb.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	if string(b) != want {
+		tb.Fatalf("got %q in %s, want %q", b, path, want)
+	}
+}
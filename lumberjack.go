@@ -22,7 +22,8 @@
 package lumberjack
 
 import (
-	"compress/gzip"
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -31,6 +32,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -65,10 +67,15 @@ var _ io.WriteCloser = (*Logger)(nil)
 // Backups use the log file name given to Logger, in the form
 // `name-timestamp.ext` where name is the filename without the extension,
 // timestamp is the time at which the log was rotated formatted with the
-// time.Time format of `2006-01-02T15-04-05.000` and the extension is the
-// original extension.  For example, if your Logger.Filename is
+// time.Time format of `2006-01-02T15-04-05.000` (see TimestampPrecision) and
+// the extension is the original extension.  For example, if your Logger.Filename is
 // `/var/log/foo/server.log`, a backup created at 6:30pm on Nov 11 2016 would
-// use the filename `/var/log/foo/server-2016-11-04T18-30-00.000.log`
+// use the filename `/var/log/foo/server-2016-11-04T18-30-00.000.log`.  If a
+// backup of that name exists already, a number is appended to the timestamp,
+// as in `server-2016-11-04T18-30-00.000-1.log`, instead of overwriting it.
+// In local time, the timestamps of the hour repeated at the end of daylight
+// saving time and the one before carry the zone offset, as in
+// `server-2016-11-06T01-30-00.000-0400.log`.
 //
 // # Cleaning Up Old Log Files
 //
@@ -84,14 +91,84 @@ var _ io.WriteCloser = (*Logger)(nil)
 //nolint:maligned
 type Logger struct {
 	// Compress determines if the rotated log files should be compressed
-	// using gzip. The default is not to perform compression.
+	// using gzip, or the format selected by CompressionFormat. The default is
+	// not to perform compression.
 	Compress bool `json:"compress" yaml:"compress"`
 
+	// CompressionFormat is the format used to compress rotated log files when
+	// Compress is set.  Backups compressed in any supported format are
+	// recognized for cleanup, so the format can be changed at any time.  The
+	// default is CompressionGzip.
+	CompressionFormat CompressionFormat `json:"compressionformat" yaml:"compressionformat"`
+
+	// CompressConcurrency is the number of goroutines compressing a backup in
+	// parallel, so that compressing large backups takes less time on machines
+	// with several cores.  With gzip, the backup is compressed in blocks of
+	// 1 MB if it is greater than one; with zstd, it limits the concurrency,
+	// which is GOMAXPROCS by default.  The default for gzip is to compress
+	// single-threaded.
+	CompressConcurrency int `json:"compressconcurrency" yaml:"compressconcurrency"`
+
+	// CompressWorkers is the number of backups compressed at the same time
+	// when several of them are pending, for example after back-to-back
+	// rotations of large files.  Together with CompressConcurrency, it caps
+	// the CPU used for compression.  The default is 1.
+	CompressWorkers int `json:"compressworkers" yaml:"compressworkers"`
+
+	// CompressBufferSize is the size in bytes of the chunks in which backups
+	// are fed to the compressor.  The buffers, like the compressors, are
+	// pooled, so that rotating many files doesn't spike allocations.  The
+	// default is 32 KB.
+	CompressBufferSize int `json:"compressbuffersize" yaml:"compressbuffersize"`
+
+	// CompressActive determines if the active file itself is written as a
+	// gzip stream, named after Filename with a ".gz" suffix, for logs too
+	// large to be written uncompressed first.  Its backups keep the suffix and
+	// need no further compression.  MaxBytes is measured against the
+	// compressed size, which lags behind the writes by the data the
+	// compressor holds back until Sync or rotation.  It requires the gzip
+	// CompressionFormat and can't be used with EncryptKey or VerifyTailBytes.
+	// The default is to write the active file uncompressed.
+	CompressActive bool `json:"compressactive" yaml:"compressactive"`
+
+	// CompressAfter is the minimum age of a backup before it is compressed
+	// or encrypted, so that log shippers can finish reading the plain file
+	// before it is replaced.  A cleanup is scheduled for when the next backup
+	// reaches the age.  CompressOnLowDisk compresses backups regardless of
+	// their age.  The default is to compress backups right after the
+	// rotation.
+	CompressAfter time.Duration `json:"compressafter" yaml:"compressafter"`
+
+	// EncryptKey is a 32 byte key used to encrypt backups at rest with
+	// AES-256-GCM.  Backups are encrypted when they are finalized by the
+	// background cleanup, after compression if Compress is set, and get the
+	// ".enc" suffix, e.g. "server-2016-11-04T18-30-00.000.log.gz.enc".  Use
+	// NewDecryptReader to read them.  Backups finalized before EncryptKey was
+	// set are left as they are.  The default is not to encrypt backups.
+	EncryptKey []byte `json:"encryptkey" yaml:"encryptkey"`
+
 	// Filename is the file to write logs to.  Backup log files will be retained
-	// in the same directory.  It uses <processname>-lumberjack.log in
+	// in the same directory, unless BackupDir is set.  It uses <processname>-lumberjack.log in
 	// os.TempDir() if empty.
+	//
+	// So that instances sharing a volume write to their own files, Filename
+	// may contain the placeholders "{pid}" for the process ID, "{hostname}"
+	// for the host name, and "${NAME}" for the environment variable NAME,
+	// such as "/logs/app-${POD_NAME}.log".  "${HOSTNAME}" is the host name if
+	// the variable isn't set.  An unset variable is an invalid setting.
 	Filename string `json:"filename" yaml:"filename"`
 
+	// FilenameDateLayout is a time layout, such as "2006-01-02", which names
+	// the active log file after the current period: the formatted date is
+	// inserted before the extension of Filename, e.g. app-2024-06-01.log.
+	// When the formatted date changes, the next write starts the file of the
+	// new period and the previous file is left in place as a backup, without
+	// renaming it, which suits log shippers keying on filenames.  Rotations
+	// due to MaxBytes within a period still rename the file.  The dates are
+	// in Location.  It can't be used with BackupDir.  The default is to write
+	// to Filename.
+	FilenameDateLayout string `json:"filenamedatelayout" yaml:"filenamedatelayout"`
+
 	// MaxAge is the maximum number of days to retain old log files based on the
 	// timestamp encoded in their filename.  Note that a day is defined as 24
 	// hours and may not exactly correspond to calendar days due to daylight
@@ -104,6 +181,22 @@ type Logger struct {
 	// deleted.)
 	MaxBackups int `json:"maxbackups" yaml:"maxbackups"`
 
+	// MaxUncompressedBackups is the number of the most recent backups kept
+	// uncompressed, e.g. for grepping, when Compress is set; older ones are
+	// compressed.  This generalizes the delaycompress option of logrotate,
+	// which is MaxUncompressedBackups set to 1.  Without Compress, it is the
+	// maximum number of uncompressed backups to retain.  The default is to
+	// compress all backups if Compress is set, and to retain all of them
+	// otherwise.
+	MaxUncompressedBackups int `json:"maxuncompressedbackups" yaml:"maxuncompressedbackups"`
+
+	// MaxCompressedBackups is the maximum number of compressed backups to
+	// retain, counting the ones about to be compressed.  Together with
+	// MaxUncompressedBackups, it allows keeping a few recent plain-text
+	// backups and many compressed ones.  MaxBackups still applies to all of
+	// them.  The default is to retain all compressed backups.
+	MaxCompressedBackups int `json:"maxcompressedbackups" yaml:"maxcompressedbackups"`
+
 	// MaxBytes is the maximum size in bytes of the log file before it gets
 	// rotated. It defaults to 104857600 (100 megabytes).
 	MaxBytes int64 `json:"maxbytes" yaml:"maxbytes"`
@@ -113,17 +206,535 @@ type Logger struct {
 	// rotated. It defaults to 100 megabytes.
 	MaxSize int `json:"maxsize" yaml:"maxsize"`
 
+	// MaxTotalBytes is the maximum combined size in bytes of the active log
+	// file and all backups, compressed or not.  Whenever old log files are
+	// cleaned up, the oldest backups are deleted until everything fits, which
+	// caps disk usage even when backups compress unevenly.  If the active file
+	// alone exceeds the limit, all backups are deleted.  The default is not to
+	// limit the total size.
+	MaxTotalBytes int64 `json:"maxtotalbytes" yaml:"maxtotalbytes"`
+
+	// MinFreeBytes is the free space in bytes to keep on the filesystem
+	// holding the backups, so that the Logger doesn't fill the disk even when
+	// other files grow.  It is checked every DiskCheckInterval and whenever
+	// old log files are cleaned up, and if less space is free, the oldest
+	// backups are deleted until enough is.  The default is not to watch the
+	// free space.
+	MinFreeBytes int64 `json:"minfreebytes" yaml:"minfreebytes"`
+
+	// MinFreePercent is like MinFreeBytes, as a percentage of the size of the
+	// filesystem.  If both are set, both are kept free.  The default is not to
+	// watch the free space.
+	MinFreePercent int `json:"minfreepercent" yaml:"minfreepercent"`
+
+	// DiskCheckInterval is the interval at which the free space is checked
+	// for MinFreeBytes and MinFreePercent.  The default is one minute.
+	DiskCheckInterval time.Duration `json:"diskcheckinterval" yaml:"diskcheckinterval"`
+
+	// CompressOnLowDisk determines if uncompressed backups are compressed
+	// before any are deleted for MinFreeBytes or MinFreePercent, even if
+	// Compress is not set.  The default is to only delete backups.
+	CompressOnLowDisk bool `json:"compressonlowdisk" yaml:"compressonlowdisk"`
+
+	// JanitorInterval is the interval at which old log files are compressed
+	// and removed while the log file is open, so that MaxAge, MaxTotalBytes
+	// and CompressAfter are honored by a Logger which is rarely rotated.
+	// The default is to only clean up after rotations.
+	JanitorInterval time.Duration `json:"janitorinterval" yaml:"janitorinterval"`
+
 	// LocalTime determines if the time used for formatting the timestamps in
 	// backup files is the computer's local time.  The default is to use UTC
 	// time.
 	LocalTime bool `json:"localtime" yaml:"localtime"`
 
-	file *os.File
-	mu   sync.Mutex
-	size int64
-
-	millCh    chan bool
-	startMill sync.Once
+	// Location is the time zone used for formatting the timestamps in backup
+	// files and for RotateAt, e.g. the zone of an operations team regardless
+	// of the zone of the host.  It takes precedence over LocalTime.  The
+	// default is UTC, or the local time if LocalTime is set.
+	Location *time.Location `json:"-" yaml:"-"`
+
+	// RotationInterval is the maximum amount of time a log file is written to
+	// before it gets rotated, regardless of its size.  It is measured from the
+	// time the Logger created or opened the file.  Rotation happens on the next
+	// write once the interval has passed, or from a background timer if no
+	// write arrives; an empty log file is not rotated.  The default is not to
+	// rotate based on time.
+	RotationInterval time.Duration `json:"rotationinterval" yaml:"rotationinterval"`
+
+	// RotateAt is a time of day in the form "HH:MM" at which the log file gets
+	// rotated, e.g. "00:00" to start a new file at midnight and get one backup
+	// per day.  The time is interpreted in the zone selected by Location.  As
+	// with RotationInterval, a background timer rotates the file even if no
+	// write arrives, and an empty log file is not rotated.  If both are set,
+	// whichever comes first triggers the rotation.  The default is not to
+	// rotate at a time of day.
+	RotateAt string `json:"rotateat" yaml:"rotateat"`
+
+	// RotateOnStart determines if an existing non-empty log file is rotated
+	// when the Logger first opens it, so that every run of the program gets a
+	// log file of its own, e.g. to find the output leading up to a crash.
+	// Later reopens, such as by Reopen or after Close, append as usual.  The
+	// default is to append to the existing log file.
+	RotateOnStart bool `json:"rotateonstart" yaml:"rotateonstart"`
+
+	// OpenMode selects what happens to the contents of an existing log file
+	// when the Logger first opens it: OpenTruncate starts every run of the
+	// program with an empty log file, discarding the previous run's output
+	// unless a rotation made a backup of it.  Later reopens append.  The
+	// default is OpenAppend.
+	OpenMode OpenMode `json:"openmode" yaml:"openmode"`
+
+	// BackupDir is the directory rotated log files are moved to, e.g.
+	// "/var/log/app/archive", while the active file stays in the directory of
+	// Filename.  A relative path is relative to the directory of Filename.
+	// Old log files are only looked for in this directory when cleaning up.
+	// It must be on the same file system as Filename, as backups are moved
+	// there by renaming the log file.  The default is the directory of
+	// Filename.
+	BackupDir string `json:"backupdir" yaml:"backupdir"`
+
+	// NamingScheme selects how backup files are named.  The default is
+	// NamingTimestamp.
+	NamingScheme NamingScheme `json:"namingscheme" yaml:"namingscheme"`
+
+	// BackupNameTemplate is a text/template controlling how backup files are
+	// named, evaluated with a BackupNameData, e.g.
+	// "{{.Prefix}}.{{.Timestamp}}{{.Ext}}" or "{{.Prefix}}-{{.Seq}}{{.Ext}}".
+	// It must use Timestamp or Seq, each at most once and unmodified, so that
+	// the names can be recognized again when cleaning up old log files.  If
+	// the name has no timestamp, the backup's modification time is used
+	// instead for MaxAge.  It can only be used with NamingTimestamp.  The
+	// default is "{{.Prefix}}-{{.Timestamp}}{{.Ext}}".
+	BackupNameTemplate string `json:"backupnametemplate" yaml:"backupnametemplate"`
+
+	// BackupNameFunc names backups for conventions a BackupNameTemplate can't
+	// express, e.g. with the hostname or pod name embedded.  It is called with
+	// the base name of Filename and the rotation time in Location, and returns
+	// the base name of the backup, which must be recognized by
+	// ParseBackupName.  If the name is taken, a number is appended like
+	// "-1" before the extension of Filename.  It must be set together with
+	// ParseBackupName, and can only be used with NamingTimestamp.  The default
+	// is to name backups as described for Logger.
+	BackupNameFunc func(baseName string, t time.Time) string `json:"-" yaml:"-"`
+
+	// ParseBackupName recognizes the names generated by BackupNameFunc when
+	// cleaning up old log files, returning the rotation time encoded in
+	// name, which is used for MaxAge and to order the backups.  It is called
+	// with base names of files in the backup directory without the suffixes
+	// of compression and encryption, and must return false for files which
+	// are not backups of this Logger.
+	ParseBackupName func(name string) (time.Time, bool) `json:"-" yaml:"-"`
+
+	// TimestampPrecision selects the precision of the timestamps in backup
+	// names.  If two rotations happen within the same unit, the second backup
+	// gets a number appended like "-1", so a coarse precision may number the
+	// backups of bursts of rotations.  The default is TimestampMillisecond.
+	TimestampPrecision TimestampPrecision `json:"timestampprecision" yaml:"timestampprecision"`
+
+	// BootFilename is an optional second file which receives a copy of every
+	// write.  It is truncated the first time this Logger opens it and is never
+	// rotated, so it always holds the logs of the current process run while
+	// Filename keeps the rolling history.  The default is not to write a boot
+	// file.
+	BootFilename string `json:"bootfilename" yaml:"bootfilename"`
+
+	// Mirror receives a copy of every record written to the log file, such as
+	// os.Stderr to log to the console as well, without losing access to
+	// Rotate and Close as with an io.MultiWriter.  It is written to with the
+	// Logger locked, right after the log file.  An error writing to it doesn't
+	// fail the Write, but is reported like other background errors.  The
+	// default is not to mirror records.
+	Mirror io.Writer `json:"-" yaml:"-"`
+
+	// Fallback receives the records which can't be written to the log file,
+	// such as os.Stderr, so that they aren't lost while the disk is full or
+	// the file isn't writable.  Once opening, rotating or writing the log
+	// file fails, Write reports the error like other background errors and
+	// writes to Fallback instead, without trying the log file again until
+	// FallbackRetryInterval has passed.  Write only fails if Fallback fails.
+	// It is written to with the Logger locked, so writes from it to this
+	// Logger are dropped.  The default is to return the error from Write.
+	Fallback io.Writer `json:"-" yaml:"-"`
+
+	// FallbackRetryInterval is the time for which writes go to Fallback
+	// before the log file is tried again.  The default is 10 seconds.
+	FallbackRetryInterval time.Duration `json:"fallbackretryinterval" yaml:"fallbackretryinterval"`
+
+	// OnRecover is called once a record is written to the log file again
+	// after writes were diverted to Fallback, with the time since the first
+	// diverted write, so that the end of an outage can be reported.  Like
+	// OnRotate, it is called from a background goroutine.  The default is not
+	// to report recoveries.
+	OnRecover func(downtime time.Duration) `json:"-" yaml:"-"`
+
+	// WriteRetries is the number of times a write to the log file is retried
+	// if it fails with a transient error, such as EINTR, EAGAIN or ENOSPC,
+	// which are common on network filesystems, before the error is returned
+	// or the record goes to Fallback.  Only the part of the record which
+	// wasn't written is retried.  Write blocks while it waits.  Writes into
+	// the buffer of BufferSize are not retried.  The default is not to retry.
+	WriteRetries int `json:"writeretries" yaml:"writeretries"`
+
+	// WriteRetryDelay is the delay before the first retry of a write, which
+	// doubles with every further retry.  The default is 10ms.
+	WriteRetryDelay time.Duration `json:"writeretrydelay" yaml:"writeretrydelay"`
+
+	// VerifyTailBytes is the number of most recently written bytes of the
+	// active file which are kept in memory so that VerifyTail can compare them
+	// against what is on disk.  The default is not to keep a copy, which
+	// disables VerifyTail.
+	VerifyTailBytes int `json:"verifytailbytes" yaml:"verifytailbytes"`
+
+	// BufferSize is the size in bytes of a buffer which coalesces small writes
+	// before they reach the active file, saving a system call per write.
+	// Buffered data is written out after FlushInterval, when the buffer is
+	// full, and on Sync, Close and rotation, so it may be lost if the process
+	// crashes.  The default is not to buffer writes.
+	BufferSize int `json:"buffersize" yaml:"buffersize"`
+
+	// FlushInterval is the maximum amount of time data is held in the buffer
+	// enabled by BufferSize.  The default is one second.
+	FlushInterval time.Duration `json:"flushinterval" yaml:"flushinterval"`
+
+	// AsyncBufferSize is the size in bytes of a queue which makes Write
+	// never block on I/O, for services which must not stall on a slow disk.
+	// Write copies the record into the queue and returns, while a background
+	// goroutine writes it out; if the queue is full, the record is dropped
+	// and counted in Stats.  Errors are reported like other background
+	// errors.  Queued records are written out before Sync, Close and Rotate,
+	// but are lost if the process crashes.  The default is to write records
+	// before Write returns.
+	AsyncBufferSize int `json:"asyncbuffersize" yaml:"asyncbuffersize"`
+
+	// PreserveOwner determines if a new log file and compressed backups get
+	// the owner and group of the file they replace, which requires the
+	// CAP_CHOWN capability unless they are the process' own.  It only has an
+	// effect on Linux.  Set it to false in containers lacking the capability,
+	// to avoid failing rotations.  The default is true.
+	PreserveOwner *bool `json:"preserveowner" yaml:"preserveowner"`
+
+	// SyncInterval is the interval at which the active file is committed to
+	// stable storage if it was written to, bounding how much data a crash of
+	// the machine can lose at the cost of throughput.  The default is to leave
+	// this to the operating system, or to explicit calls to Sync.
+	SyncInterval time.Duration `json:"syncinterval" yaml:"syncinterval"`
+
+	// SyncEveryWrite determines if every record is committed to stable
+	// storage before Write returns, for audit logs which must not lose an
+	// acknowledged record even if the machine crashes.  It costs a system
+	// call and a disk flush per write, so BufferSize has no effect with it,
+	// and it can't be used with AsyncBufferSize.  A failed sync is returned
+	// by Write although the record was written.  The default is not to sync
+	// writes, see SyncInterval.
+	SyncEveryWrite bool `json:"synceverywrite" yaml:"synceverywrite"`
+
+	// Preallocate determines if disk space for MaxBytes is reserved whenever
+	// a log file is opened, reducing fragmentation and reporting a full disk
+	// through OnError early, while the space reserved but not written to is
+	// released on rotation.  It only has an effect on Linux filesystems
+	// supporting fallocate(2).  The default is false.
+	Preallocate bool `json:"preallocate" yaml:"preallocate"`
+
+	// LargeWritePolicy selects how Write handles a record larger than
+	// MaxBytes.  The default is LargeWriteError.
+	LargeWritePolicy LargeWritePolicy `json:"largewritepolicy" yaml:"largewritepolicy"`
+
+	// MaxRecordBytes is the maximum length of a record passed to Write, so
+	// that a single runaway record, such as a dump of a huge request, can't
+	// break line-based parsers downstream or take up a log file of its own.
+	// It applies after Rewrite and AppendNewline.  A longer record is handled
+	// according to MaxRecordPolicy.  The default is not to limit records,
+	// except by LargeWritePolicy.
+	MaxRecordBytes int `json:"maxrecordbytes" yaml:"maxrecordbytes"`
+
+	// MaxRecordPolicy selects how Write handles a record longer than
+	// MaxRecordBytes.  The default is MaxRecordTruncate.
+	MaxRecordPolicy MaxRecordPolicy `json:"maxrecordpolicy" yaml:"maxrecordpolicy"`
+
+	// AppendNewline determines if a newline is appended to every record
+	// passed to Write which doesn't end with one, so that consumers of JSON
+	// lines never see two records on the same line.  The newline counts
+	// towards MaxBytes, but not towards the length returned by Write.  The
+	// default is to write records as they are.
+	AppendNewline bool `json:"appendnewline" yaml:"appendnewline"`
+
+	// StripANSI determines if ANSI escape sequences, such as the colors of
+	// console output, are removed from the records passed to Write, so that
+	// the log files stay clean for grep and log ingestion.  Records are
+	// stripped before Filter and Rewrite see them.  Data passed to ReadFrom
+	// is written as it is.  The default is to write records as they are.
+	StripANSI bool `json:"stripansi" yaml:"stripansi"`
+
+	// Rewrite returns the record to write in place of a record passed to
+	// Write, e.g. with tokens and e-mail addresses masked, so that secrets
+	// are scrubbed at the sink whichever call site logged them.  It is
+	// called concurrently, before AppendNewline applies, and must not
+	// modify its argument.  A record it returns empty is dropped.  Data
+	// passed to ReadFrom is written as it is.  The default is to write
+	// records as they are.
+	Rewrite func(record []byte) []byte `json:"-" yaml:"-"`
+
+	// Filter reports whether to write a record passed to Write, so that
+	// noisy records, such as the access logs of health checks, can be
+	// dropped before they take up disk space.  Dropped records are counted
+	// in Stats, and Write returns no error for them.  It is called
+	// concurrently, before Rewrite, and must not modify its argument.  Data
+	// passed to ReadFrom isn't filtered.  The default is to write all
+	// records.
+	Filter func(record []byte) bool `json:"-" yaml:"-"`
+
+	// MaxBytesPerSecond limits the rate at which records are written, to
+	// protect the disk from runaway logging.  Bursts of up to one second's
+	// worth of bytes are written without delay.  The default is no limit.
+	MaxBytesPerSecond int64 `json:"maxbytespersecond" yaml:"maxbytespersecond"`
+
+	// RateLimitPolicy selects what happens to records exceeding
+	// MaxBytesPerSecond.  The default is RateLimitBlock.
+	RateLimitPolicy RateLimitPolicy `json:"ratelimitpolicy" yaml:"ratelimitpolicy"`
+
+	// LockMode selects how the Logger coordinates with other processes using
+	// the same Filename, using flock(2) or LockFileEx on a file named after
+	// the log file with ".lock" appended.  The default is not to coordinate,
+	// so only one process may write to a log file.
+	LockMode LockMode `json:"lockmode" yaml:"lockmode"`
+
+	// SymlinkName is the path of a symlink kept pointing at the active log
+	// file, e.g. "current", for tail tools and humans to follow.  It is
+	// relative to the directory of Filename unless it is absolute, and is
+	// replaced atomically whenever a log file is opened.  The default is not
+	// to maintain a symlink.
+	SymlinkName string `json:"symlinkname" yaml:"symlinkname"`
+
+	// FollowName determines if every write first checks whether the log file
+	// was removed or renamed, e.g. by an operator or by logrotate, and if so
+	// opens a new log file under Filename instead of writing to the orphaned
+	// one.  The check costs a stat call per write.  The default is false.
+	FollowName bool `json:"followname" yaml:"followname"`
+
+	// PrevSymlink determines if a symlink named after the log file with
+	// ".prev" appended, e.g. "foo.log.prev", is kept pointing at the newest
+	// backup, so the file from before the last rotation can be opened without
+	// looking for its timestamp.  It is updated in the background after every
+	// rotation, once the backup is compressed.  The default is false.
+	PrevSymlink bool `json:"prevsymlink" yaml:"prevsymlink"`
+
+	// Header returns data, such as a banner or CSV column names, to write at
+	// the start of every new log file, both the first one and those created
+	// by rotation, but not to an existing file which is appended to.  It is
+	// called with the Logger locked, so it must not call back into the
+	// Logger.  The header counts towards MaxBytes.  The default is not to
+	// write a header.
+	Header func() []byte `json:"-" yaml:"-"`
+
+	// Footer returns data, such as a summary or a pointer to the next file,
+	// to write at the end of a log file just before it is rotated, but not
+	// when the Logger is closed.  Like Header, it must not call back into the
+	// Logger.  The footer may make the file larger than MaxBytes, and an
+	// error writing it is reported like other background errors.  The
+	// default is not to write a footer.
+	Footer func() []byte `json:"-" yaml:"-"`
+
+	// Transformers wrap the active file whenever it is opened, so that
+	// records can be framed, checksummed or encrypted on their way to disk.
+	// The first transformer receives the records and writes into the
+	// second, and the last one writes into the file.  On Sync the writers
+	// with a Flush() error method are flushed, and on Close and rotation the
+	// io.Closers are closed, the first one first; closing a writer must not
+	// close the writer it wraps.  MaxBytes is measured against the records
+	// written, not the transformed data, and OpenCombined and Follow read
+	// the transformed data.  They and their writers are called with the
+	// Logger locked, so writes from them to this Logger are dropped.  They
+	// can't be used with CompressActive or VerifyTailBytes.  The default is
+	// to write records as they are.
+	Transformers []func(io.Writer) io.Writer `json:"-" yaml:"-"`
+
+	// OnRotate is called after the log file at oldPath was moved to the backup
+	// at newPath for the given reason, before the backup is compressed.  It
+	// is called from a background goroutine, one rotation at a time, so it
+	// may take its time and call back into the Logger, but must not assume
+	// that newPath still exists by then.  The default is not to report
+	// rotations.
+	OnRotate func(oldPath, newPath string, reason RotationReason) `json:"-" yaml:"-"`
+
+	// PostRotateCommand is a program and its arguments, run like logrotate's
+	// postrotate script after every rotation with the path of the new backup
+	// appended as the last argument.  It runs after OnRotate, from the same
+	// background goroutine, so the backup has not been compressed yet.  If the
+	// command fails or exceeds PostRotateTimeout, an error including its
+	// standard error is reported like other background errors.  The default
+	// is not to run a command.
+	PostRotateCommand []string `json:"postrotatecommand" yaml:"postrotatecommand"`
+
+	// PostRotateTimeout is the maximum amount of time PostRotateCommand may
+	// run before it is killed.  The default is one minute.
+	PostRotateTimeout time.Duration `json:"postrotatetimeout" yaml:"postrotatetimeout"`
+
+	// OnError is called with errors of operations which run in the
+	// background: the first error of each run compressing and removing old
+	// log files, and errors rotating or flushing the log file from a timer.
+	// Like OnRotate, it is called from a background goroutine, one error at a
+	// time.  The default is to ignore these errors.
+	OnError func(err error) `json:"-" yaml:"-"`
+
+	// Instrumentation is notified of every rotation of the log file and
+	// compression of a backup, with their errors, to trace them.  The default
+	// is not to trace them.
+	Instrumentation Instrumentation `json:"-" yaml:"-"`
+
+	// Diag records the rotations of the log file and the compressions and
+	// removals of backups at debug level, with their errors, to diagnose
+	// what the Logger does in the background, e.g. a *slog.Logger.  It is
+	// called concurrently and with the Logger locked; writes from it to
+	// this Logger are dropped, see Stats.RecursiveWrites.  The default is to
+	// record nothing.
+	Diag DiagLogger `json:"-" yaml:"-"`
+
+	// Archiver uploads every backup made by this Logger to long-term storage
+	// once it is finalized, that is after compression and encryption if they
+	// are enabled.  Uploads run in the background, one at a time, before old
+	// log files are removed, so that MaxBackups can keep only a few backups
+	// locally.  A failed upload is reported like other background errors and
+	// handled according to ArchiveErrorPolicy.  The default is not to archive
+	// backups.
+	Archiver Archiver `json:"-" yaml:"-"`
+
+	// Archive is called with the path of every finalized backup, like the
+	// Archive method of Archiver, for shipping logic which needs no context.
+	// It can't be used together with Archiver.
+	Archive func(path string) error `json:"-" yaml:"-"`
+
+	// ArchiveErrorPolicy selects what happens to a backup whose upload by
+	// Archiver or Archive failed.  The default is ArchiveRetry.
+	ArchiveErrorPolicy ArchiveErrorPolicy `json:"archiveerrorpolicy" yaml:"archiveerrorpolicy"`
+
+	// Manager runs the cleanup of old log files of this Logger, compression
+	// included, on the goroutines it shares with other Loggers, and shuts the
+	// Logger down with them.  The default is to run the cleanup on a
+	// goroutine of the Logger's own.
+	Manager *Manager `json:"-" yaml:"-"`
+
+	// FS is the file system holding the log file and backups, e.g. an
+	// in-memory file system in tests.  LockMode, SymlinkName, PrevSymlink and
+	// FollowName can't be used with it, and Preallocate has no effect unless
+	// it opens an *os.File.  PostRotateCommand and Archiver are given paths
+	// on FS.  The default is the file system of the operating system.
+	FS FS `json:"-" yaml:"-"`
+
+	// Clock returns the current time, which dates backups and decides time
+	// based rotation, compression and retention, e.g. a fake clock in tests,
+	// see package lumberjacktest.  The Logger checks whether a rotation is
+	// due on every write, but its timers still fire on the real clock.  The
+	// default is time.Now.
+	Clock func() time.Time `json:"-" yaml:"-"`
+
+	// mu guards the Logger.  Writes which don't need exclusive access only
+	// read-lock it, see writeFast, and update size atomically.
+	file     File
+	mu       sync.RWMutex
+	size     int64
+	reserved int64
+
+	// needsSlowPath is set if the settings keep writes off the path of
+	// writeFast, see updateSlowPath.
+	needsSlowPath bool
+
+	// headerLen is the length of the Header at the start of the active
+	// file, which alone doesn't make the file worth rotating.
+	headerLen int64
+
+	bootFile   File
+	bootOpened bool
+
+	lockFile *os.File
+
+	shadow tailBuffer
+
+	buf        *bufio.Writer
+	flushTimer *time.Timer
+	syncTimer  *time.Timer
+	diskTimer  *time.Timer
+	janitor    *time.Timer
+	unsynced   atomic.Bool
+
+	// syncGen counts the sync timers started and stopped, so that a timer
+	// which fires after it was stopped doesn't rearm itself.  It is guarded
+	// by mu.
+	syncGen uint64
+
+	// generation counts the active files started afresh, by a rotation or
+	// by truncating, so that Follow notices when to move on.  It changes
+	// with mu held.
+	generation atomic.Uint64
+
+	// fallbackUntil is the time until which writes go to Fallback, and
+	// fallbackSince the time of the first write diverted since the log file
+	// was last written.
+	fallbackUntil time.Time
+	fallbackSince time.Time
+
+	// started is set once the Logger opened a log file, see RotateOnStart.
+	started bool
+
+	// date is the period of the active file, see FilenameDateLayout.  It is
+	// set with both mu and hooksMu held, so that either allows reading it.
+	date string
+
+	limiter rateLimiter
+
+	async asyncQueue
+
+	nextRotation time.Time
+	rotateTimer  *time.Timer
+
+	// millCh requests runs of the mill goroutine, which closes millStopped
+	// when it exits.  Both are guarded by mu.
+	millCh      chan bool
+	millStopped chan struct{}
+
+	// hooks tracks the goroutines running user hooks with the Logger
+	// locked, see reentered.
+	hooks hookCallers
+
+	hooksMu    sync.Mutex
+	rotations  []rotation
+	archives   []string
+	recoveries []time.Duration
+
+	// compressTimer runs the mill once a backup reached CompressAfter.  It
+	// is guarded by hooksMu.
+	compressTimer *time.Timer
+	errs          []error
+	errCh         chan error
+	droppedErrs   int64
+
+	// millQueued counts the runs of the mill requested so far, and millDone
+	// the ones covered by finished runs.  millIdle is closed whenever
+	// millDone advances, millCtx is canceled to abort the mill, and
+	// millStarted is the start of the current run, if any.  They are guarded
+	// by hooksMu.
+	millQueued  uint64
+	millDone    uint64
+	millIdle    chan struct{}
+	millCtx     context.Context
+	millCancel  context.CancelFunc
+	millStarted time.Time
+
+	// millMu serializes the mill with renaming backups when they are
+	// shifted.  It must not be held while acquiring mu.
+	millMu sync.Mutex
+
+	statsMu     sync.Mutex
+	stats       Stats
+	compression CompressionStats
+
+	// lastRecursive is the time of the last write dropped because it came
+	// from within a hook, for Healthy.  It is guarded by statsMu.
+	lastRecursive time.Time
+
+	// writes and writtenBytes count the writes for Stats atomically, so that
+	// concurrent writers don't serialize on statsMu.
+	writes       atomic.Int64
+	writtenBytes atomic.Int64
 }
 
 var (
@@ -139,45 +750,250 @@ var (
 	megabyte = 1024 * 1024
 )
 
+// now returns the current time of Clock.
+func (l *Logger) now() time.Time {
+	if l.Clock != nil {
+		return l.Clock()
+	}
+
+	return currentTime()
+}
+
 // Write implements io.Writer.  If a write would cause the log file to be larger
 // than MaxBytes, the file is closed, renamed to include a timestamp of the
 // current time, and a new log file is created using the original log file name.
-// If the length of the write is greater than MaxBytes, an error is returned.
+// If the length of the write is greater than MaxBytes, it is handled according
+// to LargeWritePolicy.  If MaxBytesPerSecond is set, a record exceeding the
+// rate is delayed or dropped according to RateLimitPolicy.
+//
+// Write is safe for concurrent use.  Each call is written as a whole to a
+// single log file, so a record passed in one Write is never split by a
+// rotation or interleaved with another record, unless it is split because of
+// LargeWriteSplit.
+//
+// A Write from within a hook which is called with the Logger locked, such as
+// Header, Footer, Mirror, Fallback, Diag, Instrumentation or Transformers, is
+// dropped with an error instead of deadlocking.
 func (l *Logger) Write(p []byte) (n int, err error) {
+	if l.reentered(len(p)) {
+		return 0, errRecursiveWrite
+	}
+
+	write := l.writeRecord
+	if l.AsyncBufferSize > 0 {
+		write = l.writeAsync
+	}
+
+	record, err := l.prepare(p)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(record) == 0 && len(p) > 0 {
+		return len(p), nil
+	}
+
+	n, err = write(record)
+
+	// n counts the bytes of p, whatever was written in their place.
+	if n > len(p) || (err == nil && n != len(p)) {
+		n = len(p)
+	}
+
+	return n, err
+}
+
+// writeRecord writes the record p as described by Write.
+func (l *Logger) writeRecord(p []byte) (n int, err error) {
+	if !l.limitRate(len(p)) {
+		return len(p), nil
+	}
+
+	if n, ok, err := l.writeFast(p); ok {
+		return n, err
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.LargeWritePolicy == LargeWriteSplit && int64(len(p)) > l.max() {
+		return l.writeSplit(p)
+	}
+
+	return l.write(p)
+}
+
+// write writes p to the log file, rotating it first if necessary.  It must be
+// called with l.mu held.
+func (l *Logger) write(p []byte) (n int, err error) {
 	writeLen := int64(len(p))
-	if writeLen > l.max() {
+	if writeLen > l.max() && l.LargeWritePolicy != LargeWriteAllow {
 		return 0, fmt.Errorf(
 			"write length %d exceeds maximum file size %d", writeLen, l.max(),
 		)
 	}
 
-	if l.file == nil {
-		if err = l.openExistingOrNew(len(p)); err != nil {
+	if l.fallingBack() {
+		return l.writeFallback(p)
+	}
+
+	if err := l.lock(); err != nil {
+		return 0, err
+	}
+	defer l.unlock()
+
+	if l.file != nil && l.FollowName && l.fileMoved() {
+		if err := l.reopen(); err != nil {
 			return 0, err
 		}
 	}
 
-	if l.size+writeLen > l.max() {
-		if err := l.rotate(); err != nil {
+	// The file of a period which has ended is left in place.
+	if l.file != nil && l.dateChanged() {
+		l.queueError(l.writeFooter())
+
+		if err := l.close(); err != nil {
 			return 0, err
 		}
 	}
 
-	n, err = l.file.Write(p)
-	l.size += int64(n)
+	if l.file == nil {
+		if err = l.openExistingOrNew(len(p)); err != nil {
+			return l.divert(p, err)
+		}
+	}
+
+	// An empty file, or one holding only its Header, is not rotated for a
+	// record larger than MaxBytes, nor because its time is up.  If both
+	// limits are reached, the rotation is attributed to the size.
+	reason := RotationReason("")
+
+	switch {
+	case l.size+writeLen > l.max() && l.size > l.headerLen:
+		reason = RotationSize
+	case l.rotationDue() && l.size > l.headerLen:
+		reason = RotationTime
+	case l.rotationDue():
+		// The settings were validated when the file was opened.
+		next, _ := l.nextRotationTime()
+		l.scheduleRotation(next)
+	}
 
-	return n, err
+	if reason != "" {
+		if err := l.rotate(reason); err != nil {
+			return l.divert(p, err)
+		}
+	}
+
+	n, err = l.writeRetrying(p)
+	l.size += l.grown(n)
+
+	if n > 0 {
+		l.unsynced.Store(true)
+		l.recordWrite(n)
+	}
+
+	l.shadow.write(p[:n], l.VerifyTailBytes)
+	l.writeMirror(p[:n])
+
+	if err != nil {
+		m, err := l.divert(p[n:], err)
+
+		return n + m, err
+	}
+
+	l.recovered()
+
+	if err := l.syncWrite(); err != nil {
+		return n, err
+	}
+
+	return n, l.writeBoot(p)
 }
 
-// Close implements io.Closer, and closes the current logfile.
+// Close implements io.Closer, and closes the current logfile and the boot file,
+// if any.
 func (l *Logger) Close() error {
+	l.drainAsync()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.close()
+
+	if errBoot := l.closeBoot(); err == nil {
+		err = errBoot
+	}
+
+	if errLock := l.closeLock(); err == nil {
+		err = errLock
+	}
+
+	l.unmanage()
+
+	return err
+}
+
+// Sync writes any buffered data to the active log file and commits it and the
+// boot file, if any, to stable storage, so that everything written so far
+// survives a crash of the machine.  Loggers such as zap call it on shutdown.
+func (l *Logger) Sync() error {
+	l.drainAsync()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	return l.close()
+	var err error
+
+	if l.file != nil {
+		err = l.flush()
+
+		if err == nil {
+			err = l.file.Sync()
+		}
+
+		l.unsynced.Store(false)
+	}
+
+	if l.bootFile != nil {
+		if errBoot := l.bootFile.Sync(); err == nil {
+			err = errBoot
+		}
+	}
+
+	return err
+}
+
+// Size returns the size of the active log file, which MaxBytes is compared
+// against: the data written to it so far, including data still held in the
+// buffer of BufferSize, and with CompressActive its compressed size.  If the
+// file isn't open, it is the size of the file the next write appends to, or
+// 0 if there is none.
+func (l *Logger) Size() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.file != nil {
+		return atomic.LoadInt64(&l.size)
+	}
+
+	info, err := l.fs().Stat(l.activeName())
+	if err != nil {
+		return 0
+	}
+
+	return info.Size()
+}
+
+// CurrentPath returns the path of the active log file, or of the file the
+// next write opens if none is open: Filename with its placeholders expanded,
+// or with FilenameDateLayout the file of the current period, and with
+// CompressActive the ".gz" suffix.
+func (l *Logger) CurrentPath() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.activeName()
 }
 
 // close closes the file if it is open.
@@ -186,9 +1002,111 @@ func (l *Logger) close() error {
 		return nil
 	}
 
+	l.stopRotationTimer()
+	l.stopSyncTimer()
+	l.stopDiskTimer()
+	l.stopJanitor()
+
+	errFlush := l.flush()
+
+	if f, ok := osFile(l.file); ok && l.reserved > 0 {
+		// The reserved space is released so that backups don't keep it.
+		if errRelease := releasePreallocated(f, l.reserved); errFlush == nil {
+			errFlush = errRelease
+		}
+
+		l.reserved = 0
+	}
+
 	err := l.file.Close()
 
 	l.file = nil
+	l.buf = nil
+
+	if err == nil {
+		err = errFlush
+	}
+
+	return err
+}
+
+// reserveSpace preallocates MaxBytes on disk for the active file if Preallocate
+// is set.  A failure, such as running out of disk space, doesn't prevent
+// logging, so it is reported like other background errors.  It must be called
+// with l.mu held.
+func (l *Logger) reserveSpace() {
+	f, ok := osFile(l.file)
+	if !l.Preallocate || !ok {
+		return
+	}
+
+	if err := preallocate(f, l.max()); err != nil {
+		l.queueError(fmt.Errorf("can't preallocate log file: %s", err))
+		l.mill()
+
+		return
+	}
+
+	l.reserved = l.max()
+}
+
+// writeBoot copies p into the boot file, opening it if necessary.  The boot file
+// is truncated on the first open only, so reopening it after Close appends.
+func (l *Logger) writeBoot(p []byte) error {
+	if l.BootFilename == "" {
+		return nil
+	}
+
+	if l.bootFile == nil {
+		if err := l.fs().MkdirAll(filepath.Dir(l.BootFilename), dirMode); err != nil {
+			return fmt.Errorf("can't make directories for boot file: %s", err)
+		}
+
+		flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if !l.bootOpened {
+			flag |= os.O_TRUNC
+		}
+
+		f, err := l.fs().OpenFile(l.BootFilename, flag, fileModeNew)
+		if err != nil {
+			return fmt.Errorf("can't open boot file: %s", err)
+		}
+
+		l.bootFile = f
+		l.bootOpened = true
+	}
+
+	_, err := l.bootFile.Write(p)
+
+	return err
+}
+
+// writeMirror copies p to Mirror.  A failure doesn't fail the write, so it is
+// reported like other background errors.  It must be called with l.mu held.
+func (l *Logger) writeMirror(p []byte) {
+	if l.Mirror == nil || len(p) == 0 {
+		return
+	}
+
+	id := l.hooks.enter()
+	_, err := l.Mirror.Write(p)
+	l.hooks.exit(id)
+
+	if err != nil {
+		l.queueError(fmt.Errorf("can't write to Mirror: %s", err))
+		l.mill()
+	}
+}
+
+// closeBoot closes the boot file if it is open.
+func (l *Logger) closeBoot() error {
+	if l.bootFile == nil {
+		return nil
+	}
+
+	err := l.bootFile.Close()
+
+	l.bootFile = nil
 
 	return err
 }
@@ -197,23 +1115,44 @@ func (l *Logger) close() error {
 // new one.  This is a helper function for applications that want to initiate
 // rotations outside of the normal rotation rules, such as in response to
 // SIGHUP.  After rotating, this initiates compression and removal of old log
-// files according to the configuration.
+// files according to the configuration.  The boot file, if any, is not rotated.
 func (l *Logger) Rotate() error {
+	l.drainAsync()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	return l.rotate()
+	if err := l.lock(); err != nil {
+		return err
+	}
+	defer l.unlock()
+
+	return l.rotate(RotationManual)
 }
 
-// rotate closes the current file, moves it aside with a timestamp in the name,
-// (if it exists), opens a new file with the original filename, and then runs
-// post-rotation processing and removal.
-func (l *Logger) rotate() error {
-	if err := l.close(); err != nil {
-		return err
+// rotate writes the footer to the current file and closes it, moves it aside
+// with a timestamp in the name (if it exists), opens a new file with the
+// original filename, and then runs post-rotation processing and removal.
+// reason is reported to OnRotate and Stats.
+func (l *Logger) rotate(reason RotationReason) error {
+	end := l.instrument(context.Background(), OperationRotate, l.activeName())
+
+	// A failure to write the footer doesn't prevent the rotation.
+	l.queueError(l.writeFooter())
+
+	err := l.close()
+
+	if err == nil {
+		err = l.openNew(reason)
 	}
 
-	if err := l.openNew(); err != nil {
+	// The rotation ends before the mill may compress the backup.
+	end(err)
+
+	if err != nil {
+		l.recordRotation(reason, err)
+		l.diag("lumberjack: rotation failed", "filename", l.activeName(), "reason", reason, "error", err)
+
 		return err
 	}
 
@@ -223,100 +1162,226 @@ func (l *Logger) rotate() error {
 }
 
 // openNew opens a new log file for writing, moving any old log file out of the
-// way. This methods assumes the file has already been closed.
-func (l *Logger) openNew() error {
-	err := os.MkdirAll(l.dir(), dirMode)
+// way for the given reason. This methods assumes the file has already been
+// closed.
+func (l *Logger) openNew(reason RotationReason) error {
+	if err := l.checkSettings(); err != nil {
+		return err
+	}
+
+	l.rollDate()
+
+	next, err := l.nextRotationTime()
+	if err != nil {
+		return err
+	}
+
+	err = l.fs().MkdirAll(l.dir(), dirMode)
 	if err != nil {
 		return fmt.Errorf("can't make directories for new logfile: %s", err)
 	}
 
-	name := l.filename()
+	err = l.fs().MkdirAll(l.backupDir(), dirMode)
+	if err != nil {
+		return fmt.Errorf("can't make directories for backups: %s", err)
+	}
+
+	name := l.activeName()
 
 	mode := os.FileMode(fileModeNew)
 
-	info, err := osStat(name)
+	info, err := l.fs().Stat(name)
 	if err == nil {
 		// Copy the mode off the old logfile.
 		mode = info.Mode()
 
-		// Move the existing file.
-		newname := backupName(name, l.LocalTime)
-		if err := os.Rename(name, newname); err != nil {
+		// Move the existing file, making room for it first if backups are
+		// numbered.
+		if err := l.shiftBackups(); err != nil {
+			return err
+		}
+
+		newname, err := l.backupName()
+		if err != nil {
+			return err
+		}
+
+		if err := l.renameBackup(name, newname+l.activeSuffix(), reason); err != nil {
 			return fmt.Errorf("can't rename log file: %s", err)
 		}
 
 		// This is a no-op anywhere but linux.
-		if err := chown(name, info); err != nil {
-			return err
+		if l.preserveOwner() {
+			if err := chown(l.fs(), name, info); err != nil {
+				return err
+			}
 		}
+
+		l.recordRotation(reason, nil)
+		l.diag("lumberjack: rotated log file", "filename", name, "reason", reason, "backup", newname+l.activeSuffix())
+		l.queueArchive(newname)
 	}
 
 	// we use truncate here because this should only get called when we've moved
 	// the file ourselves. if someone else creates the file in the meantime,
 	// just wipe out the contents.
-	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	f, err := l.fs().OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
 	if err != nil {
 		return fmt.Errorf("can't open new logfile: %s", err)
 	}
 
-	l.file = f
+	l.file = l.openedFile(f)
+	l.started = true
+	l.generation.Add(1)
+
+	l.startBuffer()
+	l.updateSlowPath()
+	l.startSyncTimer()
+	l.startDiskTimer()
+	l.startJanitor()
+	l.manage()
+	l.reserveSpace()
+	l.linkActive()
 
 	l.size = 0
+	l.headerLen = 0
 
-	return nil
-}
+	l.shadow.reset()
 
-// backupName creates a new filename from the given name, inserting a timestamp
-// between the filename and the extension, using the local time if requested
-// (otherwise UTC).
-func backupName(name string, local bool) string {
-	dir := filepath.Dir(name)
-	filename := filepath.Base(name)
-	ext := filepath.Ext(filename)
-	prefix := filename[:len(filename)-len(ext)]
+	l.scheduleRotation(next)
 
-	t := currentTime()
+	return l.writeHeader()
+}
 
-	if !local {
-		t = t.UTC()
+// checkSettings reports settings which would prevent the Logger from opening
+// or rotating the log file, so that they surface on Write.
+func (l *Logger) checkSettings() error {
+	for _, c := range l.settingChecks() {
+		if err := c.check(); err != nil {
+			return err
+		}
 	}
 
-	timestamp := t.Format(backupTimeFormat)
+	return nil
+}
 
-	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, timestamp, ext))
+// settingCheck is a check of checkSettings, with the setting it is mainly
+// about.
+type settingCheck struct {
+	setting string
+	check   func() error
+}
+
+// settingChecks returns the checks of checkSettings, in order.
+func (l *Logger) settingChecks() []settingCheck {
+	return []settingCheck{
+		{"Filename", l.checkFilename},
+		{"CompressionFormat", l.checkCompression},
+		{"EncryptKey", l.checkEncryption},
+		{"ArchiveErrorPolicy", l.checkArchive},
+		{"LockMode", l.checkLock},
+		{"LargeWritePolicy", l.checkLargeWritePolicy},
+		{"MaxRecordPolicy", l.checkMaxRecordPolicy},
+		{"RateLimitPolicy", l.checkRateLimitPolicy},
+		{"FS", l.checkFS},
+		{"FilenameDateLayout", l.checkDatedFilename},
+		{"CompressActive", l.checkCompressActive},
+		{"OpenMode", l.checkOpenMode},
+		{"MinFreePercent", l.checkFreeSpace},
+		{"SyncEveryWrite", l.checkSyncEveryWrite},
+		{"Transformers", l.checkTransformers},
+		{"BackupNameTemplate", func() error {
+			_, err := l.namer()
+
+			return err
+		}},
+	}
 }
 
 // openExistingOrNew opens the logfile if it exists and if the current write
 // would not put it over MaxBytes.  If there is no such file or the write would
 // put it over the MaxBytes, a new file is created.
 func (l *Logger) openExistingOrNew(writeLen int) error {
+	if err := l.checkSettings(); err != nil {
+		return err
+	}
+
+	// The file of the previous period was rotated by moving on to the next.
+	if prev, rolled := l.rollDate(); rolled {
+		l.instrument(context.Background(), OperationRotate, prev)(nil)
+	}
+
 	l.mill()
 
-	filename := l.filename()
+	filename := l.activeName()
 
-	info, err := osStat(filename)
+	info, err := l.fs().Stat(filename)
 	if os.IsNotExist(err) {
-		return l.openNew()
+		return l.openNew(RotationSize)
 	}
 
 	if err != nil {
 		return fmt.Errorf("error getting log file info: %s", err)
 	}
 
-	if info.Size()+int64(writeLen) >= l.max() {
-		return l.rotate()
+	size := info.Size()
+
+	flag := os.O_APPEND | os.O_WRONLY
+	if l.truncatesOnOpen() {
+		flag |= os.O_TRUNC
+		size = 0
+	}
+
+	if size+int64(writeLen) >= l.max() {
+		return l.rotate(RotationSize)
+	}
+
+	if l.RotateOnStart && !l.started && size > 0 {
+		return l.rotate(RotationStart)
+	}
+
+	next, err := l.nextRotationTime()
+	if err != nil {
+		return err
 	}
 
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, fileModeAlreadyExist)
+	file, err := l.fs().OpenFile(filename, flag, fileModeAlreadyExist)
 	if err != nil {
 		// if we fail to open the old log file for some reason, just ignore
 		// it and open a new log file.
-		return l.openNew()
+		end := l.instrument(context.Background(), OperationRotate, filename)
+		err = l.openNew(RotationOpen)
+		end(err)
+
+		return err
 	}
 
-	l.file = file
+	l.file = l.openedFile(file)
+	l.started = true
+
+	if flag&os.O_TRUNC != 0 {
+		l.generation.Add(1)
+	}
+
+	l.startBuffer()
+	l.updateSlowPath()
+	l.startSyncTimer()
+	l.startDiskTimer()
+	l.startJanitor()
+	l.manage()
+	l.reserveSpace()
+	l.linkActive()
+
+	l.size = size
+	l.headerLen = 0
 
-	l.size = info.Size()
+	l.shadow.reset()
+
+	l.scheduleRotation(next)
+
+	if flag&os.O_TRUNC != 0 {
+		return l.writeHeader()
+	}
 
 	return nil
 }
@@ -324,7 +1389,9 @@ func (l *Logger) openExistingOrNew(writeLen int) error {
 // filename generates the name of the logfile from the current time.
 func (l *Logger) filename() string {
 	if l.Filename != "" {
-		return l.Filename
+		name, _ := expandFilename(l.Filename)
+
+		return name
 	}
 
 	name := filepath.Base(os.Args[0]) + "-lumberjack.log"
@@ -335,21 +1402,230 @@ func (l *Logger) filename() string {
 // millRunOnce performs compression and removal of stale log files.
 // Log files are compressed if enabled via configuration and old log
 // files are removed, keeping at most l.MaxBackups files, as long as
-// none of them are older than MaxAge.
-//
-//nolint:gocognit
-func (l *Logger) millRunOnce() error {
-	if l.MaxBackups == 0 && l.MaxAge == 0 && !l.Compress {
+// none of them are older than MaxAge.  Finally, the oldest backups are
+// removed until all files fit in MaxTotalBytes, and the symlink maintained by
+// PrevSymlink is updated.
+func (l *Logger) millRunOnce(ctx context.Context) error {
+	// The settings are read with millMu held, see UpdateConfig.
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+
+	if l.MaxBackups == 0 && l.MaxAge == 0 && l.MaxTotalBytes == 0 && !l.Compress && !l.encrypts() &&
+		l.MaxUncompressedBackups == 0 && l.MaxCompressedBackups == 0 &&
+		l.archiver() == nil && !l.PrevSymlink && !l.watchesDisk() {
 		return nil
 	}
 
+	unlock, err := l.lockMill()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	files, err := l.oldLogFiles()
 	if err != nil {
 		return err
 	}
 
-	var compress, remove []logInfo
+	remove, compress := l.retention(files)
+
+	// OnRotate is promised the backups before they are compressed.
+	err = l.compressBackups(ctx, l.unreported(compress), l.Compress)
+
+	l.scheduleCompression(files)
+
+	// Backups are archived before old ones are removed, so that a backup
+	// isn't lost if it becomes old before it could be archived.
+	if errArchive := l.archiveBackups(ctx); err == nil {
+		err = errArchive
+	}
+
+	if errRemove := l.removeBackups(remove); err == nil {
+		err = errRemove
+	}
+
+	// The quota is enforced last, so that it is measured against the
+	// compressed sizes of the backups.
+	if errQuota := l.enforceQuota(); err == nil {
+		err = errQuota
+	}
+
+	if errSpace := l.freeDiskSpace(ctx); err == nil {
+		err = errSpace
+	}
+
+	if errLink := l.linkNewestBackup(); err == nil {
+		err = errLink
+	}
+
+	return err
+}
+
+// removeBackups removes the given backup files, returning the first error.
+func (l *Logger) removeBackups(files []logInfo) error {
+	var err error
+
+	for _, f := range files {
+		fn := filepath.Join(l.backupDir(), f.Name())
+
+		errRemove := l.fs().Remove(fn)
+		if err == nil && errRemove != nil {
+			err = errRemove
+		}
+
+		if errRemove == nil {
+			l.recordRemoval()
+			l.diag("lumberjack: removed backup", "path", fn)
+		} else {
+			l.diag("lumberjack: removing backup failed", "path", fn, "error", errRemove)
+		}
+	}
+
+	return err
+}
+
+// compressBackups finalizes the given backup files, see finalizer, with up to
+// CompressWorkers goroutines, returning the first error.  It stops early if
+// ctx is canceled.
+func (l *Logger) compressBackups(ctx context.Context, files []logInfo, compress bool) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	suffix, newWriter, err := l.finalizer(compress)
+	if err != nil {
+		return err
+	}
+
+	workers := l.compressWorkers()
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	// The queue holds one backup per worker, so that the mill doesn't run
+	// ahead of the workers.
+	tasks := make(chan logInfo, workers)
+
+	var (
+		wg    sync.WaitGroup
+		errMu sync.Mutex
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for f := range tasks {
+				errCompress := ctx.Err()
+
+				if errCompress == nil {
+					fn := filepath.Join(l.backupDir(), f.Name())
+
+					var res CompressionResult
+
+					end := l.instrument(ctx, OperationCompress, fn)
+
+					res, errCompress = compressLogFile(
+						ctx, l.fs(), fn, fn+suffix, newWriter, l.compressBufferSize(), l.preserveOwner(),
+					)
+
+					end(errCompress)
+
+					if errCompress != nil {
+						l.diag("lumberjack: compressing backup failed", "path", fn, "error", errCompress)
+					} else {
+						l.diag("lumberjack: compressed backup", "path", fn+suffix, "duration", res.Duration)
+					}
+
+					if errCompress == nil && compress {
+						l.recordCompression(res)
+					}
+				}
+
+				errMu.Lock()
+				if err == nil && errCompress != nil {
+					err = errCompress
+				}
+				errMu.Unlock()
+			}
+		}()
+	}
+
+	for _, f := range files {
+		tasks <- f
+	}
+
+	close(tasks)
+	wg.Wait()
+
+	return err
+}
+
+// compressWorkers returns the number of backups to compress at the same time.
+func (l *Logger) compressWorkers() int {
+	if l.CompressWorkers > 0 {
+		return l.CompressWorkers
+	}
+
+	return 1
+}
+
+// enforceQuota removes the oldest backups until they fit in MaxTotalBytes
+// together with the active file.
+func (l *Logger) enforceQuota() error {
+	if l.MaxTotalBytes <= 0 {
+		return nil
+	}
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	return l.removeBackups(l.quota(files, l.activeSize()))
+}
+
+// activeSize returns the size of the active log file on disk, or 0 if it
+// can't be determined.
+func (l *Logger) activeSize() int64 {
+	info, err := l.fs().Stat(l.activeName())
+	if err != nil {
+		return 0
+	}
+
+	return info.Size()
+}
+
+// quota returns the oldest of the given backup files, sorted newest first,
+// which have to be removed for the rest of them and an active file of
+// activeSize bytes to fit in MaxTotalBytes.  It does not touch the filesystem.
+func (l *Logger) quota(files []logInfo, activeSize int64) (remove []logInfo) {
+	if l.MaxTotalBytes <= 0 {
+		return nil
+	}
+
+	total := activeSize
+	for _, f := range files {
+		total += f.Size()
+	}
+
+	for i := len(files) - 1; i >= 0 && total > l.MaxTotalBytes; i-- {
+		remove = append(remove, files[i])
+		total -= files[i].Size()
+	}
+
+	return remove
+}
 
+// retention splits the given backup files, sorted newest first, into the ones
+// which should be removed and the ones which should be compressed according to
+// the Logger's MaxBackups, MaxAge, Compress, MaxUncompressedBackups and
+// MaxCompressedBackups settings. It does not touch the filesystem.
+//
+//nolint:gocognit
+func (l *Logger) retention(files []logInfo) (remove, compress []logInfo) {
 	if l.MaxBackups > 0 && l.MaxBackups < len(files) {
 		preserved := make(map[string]bool)
 
@@ -359,11 +1635,7 @@ func (l *Logger) millRunOnce() error {
 			// Only count the uncompressed log file or the
 			// compressed log file, not both.
 			fn := f.Name()
-
-			//nolint:gosimple
-			if strings.HasSuffix(fn, compressSuffix) {
-				fn = fn[:len(fn)-len(compressSuffix)]
-			}
+			fn = fn[:len(fn)-len(backupSuffix(fn))]
 
 			preserved[fn] = true
 
@@ -379,7 +1651,7 @@ func (l *Logger) millRunOnce() error {
 
 	if l.MaxAge > 0 {
 		diff := time.Duration(int64(dayInHours) * int64(l.MaxAge))
-		cutoff := currentTime().Add(-1 * diff)
+		cutoff := l.now().Add(-1 * diff)
 
 		var remaining []logInfo
 
@@ -394,50 +1666,95 @@ func (l *Logger) millRunOnce() error {
 		files = remaining
 	}
 
-	if l.Compress {
-		for _, f := range files {
-			if !strings.HasSuffix(f.Name(), compressSuffix) {
-				compress = append(compress, f)
+	finalize := l.Compress || l.encrypts()
+	plain := 0
+	finalized := make(map[string]bool)
+
+	for _, f := range files {
+		fn := f.Name()
+		suffix := backupSuffix(fn)
+
+		if suffix == "" {
+			plain++
+
+			switch {
+			case finalize && plain > l.MaxUncompressedBackups:
+				// Counted as compressed below.
+			case !finalize && l.MaxUncompressedBackups > 0 && plain > l.MaxUncompressedBackups:
+				remove = append(remove, f)
+
+				continue
+			default:
+				continue
 			}
 		}
-	}
 
-	for _, f := range remove {
-		errRemove := os.Remove(filepath.Join(l.dir(), f.Name()))
-		if err == nil && errRemove != nil {
-			err = errRemove
-		}
-	}
+		// Only count the uncompressed log file or the compressed log file,
+		// not both.
+		finalized[fn[:len(fn)-len(suffix)]] = true
 
-	for _, f := range compress {
-		fn := filepath.Join(l.dir(), f.Name())
+		if l.MaxCompressedBackups > 0 && len(finalized) > l.MaxCompressedBackups {
+			remove = append(remove, f)
 
-		errCompress := compressLogFile(fn, fn+compressSuffix)
+			continue
+		}
 
-		if err == nil && errCompress != nil {
-			err = errCompress
+		if suffix == "" && !l.compressDelayed(f) {
+			compress = append(compress, f)
 		}
 	}
 
-	return err
+	return remove, compress
 }
 
 // millRun runs in a goroutine to manage post-rotation compression and removal
-// of old log files.
-func (l *Logger) millRun() {
-	for range l.millCh {
-		// what am I going to do, log this?
-		_ = l.millRunOnce()
+// of old log files, until millCh is closed.
+func (l *Logger) millRun(millCh <-chan bool, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	for range millCh {
+		l.runMill()
 	}
 }
 
+// runMill runs the mill once, covering the runs requested so far.  It is run
+// by the mill goroutine, or by a worker of Manager.
+func (l *Logger) runMill() {
+	ctx, gen := l.startMillRun()
+
+	l.notifyRotations()
+	l.notifyRecoveries()
+
+	l.queueError(l.millRunOnce(ctx))
+
+	l.notifyErrors()
+
+	l.finishMillRun(gen)
+}
+
 // mill performs post-rotation compression and removal of stale log files,
-// starting the mill goroutine if necessary.
+// starting the mill goroutine if necessary.  It must be called with l.mu held.
 func (l *Logger) mill() {
-	l.startMill.Do(func() {
+	if l.Manager != nil {
+		l.hooksMu.Lock()
+		l.millQueued++
+		l.hooksMu.Unlock()
+
+		l.Manager.schedule(l)
+
+		return
+	}
+
+	if l.millCh == nil {
 		l.millCh = make(chan bool, 1)
-		go l.millRun()
-	})
+		l.millStopped = make(chan struct{})
+
+		go l.millRun(l.millCh, l.millStopped)
+	}
+
+	l.hooksMu.Lock()
+	l.millQueued++
+	l.hooksMu.Unlock()
 
 	select {
 	case l.millCh <- true:
@@ -445,41 +1762,69 @@ func (l *Logger) mill() {
 	}
 }
 
-// oldLogFiles returns the list of backup log files stored in the same
-// directory as the current log file, sorted by ModTime.
+// oldLogFiles returns the list of backup log files stored in the backup
+// directory, sorted by ModTime.
 func (l *Logger) oldLogFiles() ([]logInfo, error) {
-	files, err := os.ReadDir(l.dir())
+	files, err := l.fs().ReadDir(l.backupDir())
+	if os.IsNotExist(err) && l.BackupDir != "" {
+		// No backup has been made yet.
+		return nil, nil
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("can't read log file directory: %s", err)
 	}
 
-	logFiles := []logInfo{}
+	n, err := l.namer()
+	if err != nil {
+		return nil, err
+	}
 
-	prefix, ext := l.prefixAndExt()
+	logFiles := []logInfo{}
 
 	for _, f := range files {
 		if f.IsDir() {
 			continue
 		}
 
-		if t, err := l.timeFromName(f.Name(), prefix, ext); err == nil {
-			if fInfo, fErr := f.Info(); fErr == nil {
-				logFiles = append(logFiles, logInfo{fInfo, t})
-			}
+		name := f.Name()
 
-			continue
-		}
+		base := name[:len(name)-len(backupSuffix(name))]
 
-		if t, err := l.timeFromName(f.Name(), prefix, ext+compressSuffix); err == nil {
-			if fInfo, fErr := f.Info(); fErr == nil {
-				logFiles = append(logFiles, logInfo{fInfo, t})
+		p, ok := n.parse(base)
+		if !ok {
+			// The files of previous periods are backups as well.
+			date, dated := l.parseDated(base)
+			if !dated {
+				continue
 			}
 
+			p = parsedName{timestamp: date, hasTime: true}
+		}
+
+		fInfo, fErr := f.Info()
+		if fErr != nil {
 			continue
 		}
+
+		// Without a timestamp in the name, the time the backup was last
+		// written to is the best approximation of its rotation time.
+		t := p.timestamp
+		if !p.hasTime {
+			t = fInfo.ModTime()
+		}
+
+		logFiles = append(logFiles, logInfo{fInfo, t, p.seq, p.dup})
 	}
 
-	sort.Sort(byFormatTime(logFiles))
+	if n.shifts {
+		// The lowest number is the newest backup.
+		sort.SliceStable(logFiles, func(i, j int) bool {
+			return logFiles[i].seq < logFiles[j].seq
+		})
+	} else {
+		sort.Sort(byFormatTime(logFiles))
+	}
 
 	return logFiles, nil
 }
@@ -498,7 +1843,46 @@ func (l *Logger) timeFromName(filename, prefix, ext string) (time.Time, error) {
 
 	ts := filename[len(prefix) : len(filename)-len(ext)]
 
-	return time.Parse(backupTimeFormat, ts)
+	return l.parseTimestamp(ts)
+}
+
+// parseTimestamp parses a timestamp formatted for a backup name, in the zone
+// selected by Location.  Timestamps of any TimestampPrecision are accepted, so
+// that it can be changed at any time, and so is a zone offset appended by
+// formatTimestamp if it is the offset of Location at that time.
+func (l *Logger) parseTimestamp(ts string) (time.Time, error) {
+	loc := l.location()
+
+	t, err := time.ParseInLocation(backupTimeFormatSecond+backupZoneFormat, ts, loc)
+	if err != nil {
+		return time.ParseInLocation(backupTimeFormatSecond, ts, loc)
+	}
+
+	_, offset := t.Zone()
+	if _, want := t.In(loc).Zone(); offset != want {
+		return time.Time{}, fmt.Errorf("zone offset of %q doesn't match Location", ts)
+	}
+
+	return t.In(loc), nil
+}
+
+// location returns the time zone of backup timestamps and RotateAt, see
+// Location.
+func (l *Logger) location() *time.Location {
+	switch {
+	case l.Location != nil:
+		return l.Location
+	case l.LocalTime:
+		return time.Local
+	default:
+		return time.UTC
+	}
+}
+
+// preserveOwner reports whether file ownership is preserved, see
+// PreserveOwner.
+func (l *Logger) preserveOwner() bool {
+	return l.PreserveOwner == nil || *l.PreserveOwner
 }
 
 // max returns the maximum size in bytes of log files before rolling.
@@ -519,6 +1903,19 @@ func (l *Logger) dir() string {
 	return filepath.Dir(l.filename())
 }
 
+// backupDir returns the directory backups are stored in.
+func (l *Logger) backupDir() string {
+	if l.BackupDir == "" {
+		return l.dir()
+	}
+
+	if filepath.IsAbs(l.BackupDir) {
+		return l.BackupDir
+	}
+
+	return filepath.Join(l.dir(), l.BackupDir)
+}
+
 // prefixAndExt returns the filename part and extension part from the Logger's
 // filename.
 func (l *Logger) prefixAndExt() (prefix, ext string) {
@@ -529,75 +1926,59 @@ func (l *Logger) prefixAndExt() (prefix, ext string) {
 	return prefix, ext
 }
 
-// compressLogFile compresses the given log file, removing the
-// uncompressed log file if successful.
-func compressLogFile(src, dst string) (err error) {
-	f, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %v", err)
-	}
-
-	defer f.Close()
-
-	fi, err := osStat(src)
-	if err != nil {
-		return fmt.Errorf("failed to stat log file: %v", err)
-	}
-
-	if err := chown(dst, fi); err != nil {
-		return fmt.Errorf("failed to chown compressed log file: %v", err)
-	}
-
-	// If this file already exists, we presume it was created by
-	// a previous attempt to compress the log file.
-	gzf, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
-	if err != nil {
-		return fmt.Errorf("failed to open compressed log file: %v", err)
-	}
+// BackupInfo describes a rotated backup file.
+type BackupInfo struct {
+	// Path is the full path of the backup file.
+	Path string
 
-	defer gzf.Close()
+	// Timestamp is the rotation time encoded in the backup's filename.
+	Timestamp time.Time
 
-	gz := gzip.NewWriter(gzf)
+	// Size is the size of the backup file in bytes.
+	Size int64
 
-	defer func() {
-		if err != nil {
-			os.Remove(dst)
-
-			err = fmt.Errorf("failed to compress log file: %v", err)
-		}
-	}()
-
-	if _, err := io.Copy(gz, f); err != nil {
-		return err
-	}
+	// Compressed reports whether the backup has been compressed.
+	Compressed bool
 
-	if err := gz.Close(); err != nil {
-		return err
-	}
-
-	if err := gzf.Close(); err != nil {
-		return err
-	}
+	// Encrypted reports whether the backup has been encrypted.
+	Encrypted bool
+}
 
-	if err := f.Close(); err != nil {
-		return err
+// backupInfo converts a logInfo found in the Logger's backup directory into a
+// BackupInfo.
+func (l *Logger) backupInfo(f logInfo) BackupInfo {
+	return BackupInfo{
+		Path:       filepath.Join(l.backupDir(), f.Name()),
+		Timestamp:  f.timestamp,
+		Size:       f.Size(),
+		Compressed: compressedSuffix(strings.TrimSuffix(f.Name(), encryptSuffix)) != "",
+		Encrypted:  strings.HasSuffix(f.Name(), encryptSuffix),
 	}
-
-	return os.Remove(src)
 }
 
 // logInfo is a convenience struct to return the filename and its embedded
-// timestamp.
+// timestamp and sequence number.
 type logInfo struct {
 	os.FileInfo
 	timestamp time.Time
+	seq       int
+	dup       int
 }
 
-// byFormatTime sorts by newest time formatted in the name.
+// byFormatTime sorts by newest time formatted in the name, then by highest
+// sequence number, then by highest number appended to a name already taken.
 type byFormatTime []logInfo
 
 func (b byFormatTime) Less(i, j int) bool {
-	return b[i].timestamp.After(b[j].timestamp)
+	if !b[i].timestamp.Equal(b[j].timestamp) {
+		return b[i].timestamp.After(b[j].timestamp)
+	}
+
+	if b[i].seq != b[j].seq {
+		return b[i].seq > b[j].seq
+	}
+
+	return b[i].dup > b[j].dup
 }
 
 func (b byFormatTime) Swap(i, j int) {
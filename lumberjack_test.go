@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
@@ -73,6 +76,119 @@ func TestOpenExisting(t *testing.T) {
 	fileCount(t, dir, 1)
 }
 
+func TestRotateOnStart(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t, "TestRotateOnStart")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	data := []byte("foo!")
+	err := os.WriteFile(filename, data, fileModeNew)
+	isNil(t, err)
+
+	reasons := make(chan RotationReason, 1)
+	l := &Logger{
+		Filename:      filename,
+		RotateOnStart: true,
+		OnRotate: func(_, _ string, reason RotationReason) {
+			reasons <- reason
+		},
+	}
+	defer l.Close()
+
+	newFakeTime()
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	// The previous run's file was rotated away.
+	existsWithContent(t, filename, b)
+	existsWithContent(t, backupFile(dir), data)
+	equals(t, RotationStart, <-reasons)
+
+	// Reopening the file later appends to it.
+	isNil(t, l.Close())
+
+	b2 := []byte("baz!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+
+	existsWithContent(t, filename, append(b, b2...))
+	fileCount(t, dir, 2)
+}
+
+func TestRotateOnStartEmpty(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t, "TestRotateOnStartEmpty")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	err := os.WriteFile(filename, nil, fileModeNew)
+	isNil(t, err)
+
+	l := &Logger{
+		Filename:      filename,
+		RotateOnStart: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	isNil(t, err)
+
+	// An empty file isn't worth a backup.
+	existsWithContent(t, filename, b)
+	fileCount(t, dir, 1)
+}
+
+func TestOpenTruncate(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t, "TestOpenTruncate")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	err := os.WriteFile(filename, []byte("foo!"), fileModeNew)
+	isNil(t, err)
+
+	l := &Logger{
+		Filename: filename,
+		OpenMode: OpenTruncate,
+		Header:   func() []byte { return []byte("# header\n") },
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	// The previous run's output is gone, and no backup was made.
+	existsWithContent(t, filename, []byte("# header\nboo!"))
+	fileCount(t, dir, 1)
+
+	// Reopening the file later appends to it.
+	isNil(t, l.Close())
+
+	b2 := []byte("baz!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+
+	existsWithContent(t, filename, []byte("# header\nboo!baz!"))
+
+	// An unknown mode is rejected.
+	l2 := &Logger{
+		Filename: filename,
+		OpenMode: "overwrite",
+	}
+	defer l2.Close()
+
+	_, err = l2.Write(b)
+	notNil(t, err)
+	equals(t, `unknown OpenMode "overwrite"`, err.Error())
+}
+
 func TestWriteTooLong(t *testing.T) {
 	currentTime = fakeTime
 	dir := makeTempDir(t, "TestWriteTooLong")
@@ -277,9 +393,9 @@ func TestMaxBackups(t *testing.T) {
 	// this will use the new fake time
 	fourthFilename := backupFile(dir)
 
-	// Create a log file that is/was being compressed - this should
-	// not be counted since both the compressed and the uncompressed
-	// log files still exist.
+	// Create a compressed backup of the same name - it must not be
+	// overwritten by compressing the next backup, so that one gets a
+	// number appended.
 	compLogFile := fourthFilename + compressSuffix
 	err = os.WriteFile(compLogFile, []byte("compress"), fileModeNew)
 	isNil(t, err)
@@ -290,8 +406,8 @@ func TestMaxBackups(t *testing.T) {
 	isNil(t, err)
 	equals(t, len(b4), n)
 
+	fourthFilename = strings.TrimSuffix(fourthFilename, ".log") + "-1.log"
 	existsWithContent(t, fourthFilename, b3)
-	existsWithContent(t, fourthFilename+compressSuffix, []byte("compress"))
 
 	// we need to wait a little bit since the files get deleted on a different
 	// goroutine.
@@ -299,7 +415,10 @@ func TestMaxBackups(t *testing.T) {
 
 	// We should have four things in the directory now - the 2 log files, the
 	// not log file, and the directory
-	fileCount(t, dir, 5)
+	fileCount(t, dir, 4)
+
+	// the compressed backup is older than the one numbered after it
+	notExist(t, compLogFile)
 
 	// third file name should still exist
 	existsWithContent(t, filename, b4)
@@ -371,6 +490,58 @@ func TestCleanupExistingBackups(t *testing.T) {
 	fileCount(t, dir, 2)
 }
 
+func TestMaxTotalBytes(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestMaxTotalBytes")
+	defer os.RemoveAll(dir)
+
+	// make 3 backup files of 10 bytes each.
+	data := []byte("0123456789")
+
+	var backups []string
+
+	for i := 0; i < 3; i++ {
+		backup := backupFile(dir)
+		err := os.WriteFile(backup, data, fileModeNew)
+		isNil(t, err)
+
+		backups = append(backups, backup)
+
+		newFakeTime()
+	}
+
+	filename := logFile(dir)
+	err := os.WriteFile(filename, []byte("data"), fileModeNew)
+	isNil(t, err)
+
+	l := &Logger{
+		Filename:      filename,
+		MaxBytes:      10,
+		MaxTotalBytes: 25,
+	}
+	defer l.Close()
+
+	// this will rotate the 4 byte file into a fourth backup.
+	b := []byte("foooooo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	// 30 bytes of old backups, 4 bytes of new backup and 8 bytes of active
+	// file: the two oldest backups have to go to fit into 25 bytes.
+	notExist(t, backups[0])
+	notExist(t, backups[1])
+	existsWithContent(t, backups[2], data)
+	existsWithContent(t, backupFile(dir), []byte("data"))
+	existsWithContent(t, filename, b)
+	fileCount(t, dir, 3)
+}
+
 func TestMaxAge(t *testing.T) {
 	currentTime = fakeTime
 
@@ -525,6 +696,139 @@ func TestLocalTime(t *testing.T) {
 	existsWithContent(t, backupFileLocal(dir), b)
 }
 
+func TestLocation(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestLocation")
+	defer os.RemoveAll(dir)
+
+	loc := time.FixedZone("UTC+8", 8*60*60)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxBytes: 10,
+		// Location takes precedence.
+		LocalTime: true,
+		Location:  loc,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+
+	b2 := []byte("fooooooo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+
+	backup := filepath.Join(dir, "foobar-"+fakeTime().In(loc).Format(backupTimeFormat)+".log")
+	existsWithContent(t, logFile(dir), b2)
+	existsWithContent(t, backup, b)
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 1, len(files))
+	equals(t, true, files[0].timestamp.Equal(fakeTime().Truncate(time.Millisecond)))
+}
+
+func TestLocalTimeDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	isNil(t, err)
+
+	fallBack := []time.Time{
+		time.Date(2022, 11, 6, 5, 30, 0, 0, time.UTC),
+		time.Date(2022, 11, 6, 6, 10, 0, 0, time.UTC),
+		time.Date(2022, 11, 6, 6, 10, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		times    []time.Time
+		names    []string
+	}{
+		{
+			// 2:00 EST jumps to 3:00 EDT, 40 minutes pass between rotations.
+			name: "SpringForward",
+			times: []time.Time{
+				time.Date(2022, 3, 13, 6, 30, 0, 0, time.UTC),
+				time.Date(2022, 3, 13, 7, 10, 0, 0, time.UTC),
+			},
+			names: []string{
+				"foobar-2022-03-13T01-30-00.000.log",
+				"foobar-2022-03-13T03-10-00.000.log",
+			},
+		},
+		{
+			// 2:00 EDT falls back to 1:00 EST, 40 minutes pass between rotations
+			// but the local clock goes backwards.  The timestamps of the
+			// repeated hour carry the zone offset, and a repeated name gets a
+			// number appended.
+			name:  "FallBack",
+			times: fallBack,
+			names: []string{
+				"foobar-2022-11-06T01-30-00.000-0400.log",
+				"foobar-2022-11-06T01-10-00.000-0500.log",
+				"foobar-2022-11-06T01-10-00.000-0500-1.log",
+			},
+		},
+		{
+			// The zone offset isn't taken for an appended number.
+			name:     "FallBackTemplate",
+			template: "{{.Prefix}}.{{.Timestamp}}{{.Ext}}",
+			times:    fallBack,
+			names: []string{
+				"foobar.2022-11-06T01-30-00.000-0400.log",
+				"foobar.2022-11-06T01-10-00.000-0500.log",
+				"foobar.2022-11-06T01-10-00.000-0500-1.log",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := makeTempDir(t, "TestLocalTimeDST"+test.name)
+			defer os.RemoveAll(dir)
+
+			l := &Logger{
+				Filename:           logFile(dir),
+				Location:           loc,
+				BackupNameTemplate: test.template,
+			}
+			defer l.Close()
+
+			for i, now := range test.times {
+				now := now.In(loc)
+				currentTime = func() time.Time { return now }
+
+				_, err := l.Write([]byte{byte('a' + i)})
+				isNil(t, err)
+				isNil(t, l.Rotate())
+			}
+
+			currentTime = fakeTime
+
+			for i, name := range test.names {
+				existsWithContent(t, filepath.Join(dir, name), []byte{byte('a' + i)})
+			}
+
+			// Backups are listed newest first and their timestamps are read back
+			// in Location.
+			files, err := l.oldLogFiles()
+			isNil(t, err)
+			equals(t, len(test.names), len(files))
+
+			for i, f := range files {
+				j := len(test.names) - 1 - i
+				equals(t, test.names[j], f.Name())
+				equals(t, test.times[j].Unix(), f.timestamp.Unix())
+			}
+		})
+	}
+}
+
 func TestRotate(t *testing.T) {
 	currentTime = fakeTime
 	dir := makeTempDir(t, "TestRotate")
@@ -678,6 +982,297 @@ func TestCompressOnResume(t *testing.T) {
 	fileCount(t, dir, 2)
 }
 
+func TestMaxUncompressedBackups(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestMaxUncompressedBackups")
+	defer os.RemoveAll(dir)
+
+	// Five backups, the last one the newest.
+	var backups []string
+
+	for i := 0; i < 5; i++ {
+		newFakeTime()
+
+		backup := backupFile(dir)
+		err := os.WriteFile(backup, []byte("foo!"), fileModeNew)
+		isNil(t, err)
+
+		backups = append(backups, backup)
+	}
+
+	newFakeTime()
+
+	l := &Logger{
+		Filename:               logFile(dir),
+		Compress:               true,
+		MaxUncompressedBackups: 2,
+		MaxCompressedBackups:   2,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+	isNil(t, l.CloseAndWait())
+
+	// The newest two are kept for grepping, the next two compressed, and the
+	// oldest one removed.
+	existsWithContent(t, backups[4], []byte("foo!"))
+	existsWithContent(t, backups[3], []byte("foo!"))
+	exists(t, backups[2]+compressSuffix)
+	exists(t, backups[1]+compressSuffix)
+	notExist(t, backups[2])
+	notExist(t, backups[1])
+	notExist(t, backups[0])
+	notExist(t, backups[0]+compressSuffix)
+	fileCount(t, dir, 5)
+
+	// Without Compress, both limits remove the excess backups.
+	l = &Logger{
+		Filename:               logFile(dir),
+		MaxUncompressedBackups: 1,
+		MaxCompressedBackups:   1,
+	}
+	defer l.Close()
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(t, err)
+	isNil(t, l.CloseAndWait())
+
+	exists(t, backups[4])
+	notExist(t, backups[3])
+	exists(t, backups[2]+compressSuffix)
+	notExist(t, backups[1]+compressSuffix)
+	fileCount(t, dir, 3)
+}
+
+func TestAppendNewline(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestAppendNewline")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		MaxBytes:      10,
+		AppendNewline: true,
+	}
+	defer l.Close()
+
+	// The caller's buffer isn't written to, even if it has room.
+	b := make([]byte, 4, 8)
+	copy(b, "boo!")
+
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, 4, n)
+	equals(t, byte(0), b[:5][4])
+
+	n, err = l.Write([]byte("foo\n"))
+	isNil(t, err)
+	equals(t, 4, n)
+	existsWithContent(t, filename, []byte("boo!\nfoo\n"))
+
+	// The newline counts towards MaxBytes.
+	newFakeTime()
+
+	_, err = l.Write([]byte("bar"))
+	isNil(t, err)
+	existsWithContent(t, backupFile(dir), []byte("boo!\nfoo\n"))
+	existsWithContent(t, filename, []byte("bar\n"))
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestMirror(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestMirror")
+	defer os.RemoveAll(dir)
+
+	var mirror bytes.Buffer
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxBytes: 10,
+		Mirror:   &mirror,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+
+	// The mirror sees every record, across rotations.
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+	existsWithContent(t, filename, b2)
+	existsWithContent(t, backupFile(dir), b)
+	equals(t, "boo!foooooo!", mirror.String())
+
+	// A broken mirror doesn't fail the write.
+	l.Mirror = failingWriter{}
+	errs := l.Errors()
+
+	_, err = l.Write(b)
+	isNil(t, err)
+	existsWithContent(t, filename, b)
+
+	select {
+	case err := <-errs:
+		equals(t, "can't write to Mirror: broken pipe", err.Error())
+	case <-time.After(time.Second):
+		t.Fatal("no error was delivered")
+	}
+}
+
+func TestBootFile(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBootFile")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	bootFilename := filepath.Join(dir, "boot", "foobar-boot.log")
+
+	// Leftovers from a previous run must be discarded.
+	err := os.MkdirAll(filepath.Dir(bootFilename), 0o700)
+	isNil(t, err)
+	err = os.WriteFile(bootFilename, []byte("previous run"), fileModeNew)
+	isNil(t, err)
+
+	l := &Logger{
+		Filename:     filename,
+		BootFilename: bootFilename,
+		MaxBytes:     10,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+	existsWithContent(t, filename, b)
+	existsWithContent(t, bootFilename, b)
+
+	newFakeTime()
+
+	// Rotation only affects the rolling file.
+	b2 := []byte("foooooo!")
+	n, err = l.Write(b2)
+	isNil(t, err)
+	equals(t, len(b2), n)
+	existsWithContent(t, filename, b2)
+	existsWithContent(t, backupFile(dir), b)
+	existsWithContent(t, bootFilename, append(b, b2...))
+
+	// Reopening after Close appends instead of truncating.
+	isNil(t, l.Close())
+
+	b3 := []byte("bar")
+	_, err = l.Write(b3)
+	isNil(t, err)
+	existsWithContent(t, bootFilename, []byte("boo!foooooo!bar"))
+}
+
+func TestSync(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestSync")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	bootFilename := filepath.Join(dir, "boot.log")
+
+	l := &Logger{
+		Filename:     filename,
+		BootFilename: bootFilename,
+	}
+	defer l.Close()
+
+	// Syncing before anything was written is a no-op.
+	isNil(t, l.Sync())
+	fileCount(t, dir, 0)
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	isNil(t, l.Sync())
+	existsWithContent(t, filename, b)
+	existsWithContent(t, bootFilename, b)
+
+	isNil(t, l.Close())
+	isNil(t, l.Sync())
+}
+
+func TestBackupDir(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackupDir")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	archive := filepath.Join(dir, "archive")
+
+	l := &Logger{
+		Filename:   filename,
+		BackupDir:  "archive",
+		MaxBackups: 1,
+		Compress:   true,
+		MaxBytes:   10,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+	existsWithContent(t, filename, b)
+
+	// A backup directory which doesn't exist yet holds no backups.
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 0, len(files))
+
+	newFakeTime()
+
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+	existsWithContent(t, filename, b2)
+
+	// we need to wait a little bit since the files get compressed on a
+	// different goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	notExist(t, backupFile(dir))
+	exists(t, backupFile(archive)+compressSuffix)
+	fileCount(t, dir, 2)
+	fileCount(t, archive, 1)
+
+	newFakeTime()
+
+	b3 := []byte("baaaaaar!")
+	_, err = l.Write(b3)
+	isNil(t, err)
+
+	<-time.After(300 * time.Millisecond)
+
+	// Retention only considers the backup directory.
+	exists(t, backupFile(archive)+compressSuffix)
+	fileCount(t, archive, 1)
+}
+
 func TestJson(t *testing.T) {
 	data := []byte(`
 {
@@ -810,3 +1405,36 @@ func exists(tb testing.TB, path string) {
 	_, err := os.Stat(path)
 	assertUp(tb, err == nil, 1, "expected file to exist, but got error from os.Stat: %v", err)
 }
+
+func TestSizeAndCurrentPath(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestSizeAndCurrentPath")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxBytes:   10,
+		BufferSize: 100,
+	}
+	defer l.Close()
+
+	equals(t, filename, l.CurrentPath())
+	equals(t, int64(0), l.Size())
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	// Buffered data counts.
+	equals(t, int64(4), l.Size())
+
+	_, err = l.Write([]byte("foo!bar!"))
+	isNil(t, err)
+	equals(t, int64(8), l.Size())
+	equals(t, filename, l.CurrentPath())
+
+	// The size of a closed file is read from disk.
+	isNil(t, l.Close())
+	equals(t, int64(8), l.Size())
+}
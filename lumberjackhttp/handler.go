@@ -0,0 +1,185 @@
+// Package lumberjackhttp provides an HTTP handler to administer a
+// lumberjack.Logger, to be mounted into the admin or debug mux of a service:
+//
+//	l := &lumberjack.Logger{Filename: "/var/log/myapp/foo.log"}
+//	mux.Handle("/debug/logs/", http.StripPrefix("/debug/logs", lumberjackhttp.Handler(l)))
+//
+// The handler serves the following endpoints, relative to where it is
+// mounted:
+//
+//	POST /rotate          rotates the log file
+//	GET  /stats           returns the Stats of the Logger as JSON
+//	GET  /health          returns 200 if the Logger is Healthy, or 503 and the error
+//	GET  /backups         lists the backups, newest first, as JSON
+//	GET  /backups/{name}  downloads the backup with the given file name
+//
+// The handler does no authentication, so it must only be reachable by
+// administrators.
+package lumberjackhttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+// Handler returns an http.Handler serving the endpoints described in the
+// package documentation for l.
+func Handler(l *lumberjack.Logger) http.Handler {
+	return &handler{l: l}
+}
+
+type handler struct {
+	l *lumberjack.Logger
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+
+	switch {
+	case path == "rotate":
+		if allow(w, r, http.MethodPost) {
+			h.rotate(w)
+		}
+	case path == "stats":
+		if allow(w, r, http.MethodGet, http.MethodHead) {
+			writeJSON(w, h.l.Stats())
+		}
+	case path == "health":
+		if allow(w, r, http.MethodGet, http.MethodHead) {
+			h.health(w)
+		}
+	case path == "backups":
+		if allow(w, r, http.MethodGet, http.MethodHead) {
+			h.backups(w)
+		}
+	case strings.HasPrefix(path, "backups/"):
+		if allow(w, r, http.MethodGet, http.MethodHead) {
+			h.download(w, r, strings.TrimPrefix(path, "backups/"))
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// allow reports whether r uses one of the given methods, and responds with
+// 405 Method Not Allowed if not.
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+	return false
+}
+
+func (h *handler) rotate(w http.ResponseWriter) {
+	if err := h.l.Rotate(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) health(w http.ResponseWriter) {
+	if err := h.l.Healthy(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+func (h *handler) backups(w http.ResponseWriter) {
+	backups, err := h.l.Backups()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, backups)
+}
+
+// download serves the backup with the given file name.  Only the files listed
+// by Backups are served, so that the name can't reach other files.
+func (h *handler) download(w http.ResponseWriter, r *http.Request, name string) {
+	backups, err := h.l.Backups()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	for _, b := range backups {
+		if filepath.Base(b.Path) == name {
+			h.serveBackup(w, r, b)
+
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+func (h *handler) serveBackup(w http.ResponseWriter, r *http.Request, b lumberjack.BackupInfo) {
+	var (
+		f   io.ReadSeekCloser
+		err error
+	)
+
+	if h.l.FS != nil {
+		var file lumberjack.File
+
+		if file, err = h.l.FS.OpenFile(b.Path, os.O_RDONLY, 0); err == nil {
+			f = struct {
+				io.ReadSeeker
+				io.Closer
+			}{io.NewSectionReader(file, 0, b.Size), file}
+		}
+	} else {
+		f, err = os.Open(b.Path)
+	}
+
+	if os.IsNotExist(err) {
+		// The backup was removed since it was listed.
+		http.NotFound(w, r)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	defer f.Close()
+
+	name := filepath.Base(b.Path)
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	http.ServeContent(w, r, name, b.Timestamp, f)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
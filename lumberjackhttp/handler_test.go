@@ -0,0 +1,99 @@
+package lumberjackhttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+func do(t *testing.T, h http.Handler, method, path string) *http.Response {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+
+	return w.Result()
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	l := &lumberjack.Logger{Filename: filepath.Join(dir, "foo.log")}
+	defer l.Close()
+
+	if _, err := l.Write([]byte("boo!")); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/logs/", http.StripPrefix("/logs", Handler(l)))
+
+	if resp := do(t, mux, http.MethodGet, "/logs/rotate"); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("GET /rotate: got status %d", resp.StatusCode)
+	}
+
+	if resp := do(t, mux, http.MethodPost, "/logs/rotate"); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST /rotate: got status %d: %s", resp.StatusCode, body(t, resp))
+	}
+
+	var stats lumberjack.Stats
+
+	resp := do(t, mux, http.MethodGet, "/logs/stats")
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Rotations != 1 || stats.Writes != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	if resp := do(t, mux, http.MethodGet, "/logs/health"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /health: got status %d: %s", resp.StatusCode, body(t, resp))
+	}
+
+	var backups []lumberjack.BackupInfo
+
+	resp = do(t, mux, http.MethodGet, "/logs/backups")
+	if err := json.NewDecoder(resp.Body).Decode(&backups); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 1 || backups[0].Size != 4 {
+		t.Fatalf("unexpected backups %+v", backups)
+	}
+
+	name := filepath.Base(backups[0].Path)
+
+	resp = do(t, mux, http.MethodGet, "/logs/backups/"+name)
+	if got := body(t, resp); resp.StatusCode != http.StatusOK || got != "boo!" {
+		t.Fatalf("GET /backups/%s: got status %d and %q", name, resp.StatusCode, got)
+	}
+
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, name) {
+		t.Fatalf("unexpected Content-Disposition %q", cd)
+	}
+
+	// Only backups are served.
+	for _, path := range []string{"/backups/foo.log", "/backups/../foo.log", "/unknown"} {
+		if resp := do(t, Handler(l), http.MethodGet, path); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("GET %s: got status %d", path, resp.StatusCode)
+		}
+	}
+}
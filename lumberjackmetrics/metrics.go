@@ -0,0 +1,119 @@
+// Package lumberjackmetrics exports the activity of a lumberjack.Logger as
+// Prometheus metrics:
+//
+//	l := &lumberjack.Logger{Filename: "/var/log/myapp/foo.log"}
+//	if err := lumberjackmetrics.Register(prometheus.DefaultRegisterer, l); err != nil {
+//		panic(err)
+//	}
+//
+// All metrics carry a "filename" label with the Filename of the Logger, so
+// several Loggers can be registered with the same Registerer.
+package lumberjackmetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+const namespace = "lumberjack"
+
+// Collector is a prometheus.Collector reading the Stats of a Logger whenever
+// it is collected.
+type Collector struct {
+	l *lumberjack.Logger
+
+	writes            *prometheus.Desc
+	writtenBytes      *prometheus.Desc
+	rotations         *prometheus.Desc
+	rotationErrors    *prometheus.Desc
+	removedBackups    *prometheus.Desc
+	compressedFiles   *prometheus.Desc
+	compressionSecs   *prometheus.Desc
+	compressionInput  *prometheus.Desc
+	compressionOutput *prometheus.Desc
+	lastRotation      *prometheus.Desc
+	fileSize          *prometheus.Desc
+	backups           *prometheus.Desc
+	backupBytes       *prometheus.Desc
+}
+
+// NewCollector returns a Collector for l.
+func NewCollector(l *lumberjack.Logger) *Collector {
+	labels := prometheus.Labels{"filename": l.Filename}
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, labels)
+	}
+
+	return &Collector{
+		l:                 l,
+		writes:            desc("writes_total", "Number of writes to the log file."),
+		writtenBytes:      desc("written_bytes_total", "Number of bytes written to the log file."),
+		rotations:         desc("rotations_total", "Number of times the log file was rotated."),
+		rotationErrors:    desc("rotation_errors_total", "Number of failed rotations of the log file."),
+		removedBackups:    desc("removed_backups_total", "Number of old log files removed."),
+		compressedFiles:   desc("compressed_backups_total", "Number of old log files compressed."),
+		compressionSecs:   desc("compression_seconds_total", "Time spent compressing old log files."),
+		compressionInput:  desc("compression_input_bytes_total", "Size of old log files before compression."),
+		compressionOutput: desc("compression_output_bytes_total", "Size of old log files after compression."),
+		lastRotation:      desc("last_rotation_timestamp_seconds", "Time of the most recent rotation."),
+		fileSize:          desc("file_size_bytes", "Current size of the active log file."),
+		backups:           desc("backups", "Current number of old log files."),
+		backupBytes:       desc("backup_bytes", "Current total size of old log files."),
+	}
+}
+
+// Register registers a Collector for l with reg.
+func Register(reg prometheus.Registerer, l *lumberjack.Logger) error {
+	return reg.Register(NewCollector(l))
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.writes
+	ch <- c.writtenBytes
+	ch <- c.rotations
+	ch <- c.rotationErrors
+	ch <- c.removedBackups
+	ch <- c.compressedFiles
+	ch <- c.compressionSecs
+	ch <- c.compressionInput
+	ch <- c.compressionOutput
+	ch <- c.lastRotation
+	ch <- c.fileSize
+	ch <- c.backups
+	ch <- c.backupBytes
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.l.Stats()
+
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+
+	counter(c.writes, float64(s.Writes))
+	counter(c.writtenBytes, float64(s.WrittenBytes))
+	counter(c.rotations, float64(s.Rotations))
+	counter(c.rotationErrors, float64(s.RotationErrors))
+	counter(c.removedBackups, float64(s.RemovedBackups))
+	counter(c.compressedFiles, float64(s.Compression.Files))
+	counter(c.compressionSecs, s.Compression.Duration.Seconds())
+	counter(c.compressionInput, float64(s.Compression.InputBytes))
+	counter(c.compressionOutput, float64(s.Compression.OutputBytes))
+
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+
+	var lastRotation float64
+	if !s.LastRotation.IsZero() {
+		lastRotation = float64(s.LastRotation.UnixNano()) / 1e9
+	}
+
+	gauge(c.lastRotation, lastRotation)
+	gauge(c.fileSize, float64(s.FileSize))
+	gauge(c.backups, float64(s.Backups))
+	gauge(c.backupBytes, float64(s.BackupBytes))
+}
@@ -0,0 +1,90 @@
+package lumberjackmetrics
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+func TestCollector(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "foo.log")
+
+	l := &lumberjack.Logger{Filename: filename}
+	defer l.Close()
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := Register(reg, l); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.Write([]byte("boo!")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]float64)
+
+	for _, mf := range families {
+		m := mf.GetMetric()[0]
+
+		if l := m.GetLabel(); len(l) != 1 || l[0].GetName() != "filename" || l[0].GetValue() != filename {
+			t.Fatalf("unexpected labels %v on %s", l, mf.GetName())
+		}
+
+		switch {
+		case m.Counter != nil:
+			got[mf.GetName()] = m.GetCounter().GetValue()
+		case m.Gauge != nil:
+			got[mf.GetName()] = m.GetGauge().GetValue()
+		}
+	}
+
+	want := map[string]float64{
+		"lumberjack_writes_total":                   1,
+		"lumberjack_written_bytes_total":            4,
+		"lumberjack_rotations_total":                1,
+		"lumberjack_rotation_errors_total":          0,
+		"lumberjack_removed_backups_total":          0,
+		"lumberjack_compressed_backups_total":       0,
+		"lumberjack_compression_seconds_total":      0,
+		"lumberjack_compression_input_bytes_total":  0,
+		"lumberjack_compression_output_bytes_total": 0,
+		"lumberjack_file_size_bytes":                0,
+		"lumberjack_backups":                        1,
+		"lumberjack_backup_bytes":                   4,
+	}
+
+	// The rotation just happened.
+	if time.Since(time.Unix(int64(got["lumberjack_last_rotation_timestamp_seconds"]), 0)) > time.Minute {
+		t.Errorf("unexpected last rotation time %v", got["lumberjack_last_rotation_timestamp_seconds"])
+	}
+
+	delete(got, "lumberjack_last_rotation_timestamp_seconds")
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d metrics, want %d: %v", len(got), len(want), got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("got %s %v, want %v", name, got[name], v)
+		}
+	}
+
+	// A second Logger can be registered along the first one.
+	l2 := &lumberjack.Logger{Filename: filepath.Join(t.TempDir(), "bar.log")}
+	if err := Register(reg, l2); err != nil {
+		t.Fatal(err)
+	}
+}
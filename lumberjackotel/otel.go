@@ -0,0 +1,151 @@
+// Package lumberjackotel reports the activity of a lumberjack.Logger to
+// OpenTelemetry, as spans around its rotations and compressions and as
+// metrics:
+//
+//	l := &lumberjack.Logger{Filename: "/var/log/myapp/foo.log"}
+//	if err := lumberjackotel.Instrument(l, otel.GetTracerProvider(), otel.GetMeterProvider()); err != nil {
+//		panic(err)
+//	}
+//
+// The spans are named "lumberjack.rotate" and "lumberjack.compress", and
+// record the error of a failed operation.  The metrics are:
+//
+//   - lumberjack.writes: the number of writes to the log file
+//   - lumberjack.written: the number of bytes written to the log file
+//   - lumberjack.rotations: the number of rotations of the log file
+//   - lumberjack.errors: the number of failed rotations and compressions,
+//     by lumberjack.operation
+//   - lumberjack.compression.duration: the time it took to compress a backup
+//
+// All spans and metrics carry a lumberjack.filename attribute with the
+// Filename of the Logger, so several Loggers can report to the same
+// providers.
+package lumberjackotel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+// instrumentationName names the tracer and the meter.
+const instrumentationName = "github.com/saucelabs/lumberjack/v3/lumberjackotel"
+
+// Instrumentation is a lumberjack.Instrumentation reporting to OpenTelemetry.
+type Instrumentation struct {
+	tracer   trace.Tracer
+	filename attribute.KeyValue
+
+	rotations   metric.Int64Counter
+	errors      metric.Int64Counter
+	compression metric.Float64Histogram
+}
+
+var _ lumberjack.Instrumentation = (*Instrumentation)(nil)
+
+// New returns an Instrumentation for l, which creates spans with tp and
+// metrics with mp.  The counters of writes are read from l with Written
+// whenever the metrics are collected.  Set the Instrumentation of l to it,
+// or use Instrument, before l is used.
+func New(l *lumberjack.Logger, tp trace.TracerProvider, mp metric.MeterProvider) (*Instrumentation, error) {
+	meter := mp.Meter(instrumentationName)
+	filename := attribute.String("lumberjack.filename", l.Filename)
+
+	i := &Instrumentation{
+		tracer:   tp.Tracer(instrumentationName),
+		filename: filename,
+	}
+
+	var err error
+
+	i.rotations, err = meter.Int64Counter("lumberjack.rotations",
+		metric.WithDescription("Number of rotations of the log file."))
+	if err != nil {
+		return nil, err
+	}
+
+	i.errors, err = meter.Int64Counter("lumberjack.errors",
+		metric.WithDescription("Number of failed rotations and compressions."))
+	if err != nil {
+		return nil, err
+	}
+
+	i.compression, err = meter.Float64Histogram("lumberjack.compression.duration",
+		metric.WithDescription("Time it took to compress a backup."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	writes, err := meter.Int64ObservableCounter("lumberjack.writes",
+		metric.WithDescription("Number of writes to the log file."))
+	if err != nil {
+		return nil, err
+	}
+
+	written, err := meter.Int64ObservableCounter("lumberjack.written",
+		metric.WithDescription("Number of bytes written to the log file."), metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		n, bytes := l.Written()
+
+		o.ObserveInt64(writes, n, metric.WithAttributes(filename))
+		o.ObserveInt64(written, bytes, metric.WithAttributes(filename))
+
+		return nil
+	}, writes, written)
+	if err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// Instrument sets the Instrumentation of l to one returned by New.
+func Instrument(l *lumberjack.Logger, tp trace.TracerProvider, mp metric.MeterProvider) error {
+	i, err := New(l, tp, mp)
+	if err != nil {
+		return err
+	}
+
+	l.Instrumentation = i
+
+	return nil
+}
+
+// Start implements lumberjack.Instrumentation.
+func (i *Instrumentation) Start(ctx context.Context, op lumberjack.Operation, path string) func(err error) {
+	ctx, span := i.tracer.Start(ctx, "lumberjack."+string(op),
+		trace.WithAttributes(i.filename, attribute.String("lumberjack.path", path)))
+
+	start := time.Now()
+
+	return func(err error) {
+		defer span.End()
+
+		attrs := metric.WithAttributes(i.filename, attribute.String("lumberjack.operation", string(op)))
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			i.errors.Add(ctx, 1, attrs)
+
+			return
+		}
+
+		switch op {
+		case lumberjack.OperationRotate:
+			i.rotations.Add(ctx, 1, metric.WithAttributes(i.filename))
+		case lumberjack.OperationCompress:
+			i.compression.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(i.filename))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package lumberjackotel
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+func TestInstrument(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "foo.log")
+
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	l := &lumberjack.Logger{Filename: filename, Compress: true}
+	defer l.Close()
+
+	if err := Instrument(l, tp, mp); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.Write([]byte("boo!")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.CloseAndWait(); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, s := range spans.Ended() {
+		names = append(names, s.Name())
+
+		if !hasAttribute(s.Attributes(), "lumberjack.filename", filename) {
+			t.Errorf("span %s lacks the filename: %v", s.Name(), s.Attributes())
+		}
+	}
+
+	// The backup may be compressed by a mill started before the rotation
+	// ended, so the order of the spans isn't checked.
+	sort.Strings(names)
+
+	if len(names) != 2 || names[0] != "lumberjack.compress" || names[1] != "lumberjack.rotate" {
+		t.Fatalf("unexpected spans %v", names)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]float64)
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				got[m.Name] = float64(data.DataPoints[0].Value)
+			case metricdata.Histogram[float64]:
+				got[m.Name] = float64(data.DataPoints[0].Count)
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"lumberjack.writes":               1,
+		"lumberjack.written":              4,
+		"lumberjack.rotations":            1,
+		"lumberjack.compression.duration": 1,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d metrics, want %d: %v", len(got), len(want), got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("%s: got %v, want %v", name, got[name], v)
+		}
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, key, value string) bool {
+	for _, a := range attrs {
+		if string(a.Key) == key && a.Value.AsString() == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+// Package lumberjacktest helps testing the rotation settings of a
+// lumberjack.Logger without real time passing or files left behind:
+//
+//	func TestRotation(t *testing.T) {
+//		clock := lumberjacktest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//		fs := &lumberjacktest.MemFS{Clock: clock.Now}
+//
+//		l := &lumberjack.Logger{
+//			Filename:         "/var/log/myapp/foo.log",
+//			RotationInterval: 24 * time.Hour,
+//			MaxBackups:       3,
+//			FS:               fs,
+//			Clock:            clock.Now,
+//		}
+//
+//		fmt.Fprintln(l, "monday")
+//		clock.Advance(24 * time.Hour)
+//		fmt.Fprintln(l, "tuesday")
+//
+//		if err := l.CloseAndWait(); err != nil {
+//			t.Fatal(err)
+//		}
+//
+//		lumberjacktest.BackupCount(t, l, 1)
+//		lumberjacktest.LatestBackupContains(t, l, "monday")
+//	}
+//
+// Compression and the removal of old backups run in the background, so the
+// assertions should follow CloseAndWait, which waits for them.
+package lumberjacktest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+// Clock is a fake clock for Logger.Clock, which only moves when told to.  It
+// is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock returns a Clock set to t.
+func NewClock(t time.Time) *Clock {
+	return &Clock{now: t}
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Set sets the clock to t.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = t
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// BackupCount fails the test unless l has n backups.
+func BackupCount(tb testing.TB, l *lumberjack.Logger, n int) {
+	tb.Helper()
+
+	backups, err := l.Backups()
+	if err != nil {
+		tb.Fatalf("can't list backups: %v", err)
+	}
+
+	if len(backups) != n {
+		tb.Fatalf("expected %d backups, got %d: %s", n, len(backups), paths(backups))
+	}
+}
+
+// LatestBackupContains fails the test unless the newest backup of l contains
+// substr.  Compressed and encrypted backups are read back first; backups
+// compressed with xz aren't supported.
+func LatestBackupContains(tb testing.TB, l *lumberjack.Logger, substr string) {
+	tb.Helper()
+
+	backups, err := l.Backups()
+	if err != nil {
+		tb.Fatalf("can't list backups: %v", err)
+	}
+
+	if len(backups) == 0 {
+		tb.Fatalf("expected a backup containing %q, got none", substr)
+	}
+
+	content, err := readBackup(l, backups[0])
+	if err != nil {
+		tb.Fatalf("can't read backup %s: %v", backups[0].Path, err)
+	}
+
+	if !strings.Contains(string(content), substr) {
+		tb.Fatalf("expected backup %s to contain %q, got %q", backups[0].Path, substr, content)
+	}
+}
+
+// readBackup returns the content of the backup b of l, decrypted and
+// decompressed.
+func readBackup(l *lumberjack.Logger, b lumberjack.BackupInfo) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if l.FS != nil {
+		data, err = readFS(l.FS, b.Path)
+	} else {
+		data, err = os.ReadFile(b.Path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+
+	name := b.Path
+
+	if b.Encrypted {
+		if r, err = lumberjack.NewDecryptReader(r, l.EncryptKey); err != nil {
+			return nil, err
+		}
+
+		name = strings.TrimSuffix(name, ".enc")
+	}
+
+	switch {
+	case !b.Compressed:
+	case strings.HasSuffix(name, ".gz"):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		r = zr
+	case strings.HasSuffix(name, ".zst"):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		r = zr
+	default:
+		return nil, fmt.Errorf("unsupported compression of %s", b.Path)
+	}
+
+	return io.ReadAll(r)
+}
+
+// readFS returns the content of the file name on fs.
+func readFS(fs lumberjack.FS, name string) ([]byte, error) {
+	f, err := fs.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+func paths(backups []lumberjack.BackupInfo) string {
+	names := make([]string, 0, len(backups))
+	for _, b := range backups {
+		names = append(names, b.Path)
+	}
+
+	return strings.Join(names, ", ")
+}
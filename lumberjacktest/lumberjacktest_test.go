@@ -0,0 +1,81 @@
+package lumberjacktest
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+func TestRotation(t *testing.T) {
+	clock := NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	fs := &MemFS{Clock: clock.Now}
+
+	l := &lumberjack.Logger{
+		Filename:         "/var/log/myapp/foo.log",
+		RotationInterval: 24 * time.Hour,
+		MaxBackups:       2,
+		Compress:         true,
+		FS:               fs,
+		Clock:            clock.Now,
+	}
+
+	for _, day := range []string{"monday", "tuesday", "wednesday", "thursday"} {
+		if _, err := fmt.Fprintln(l, day); err != nil {
+			t.Fatal(err)
+		}
+
+		clock.Advance(24 * time.Hour)
+	}
+
+	if err := l.CloseAndWait(); err != nil {
+		t.Fatal(err)
+	}
+
+	BackupCount(t, l, 2)
+	LatestBackupContains(t, l, "wednesday")
+
+	data, err := fs.ReadFile("/var/log/myapp/foo.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "thursday\n" {
+		t.Fatalf("expected the active file to hold the last day, got %q", data)
+	}
+}
+
+func TestMemFSOpenRenamed(t *testing.T) {
+	fs := &MemFS{}
+
+	if err := fs.MkdirAll("/logs", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fs.OpenFile("/logs/foo.log", os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.Rename("/logs/foo.log", "/logs/bar.log"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Write([]byte("boo!")); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := fs.ReadFile("/logs/bar.log"); err != nil || string(data) != "boo!" {
+		t.Fatalf("expected the renamed file to be written, got %q, %v", data, err)
+	}
+
+	if _, err := fs.Stat("/logs/foo.log"); err == nil {
+		t.Fatal("the old name still exists")
+	}
+
+	if err := fs.Remove("/logs"); err == nil {
+		t.Fatal("removed a directory which isn't empty")
+	}
+}
@@ -0,0 +1,437 @@
+package lumberjacktest
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saucelabs/lumberjack/v3"
+)
+
+// MemFS is an in-memory lumberjack.FS.  Files are kept after they are closed
+// and renamed like on a POSIX file system, so a file which is open keeps
+// being written after it was renamed or removed.  Paths are cleaned but not
+// made absolute, so a relative and an absolute path never name the same
+// file.  The zero value is an empty file system, and it is safe for
+// concurrent use.
+type MemFS struct {
+	// Clock returns the modification time of written files.  The default
+	// is time.Now.
+	Clock func() time.Time
+
+	mu    sync.Mutex
+	files map[string]*memNode
+	dirs  map[string]os.FileMode
+}
+
+var _ lumberjack.FS = (*MemFS)(nil)
+
+// memNode is the content of a file, shared by all its open handles.
+type memNode struct {
+	mu      sync.Mutex
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+	uid     int
+	gid     int
+}
+
+// ReadFile returns the content of the file name.
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	n, ok := m.files[filepath.Clean(name)]
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]byte(nil), n.data...), nil
+}
+
+// WriteFile writes data to the file name, creating its directory if needed,
+// e.g. to prepare backups left by an earlier run.
+func (m *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if err := m.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	f, err := m.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+func (m *MemFS) now() time.Time {
+	if m.Clock != nil {
+		return m.Clock()
+	}
+
+	return time.Now()
+}
+
+// init creates the maps and the root directory.  It must be called with mu
+// held.
+func (m *MemFS) init() {
+	if m.files == nil {
+		m.files = make(map[string]*memNode)
+		m.dirs = map[string]os.FileMode{string(filepath.Separator): 0o755, ".": 0o755}
+	}
+}
+
+// checkDir reports an error if the directory of name doesn't exist.  It must
+// be called with mu held.
+func (m *MemFS) checkDir(op, name string) error {
+	if _, ok := m.dirs[filepath.Dir(name)]; !ok {
+		return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+
+	return nil
+}
+
+func (m *MemFS) OpenFile(name string, flag int, perm os.FileMode) (lumberjack.File, error) {
+	name = filepath.Clean(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	if _, ok := m.dirs[name]; ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errIsDir}
+	}
+
+	n, ok := m.files[name]
+
+	switch {
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case !ok:
+		if err := m.checkDir("open", name); err != nil {
+			return nil, err
+		}
+
+		n = &memNode{mode: perm.Perm(), modTime: m.now()}
+		m.files[name] = n
+	}
+
+	if flag&os.O_TRUNC != 0 {
+		n.mu.Lock()
+		n.data = nil
+		n.modTime = m.now()
+		n.mu.Unlock()
+	}
+
+	return &memFile{fs: m, node: n, name: name, flag: flag}, nil
+}
+
+func (m *MemFS) Rename(oldpath, newpath string) error {
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	n, ok := m.files[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+
+	if err := m.checkDir("rename", newpath); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+
+	delete(m.files, oldpath)
+	m.files[newpath] = n
+
+	return nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	name = filepath.Clean(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	if _, ok := m.files[name]; ok {
+		delete(m.files, name)
+
+		return nil
+	}
+
+	if _, ok := m.dirs[name]; ok {
+		prefix := name + string(filepath.Separator)
+
+		for p := range m.files {
+			if strings.HasPrefix(p, prefix) {
+				return &os.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+			}
+		}
+
+		delete(m.dirs, name)
+
+		return nil
+	}
+
+	return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+}
+
+func (m *MemFS) Stat(name string) (os.FileInfo, error) {
+	name = filepath.Clean(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	if n, ok := m.files[name]; ok {
+		return n.info(name), nil
+	}
+
+	if mode, ok := m.dirs[name]; ok {
+		return &memInfo{name: filepath.Base(name), mode: mode | os.ModeDir}, nil
+	}
+
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+func (m *MemFS) Chown(name string, uid, gid int) error {
+	name = filepath.Clean(name)
+
+	m.mu.Lock()
+	n, ok := m.files[name]
+	m.mu.Unlock()
+
+	if !ok {
+		return &os.PathError{Op: "chown", Path: name, Err: os.ErrNotExist}
+	}
+
+	n.mu.Lock()
+	n.uid, n.gid = uid, gid
+	n.mu.Unlock()
+
+	return nil
+}
+
+func (m *MemFS) ReadDir(name string) ([]os.DirEntry, error) {
+	name = filepath.Clean(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	if _, ok := m.dirs[name]; !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	var entries []os.DirEntry
+
+	for p, n := range m.files {
+		if filepath.Dir(p) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(n.info(p)))
+		}
+	}
+
+	for p, mode := range m.dirs {
+		if p != name && filepath.Dir(p) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(&memInfo{name: filepath.Base(p), mode: mode | os.ModeDir}))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return entries, nil
+}
+
+func (m *MemFS) MkdirAll(dir string, perm os.FileMode) error {
+	dir = filepath.Clean(dir)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+
+	for p := dir; ; p = filepath.Dir(p) {
+		if _, ok := m.files[p]; ok {
+			return &os.PathError{Op: "mkdir", Path: p, Err: errNotDir}
+		}
+
+		if _, ok := m.dirs[p]; ok {
+			break
+		}
+
+		m.dirs[p] = perm.Perm()
+	}
+
+	return nil
+}
+
+func (n *memNode) info(name string) *memInfo {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return &memInfo{
+		name:    filepath.Base(name),
+		size:    int64(len(n.data)),
+		mode:    n.mode,
+		modTime: n.modTime,
+	}
+}
+
+// readAt implements io.ReaderAt.  It must be called with mu held.
+func (n *memNode) readAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(n.data)) {
+		return 0, io.EOF
+	}
+
+	c := copy(p, n.data[off:])
+	if c < len(p) {
+		return c, io.EOF
+	}
+
+	return c, nil
+}
+
+// memFile is an open handle of a memNode.
+type memFile struct {
+	fs     *MemFS
+	node   *memNode
+	name   string
+	flag   int
+	offset int64
+	closed bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if err := f.check("read", os.O_WRONLY); err != nil {
+		return 0, err
+	}
+
+	f.node.mu.Lock()
+	defer f.node.mu.Unlock()
+
+	n, err := f.node.readAt(p, f.offset)
+	f.offset += int64(n)
+
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.check("read", os.O_WRONLY); err != nil {
+		return 0, err
+	}
+
+	f.node.mu.Lock()
+	defer f.node.mu.Unlock()
+
+	return f.node.readAt(p, off)
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if err := f.check("write", os.O_RDONLY); err != nil {
+		return 0, err
+	}
+
+	now := f.fs.now()
+
+	f.node.mu.Lock()
+	defer f.node.mu.Unlock()
+
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+
+	if end := f.offset + int64(len(p)); end > int64(len(f.node.data)) {
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+
+	copy(f.node.data[f.offset:], p)
+	f.offset += int64(len(p))
+	f.node.modTime = now
+
+	return len(p), nil
+}
+
+func (f *memFile) Close() error {
+	if f.closed {
+		return &os.PathError{Op: "close", Path: f.name, Err: os.ErrClosed}
+	}
+
+	f.closed = true
+
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	if f.closed {
+		return nil, &os.PathError{Op: "stat", Path: f.name, Err: os.ErrClosed}
+	}
+
+	return f.node.info(f.name), nil
+}
+
+func (f *memFile) Sync() error {
+	if f.closed {
+		return &os.PathError{Op: "sync", Path: f.name, Err: os.ErrClosed}
+	}
+
+	return nil
+}
+
+// check reports an error if the file is closed or was opened with the access
+// mode denied.
+func (f *memFile) check(op string, denied int) error {
+	switch {
+	case f.closed:
+		return &os.PathError{Op: op, Path: f.name, Err: os.ErrClosed}
+	case f.flag&(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) == denied:
+		return &os.PathError{Op: op, Path: f.name, Err: os.ErrPermission}
+	}
+
+	return nil
+}
+
+// memInfo is the os.FileInfo of a file or directory of a MemFS.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return i.size }
+func (i *memInfo) Mode() os.FileMode  { return i.mode }
+func (i *memInfo) ModTime() time.Time { return i.modTime }
+func (i *memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memInfo) Sys() any           { return nil }
+
+// Errors of a MemFS without a counterpart in package os.
+var (
+	errIsDir    = errors.New("is a directory")
+	errNotDir   = errors.New("not a directory")
+	errNotEmpty = errors.New("directory not empty")
+)
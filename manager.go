@@ -0,0 +1,190 @@
+package lumberjack
+
+import (
+	"context"
+	"sync"
+)
+
+// Manager runs the cleanup of old log files, compression included, for many
+// Loggers on a fixed number of goroutines, for services creating a Logger per
+// tenant or per job, which would otherwise each run a goroutine of their own.
+// Loggers use it if their Manager field is set:
+//
+//	m := lumberjack.NewManager(4)
+//	defer m.Shutdown(ctx)
+//
+//	l := &lumberjack.Logger{Filename: "/var/log/myapp/tenant-1.log", Manager: m}
+//
+// A Logger is cleaned up by one worker at a time, and runs requested while it
+// is waiting for a worker are merged into one.  The backups of a run are
+// still compressed by up to CompressWorkers goroutines.
+type Manager struct {
+	mu      sync.Mutex
+	work    *sync.Cond
+	queue   []*Logger
+	queued  map[*Logger]bool
+	running map[*Logger]bool
+	rerun   map[*Logger]bool
+	loggers map[*Logger]bool
+	closed  bool
+
+	stopped chan struct{}
+}
+
+// NewManager returns a Manager running the cleanup of its Loggers on the given
+// number of goroutines, at least one.
+func NewManager(workers int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+
+	m := &Manager{
+		queued:  make(map[*Logger]bool),
+		running: make(map[*Logger]bool),
+		rerun:   make(map[*Logger]bool),
+		loggers: make(map[*Logger]bool),
+		stopped: make(chan struct{}),
+	}
+	m.work = sync.NewCond(&m.mu)
+
+	var wg sync.WaitGroup
+
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			m.runWorker()
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(m.stopped)
+	}()
+
+	return m
+}
+
+// Shutdown shuts down all Loggers which opened a log file with the Manager,
+// like Logger.Shutdown, and then stops its goroutines.  It returns the first
+// error of a Logger, or ctx's error if ctx is done first.  Cleanups requested
+// afterwards are skipped.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	loggers := make([]*Logger, 0, len(m.loggers))
+
+	for l := range m.loggers {
+		loggers = append(loggers, l)
+	}
+	m.mu.Unlock()
+
+	var err error
+
+	for _, l := range loggers {
+		if errShutdown := l.Shutdown(ctx); err == nil {
+			err = errShutdown
+		}
+	}
+
+	m.mu.Lock()
+	m.closed = true
+	m.work.Broadcast()
+	m.mu.Unlock()
+
+	select {
+	case <-m.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// manage registers the Logger with its Manager, if any, so that it is shut
+// down by the Manager.  It must be called with l.mu held.
+func (l *Logger) manage() {
+	if l.Manager == nil {
+		return
+	}
+
+	l.Manager.mu.Lock()
+	l.Manager.loggers[l] = true
+	l.Manager.mu.Unlock()
+}
+
+// unmanage removes the Logger from its Manager, if any, once it is closed.  It
+// is registered again if it opens a log file afterwards.  Cleanups queued
+// already still run.
+func (l *Logger) unmanage() {
+	if l.Manager == nil {
+		return
+	}
+
+	l.Manager.mu.Lock()
+	delete(l.Manager.loggers, l)
+	l.Manager.mu.Unlock()
+}
+
+// schedule queues a run of the mill of l, unless one is queued already.  If
+// l is being cleaned up, it is queued again afterwards, which the workers do
+// even while they are stopping.
+func (m *Manager) schedule(l *Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case m.queued[l]:
+	case m.running[l]:
+		m.rerun[l] = true
+	case m.closed:
+		// The skipped run counts as finished, so that waitMill returns.
+		l.finishMillRun(l.requestedMillRuns())
+	default:
+		m.push(l)
+	}
+}
+
+// push appends l to the queue.  It must be called with m.mu held.
+func (m *Manager) push(l *Logger) {
+	m.queue = append(m.queue, l)
+	m.queued[l] = true
+	m.work.Signal()
+}
+
+// runWorker cleans up the queued Loggers until the Manager is shut down.
+func (m *Manager) runWorker() {
+	for {
+		m.mu.Lock()
+
+		for len(m.queue) == 0 && !m.closed {
+			m.work.Wait()
+		}
+
+		if len(m.queue) == 0 {
+			m.mu.Unlock()
+
+			return
+		}
+
+		l := m.queue[0]
+		m.queue[0] = nil
+		m.queue = m.queue[1:]
+
+		delete(m.queued, l)
+		m.running[l] = true
+		m.mu.Unlock()
+
+		l.runMill()
+
+		m.mu.Lock()
+		delete(m.running, l)
+
+		if m.rerun[l] {
+			delete(m.rerun, l)
+			m.push(l)
+		}
+		m.mu.Unlock()
+	}
+}
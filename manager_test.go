@@ -0,0 +1,104 @@
+package lumberjack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestManager")
+	defer os.RemoveAll(dir)
+
+	m := NewManager(2)
+
+	before := runtime.NumGoroutine()
+
+	var loggers []*Logger
+
+	for i := 0; i < 20; i++ {
+		l := &Logger{
+			Filename:   filepath.Join(dir, fmt.Sprintf("tenant-%d", i), "foobar.log"),
+			MaxBackups: 1,
+			Compress:   true,
+			Manager:    m,
+		}
+
+		loggers = append(loggers, l)
+	}
+
+	for round := 0; round < 3; round++ {
+		newFakeTime()
+
+		for _, l := range loggers {
+			_, err := l.Write([]byte("boo!"))
+			isNil(t, err)
+			isNil(t, l.Rotate())
+		}
+	}
+
+	// The Loggers don't start a goroutine each.
+	if n := runtime.NumGoroutine(); n > before+4 {
+		t.Fatalf("expected at most %d goroutines, got %d", before+4, n)
+	}
+
+	isNil(t, m.Shutdown(context.Background()))
+
+	for _, l := range loggers {
+		// The log file and one compressed backup.
+		fileCount(t, filepath.Dir(l.Filename), 2)
+		exists(t, filepath.Join(filepath.Dir(l.Filename), filepath.Base(backupFile(dir))+compressSuffix))
+
+		l.mu.Lock()
+		equals(t, nil, l.file)
+		l.mu.Unlock()
+	}
+
+	// Cleanups requested after the Shutdown are skipped.
+	l := loggers[0]
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+	isNil(t, l.RotateContext(context.Background()))
+	isNil(t, l.Close())
+}
+
+func TestManagerClose(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestManagerClose")
+	defer os.RemoveAll(dir)
+
+	m := NewManager(1)
+	defer m.Shutdown(context.Background())
+
+	l := &Logger{Filename: logFile(dir), Manager: m}
+
+	loggers := func() int {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		return len(m.loggers)
+	}
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+	equals(t, 1, loggers())
+
+	// A closed Logger is no longer kept by the Manager.
+	isNil(t, l.Close())
+	equals(t, 0, loggers())
+
+	// It is registered again once it opens a log file.
+	_, err = l.Write([]byte("foo!"))
+	isNil(t, err)
+	equals(t, 1, loggers())
+
+	isNil(t, l.Close())
+	equals(t, 0, loggers())
+}
@@ -0,0 +1,73 @@
+package lumberjack
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MultiLogger routes records to separate log files by key, such as a level
+// or a label, e.g. error.log, access.log and audit.log, which share their
+// rotation and retention settings.  Close and Rotate act on all of them.
+type MultiLogger struct {
+	keys    []string
+	loggers map[string]*Logger
+}
+
+// NewMultiLogger returns a MultiLogger writing the records of each key of
+// filenames to the file it maps to, with the settings of c.  The Filename of
+// c is ignored.  The log files are not opened until they are written to.
+func NewMultiLogger(c Config, filenames map[string]string) *MultiLogger {
+	m := &MultiLogger{loggers: make(map[string]*Logger, len(filenames))}
+
+	for key, filename := range filenames {
+		l := c.NewLogger()
+		l.Filename = filename
+
+		m.keys = append(m.keys, key)
+		m.loggers[key] = l
+	}
+
+	sort.Strings(m.keys)
+
+	return m
+}
+
+// Logger returns the Logger of key, or nil if key is unknown, for settings
+// which are not part of a Config, such as OnRotate.
+func (m *MultiLogger) Logger(key string) *Logger {
+	return m.loggers[key]
+}
+
+// Write writes p to the log file of key.
+func (m *MultiLogger) Write(key string, p []byte) (int, error) {
+	l, ok := m.loggers[key]
+	if !ok {
+		return 0, fmt.Errorf("unknown key %q", key)
+	}
+
+	return l.Write(p)
+}
+
+// Rotate rotates the log files of all keys, returning the first error.
+func (m *MultiLogger) Rotate() error {
+	return m.each((*Logger).Rotate)
+}
+
+// Close closes the log files of all keys, returning the first error.
+func (m *MultiLogger) Close() error {
+	return m.each((*Logger).Close)
+}
+
+// each calls fn for the Logger of every key in order, returning the first
+// error.
+func (m *MultiLogger) each(fn func(*Logger) error) error {
+	var err error
+
+	for _, key := range m.keys {
+		if errKey := fn(m.loggers[key]); err == nil && errKey != nil {
+			err = fmt.Errorf("%s: %w", key, errKey)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,50 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMultiLogger(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestMultiLogger")
+	defer os.RemoveAll(dir)
+
+	errorLog := filepath.Join(dir, "error.log")
+	accessLog := filepath.Join(dir, "access.log")
+
+	m := NewMultiLogger(Config{MaxBytes: 10}, map[string]string{
+		"error":  errorLog,
+		"access": accessLog,
+	})
+	defer m.Close()
+
+	_, err := m.Write("error", []byte("boo!"))
+	isNil(t, err)
+
+	_, err = m.Write("access", []byte("foo!"))
+	isNil(t, err)
+
+	existsWithContent(t, errorLog, []byte("boo!"))
+	existsWithContent(t, accessLog, []byte("foo!"))
+
+	_, err = m.Write("audit", []byte("bar!"))
+	notNil(t, err)
+	equals(t, `unknown key "audit"`, err.Error())
+
+	// The settings are shared.
+	equals(t, int64(10), m.Logger("access").MaxBytes)
+	equals(t, (*Logger)(nil), m.Logger("audit"))
+
+	// Rotate fans out to all files.
+	newFakeTime()
+	isNil(t, m.Rotate())
+
+	existsWithContent(t, filepath.Join(dir, "error-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), []byte("boo!"))
+	existsWithContent(t, filepath.Join(dir, "access-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), []byte("foo!"))
+	fileCount(t, dir, 4)
+
+	isNil(t, m.Close())
+}
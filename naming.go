@@ -0,0 +1,596 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NamingScheme selects how backup files are named.
+type NamingScheme string
+
+const (
+	// NamingTimestamp names backups after the time of their rotation, as
+	// described for Logger, or as given by BackupNameTemplate.
+	NamingTimestamp NamingScheme = "timestamp"
+
+	// NamingSequence names backups like logrotate, by appending a number to
+	// the log filename: "app.log.1" is the most recent backup, "app.log.2" the
+	// one before, and so on.  Every rotation renames the existing backups to
+	// shift their numbers up by one, waiting for a running compression to
+	// finish.  The modification time of the backups is used for MaxAge.
+	NamingSequence NamingScheme = "sequence"
+)
+
+// TimestampPrecision selects the precision of the timestamps in backup names.
+type TimestampPrecision string
+
+const (
+	// TimestampSecond formats timestamps like 2006-01-02T15-04-05.
+	TimestampSecond TimestampPrecision = "second"
+
+	// TimestampMillisecond formats timestamps like 2006-01-02T15-04-05.000.
+	TimestampMillisecond TimestampPrecision = "millisecond"
+
+	// TimestampNanosecond formats timestamps like
+	// 2006-01-02T15-04-05.000000000.
+	TimestampNanosecond TimestampPrecision = "nanosecond"
+)
+
+const (
+	backupTimeFormatSecond = "2006-01-02T15-04-05"
+	backupTimeFormatNano   = "2006-01-02T15-04-05.000000000"
+
+	// backupZoneFormat is appended to timestamps whose wall clock reading
+	// occurs twice, see backupName.
+	backupZoneFormat = "-0700"
+)
+
+// timestampFormat returns the time format of the timestamps in backup names
+// for the Logger's TimestampPrecision.
+func (l *Logger) timestampFormat() (string, error) {
+	switch l.TimestampPrecision {
+	case TimestampSecond:
+		return backupTimeFormatSecond, nil
+	case "", TimestampMillisecond:
+		return backupTimeFormat, nil
+	case TimestampNanosecond:
+		return backupTimeFormatNano, nil
+	}
+
+	return "", fmt.Errorf("unknown TimestampPrecision %q", l.TimestampPrecision)
+}
+
+// formatTimestamp formats t for a backup name with layout.  If the wall clock
+// reading of t occurs twice in its location, as in the hour repeated at the
+// end of daylight saving time, the zone offset is appended, so that the
+// timestamp can be read back as the time it was formatted from.
+func formatTimestamp(t time.Time, layout string) string {
+	if repeatedWallClock(t) {
+		layout += backupZoneFormat
+	}
+
+	return t.Format(layout)
+}
+
+// repeatedWallClock reports whether the wall clock reading of t in its
+// location occurs at another time as well, with a different zone offset.
+func repeatedWallClock(t time.Time) bool {
+	_, offset := t.Zone()
+
+	// Zone transitions are assumed to be more than 12 hours apart.
+	for _, d := range []time.Duration{-12 * time.Hour, 12 * time.Hour} {
+		_, other := t.Add(d).Zone()
+		if other == offset {
+			continue
+		}
+
+		if _, o := t.Add(time.Duration(offset-other) * time.Second).Zone(); o == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BackupNameData holds the values available to a BackupNameTemplate.
+type BackupNameData struct {
+	// Prefix is the log filename without its extension, e.g. "server" for
+	// "server.log".
+	Prefix string
+
+	// Ext is the extension of the log filename including the dot, e.g. ".log".
+	Ext string
+
+	// Timestamp is the rotation time, formatted as 2006-01-02T15-04-05.000 or
+	// with the precision selected by TimestampPrecision.  In the hour
+	// repeated at the end of daylight saving time and the one before, the
+	// zone offset is appended like 2006-01-02T01-30-00.000-0400.
+	Timestamp string
+
+	// Seq is a sequence number, one higher than the highest one found among the
+	// existing backups.
+	Seq int
+}
+
+// Placeholders substituted for Timestamp and Seq when a template is executed
+// to derive the pattern matching the names it generates.
+const (
+	timestampMark = "\x00timestamp\x00"
+	seqMark       = "\x00seq\x00"
+)
+
+// backupNamer generates the names of backup files and recognizes them again.
+type backupNamer struct {
+	l      *Logger
+	prefix string
+	ext    string
+
+	// tmpl and re are nil when the default naming is used.
+	tmpl     *template.Template
+	re       *regexp.Regexp
+	tsIndex  int
+	seqIndex int
+	dupIndex int
+
+	// shifts is set for NamingSequence, whose backups are renumbered on
+	// every rotation.
+	shifts bool
+
+	// custom is set when BackupNameFunc and ParseBackupName name backups.
+	custom bool
+}
+
+// parsedName is the information recovered from a backup filename.
+type parsedName struct {
+	timestamp time.Time
+	hasTime   bool
+	seq       int
+
+	// dup is the number appended to a name which was already taken.
+	dup int
+}
+
+// namer returns the backupNamer for the Logger's filename, NamingScheme,
+// BackupNameTemplate and BackupNameFunc, or an error if they are invalid.
+func (l *Logger) namer() (*backupNamer, error) {
+	filename := l.filename()
+	base := filepath.Base(filename)
+	ext := filepath.Ext(filename)
+
+	n := &backupNamer{l: l, prefix: base[:len(base)-len(ext)], ext: ext}
+
+	if _, err := l.timestampFormat(); err != nil {
+		return nil, err
+	}
+
+	if l.BackupNameFunc != nil || l.ParseBackupName != nil {
+		if err := n.checkCustom(); err != nil {
+			return nil, err
+		}
+
+		return n, nil
+	}
+
+	switch l.NamingScheme {
+	case "", NamingTimestamp:
+	case NamingSequence:
+		if l.BackupNameTemplate != "" {
+			return nil, errors.New("BackupNameTemplate can't be used with NamingSequence")
+		}
+
+		n.re = regexp.MustCompile(`^` + regexp.QuoteMeta(base) + `\.(?P<seq>\d+)$`)
+		n.seqIndex = n.re.SubexpIndex("seq")
+		n.shifts = true
+
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unknown NamingScheme %q", l.NamingScheme)
+	}
+
+	if l.BackupNameTemplate == "" {
+		return n, nil
+	}
+
+	tmpl, err := template.New("backup").Option("missingkey=error").Parse(l.BackupNameTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BackupNameTemplate: %s", err)
+	}
+
+	var sample strings.Builder
+
+	err = tmpl.Execute(&sample, map[string]interface{}{
+		"Prefix":    n.prefix,
+		"Ext":       n.ext,
+		"Timestamp": timestampMark,
+		"Seq":       seqMark,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid BackupNameTemplate: %s", err)
+	}
+
+	name := sample.String()
+	if err := checkTemplateOutput(name, base); err != nil {
+		return nil, fmt.Errorf("invalid BackupNameTemplate %q: %s", l.BackupNameTemplate, err)
+	}
+
+	// Turn the sample into a pattern, capturing the marked values.
+	pattern := regexp.QuoteMeta(name)
+	pattern = strings.Replace(pattern, regexp.QuoteMeta(timestampMark), `(?P<ts>.+?)`, 1)
+	pattern = strings.Replace(pattern, regexp.QuoteMeta(seqMark), `(?P<seq>\d+)`, 1)
+
+	// Allow for the number appended by dedupe.
+	dupPattern := `(?:-(?P<dup>\d+))?`
+	if quotedExt := regexp.QuoteMeta(n.ext); n.ext != "" && strings.HasSuffix(pattern, quotedExt) {
+		pattern = strings.TrimSuffix(pattern, quotedExt) + dupPattern + quotedExt
+	} else {
+		pattern += dupPattern
+	}
+
+	n.tmpl = tmpl
+	n.re = regexp.MustCompile("^" + pattern + "$")
+	n.tsIndex = n.re.SubexpIndex("ts")
+	n.seqIndex = n.re.SubexpIndex("seq")
+	n.dupIndex = n.re.SubexpIndex("dup")
+
+	return n, nil
+}
+
+// checkCustom reports an error if BackupNameFunc and ParseBackupName aren't
+// set together or are used with other naming settings.
+func (n *backupNamer) checkCustom() error {
+	l := n.l
+
+	switch {
+	case l.BackupNameFunc == nil:
+		return errors.New("ParseBackupName requires BackupNameFunc")
+	case l.ParseBackupName == nil:
+		return errors.New("BackupNameFunc requires ParseBackupName")
+	case l.NamingScheme != "" && l.NamingScheme != NamingTimestamp:
+		return fmt.Errorf("BackupNameFunc can't be used with NamingScheme %q", l.NamingScheme)
+	case l.BackupNameTemplate != "":
+		return errors.New("BackupNameFunc can't be used with BackupNameTemplate")
+	}
+
+	n.custom = true
+
+	return nil
+}
+
+// checkTemplateOutput validates the name a BackupNameTemplate generates with
+// marked Timestamp and Seq values.
+func checkTemplateOutput(name, logName string) error {
+	ts := strings.Count(name, timestampMark)
+	seq := strings.Count(name, seqMark)
+
+	switch {
+	case ts == 0 && seq == 0:
+		return errors.New("must use {{.Timestamp}} or {{.Seq}}")
+	case ts > 1 || seq > 1:
+		return errors.New("must use {{.Timestamp}} and {{.Seq}} at most once")
+	case strings.ContainsRune(name, os.PathSeparator) || strings.ContainsRune(name, '/'):
+		return errors.New("must not contain a path separator")
+	case name == logName:
+		return errors.New("must differ from the log filename")
+	}
+
+	return nil
+}
+
+// usesSeq reports whether generated names contain an increasing sequence
+// number.
+func (n *backupNamer) usesSeq() bool {
+	return n.tmpl != nil && n.seqIndex > 0
+}
+
+// format returns the base name of a backup with the given formatted timestamp
+// and sequence number.
+func (n *backupNamer) format(timestamp string, seq int) (string, error) {
+	if n.shifts {
+		return fmt.Sprintf("%s%s.%d", n.prefix, n.ext, seq), nil
+	}
+
+	if n.tmpl == nil {
+		return fmt.Sprintf("%s-%s%s", n.prefix, timestamp, n.ext), nil
+	}
+
+	var name strings.Builder
+
+	err := n.tmpl.Execute(&name, BackupNameData{
+		Prefix:    n.prefix,
+		Ext:       n.ext,
+		Timestamp: timestamp,
+		Seq:       seq,
+	})
+	if err != nil {
+		return "", fmt.Errorf("can't generate backup name: %s", err)
+	}
+
+	return name.String(), nil
+}
+
+// dedupe returns name, or if a backup of that name already exists in dir,
+// the name with the lowest free number appended like "-1", "-2", …, before the
+// extension of the log file.  This keeps a backup from being overwritten when
+// the timestamp repeats, for example after a restart within the same
+// millisecond or when the clock was set back.  A name is taken as well by a
+// backup which has been compressed or encrypted since.
+func (n *backupNamer) dedupe(dir, name string) string {
+	base, ext := name, ""
+	if n.ext != "" && strings.HasSuffix(name, n.ext) {
+		base, ext = name[:len(name)-len(n.ext)], n.ext
+	}
+
+	for i := 1; n.taken(filepath.Join(dir, name)); i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+
+	return name
+}
+
+// taken reports whether a backup exists at path, with any of the suffixes
+// added by CompressActive, compression and encryption.
+func (n *backupNamer) taken(path string) bool {
+	suffixes := []string{"", n.l.activeSuffix()}
+	for _, c := range codecs {
+		suffixes = append(suffixes, c.suffix)
+	}
+
+	for _, suffix := range suffixes {
+		for _, enc := range []string{"", encryptSuffix} {
+			if _, err := n.l.fs().Stat(path + suffix + enc); err == nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// cutDup splits the number appended by dedupe off a backup name with the
+// default or custom naming, returning the name without it.
+func (n *backupNamer) cutDup(name string) (string, int, bool) {
+	base, ext := name, ""
+	if n.ext != "" && strings.HasSuffix(name, n.ext) {
+		base, ext = name[:len(name)-len(n.ext)], n.ext
+	}
+
+	i := strings.LastIndexByte(base, '-')
+	if i < 0 {
+		return "", 0, false
+	}
+
+	dup, err := strconv.Atoi(base[i+1:])
+	if err != nil || dup < 1 {
+		return "", 0, false
+	}
+
+	return base[:i] + ext, dup, true
+}
+
+// parse recognizes the base name of an uncompressed backup.
+func (n *backupNamer) parse(name string) (parsedName, bool) {
+	if n.custom {
+		return n.parseCustom(name)
+	}
+
+	if n.re == nil {
+		t, err := n.l.timeFromName(name, n.prefix+"-", n.ext)
+		if err == nil {
+			return parsedName{timestamp: t, hasTime: true}, true
+		}
+
+		base, dup, ok := n.cutDup(name)
+		if !ok {
+			return parsedName{}, false
+		}
+
+		t, err = n.l.timeFromName(base, n.prefix+"-", n.ext)
+
+		return parsedName{timestamp: t, hasTime: true, dup: dup}, err == nil
+	}
+
+	m := n.re.FindStringSubmatch(name)
+	if m == nil {
+		return parsedName{}, false
+	}
+
+	var p parsedName
+
+	dup := ""
+	if n.dupIndex > 0 {
+		dup = m[n.dupIndex]
+	}
+
+	if n.tsIndex > 0 {
+		var (
+			t   time.Time
+			err = errors.New("no appended number")
+		)
+
+		if dup != "" {
+			// The timestamp may end in digits after a dash, which were
+			// taken for the appended number, such as the zone offset.
+			t, err = n.l.parseTimestamp(m[n.tsIndex] + "-" + dup)
+			if err == nil {
+				dup = ""
+			}
+		}
+
+		if err != nil {
+			t, err = n.l.parseTimestamp(m[n.tsIndex])
+		}
+
+		if err != nil {
+			return parsedName{}, false
+		}
+
+		p.timestamp, p.hasTime = t, true
+	}
+
+	if dup != "" {
+		d, err := strconv.Atoi(dup)
+		if err != nil {
+			return parsedName{}, false
+		}
+
+		p.dup = d
+	}
+
+	if n.seqIndex > 0 {
+		seq, err := strconv.Atoi(m[n.seqIndex])
+		if err != nil {
+			return parsedName{}, false
+		}
+
+		p.seq = seq
+	}
+
+	return p, true
+}
+
+// parseCustom recognizes a backup name generated by BackupNameFunc with
+// ParseBackupName, allowing for the number appended by dedupe.
+func (n *backupNamer) parseCustom(name string) (parsedName, bool) {
+	if name == n.prefix+n.ext {
+		return parsedName{}, false
+	}
+
+	if t, ok := n.l.ParseBackupName(name); ok {
+		return parsedName{timestamp: t, hasTime: true}, true
+	}
+
+	base, dup, ok := n.cutDup(name)
+	if !ok {
+		return parsedName{}, false
+	}
+
+	t, ok := n.l.ParseBackupName(base)
+
+	return parsedName{timestamp: t, hasTime: true, dup: dup}, ok
+}
+
+// customName returns the backup name BackupNameFunc generates for time t, or
+// an error if it can't be used.
+func (n *backupNamer) customName(t time.Time) (string, error) {
+	logName := n.prefix + n.ext
+	name := n.l.BackupNameFunc(logName, t)
+
+	switch {
+	case name == "":
+		return "", errors.New("BackupNameFunc returned an empty name")
+	case strings.ContainsRune(name, os.PathSeparator) || strings.ContainsRune(name, '/'):
+		return "", fmt.Errorf("BackupNameFunc returned %q: must not contain a path separator", name)
+	case name == logName:
+		return "", fmt.Errorf("BackupNameFunc returned %q: must differ from the log filename", name)
+	}
+
+	if _, ok := n.l.ParseBackupName(name); !ok {
+		return "", fmt.Errorf("BackupNameFunc returned %q, which ParseBackupName doesn't recognize", name)
+	}
+
+	return name, nil
+}
+
+// backupName returns the full path to move the log file to when it is
+// rotated, named after the current time.  In local time, the wall clock
+// repeats an hour at the end of daylight saving time, so the timestamps of
+// that hour and the one before carry the zone offset, which keeps the names
+// of backups ordered by their rotation time.  A name which is taken by an
+// existing backup gets a number appended, see dedupe.
+func (l *Logger) backupName() (string, error) {
+	n, err := l.namer()
+	if err != nil {
+		return "", err
+	}
+
+	layout, err := l.timestampFormat()
+	if err != nil {
+		return "", err
+	}
+
+	t := l.now().In(l.location())
+	timestamp := formatTimestamp(t, layout)
+
+	// Numbered backups have been shifted to make room for number 1.
+	seq := 1
+
+	if n.usesSeq() {
+		seq = 0
+
+		files, err := l.oldLogFiles()
+		if err != nil {
+			return "", err
+		}
+
+		for _, f := range files {
+			if f.seq > seq {
+				seq = f.seq
+			}
+		}
+
+		seq++
+	}
+
+	var backup string
+
+	if n.custom {
+		backup, err = n.customName(t)
+	} else {
+		backup, err = n.format(timestamp, seq)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if !n.shifts {
+		backup = n.dedupe(l.backupDir(), backup)
+	}
+
+	return filepath.Join(l.backupDir(), backup), nil
+}
+
+// shiftBackups renames numbered backups to one number higher, from the highest
+// number down, to make room for the next backup.  It is a no-op unless the
+// NamingSequence scheme is used.
+func (l *Logger) shiftBackups() error {
+	n, err := l.namer()
+	if err != nil || !n.shifts {
+		return err
+	}
+
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	for i := len(files) - 1; i >= 0; i-- {
+		name := files[i].Name()
+		suffix := backupSuffix(name)
+
+		shifted, err := n.format("", files[i].seq+1)
+		if err != nil {
+			return err
+		}
+
+		dir := l.backupDir()
+
+		if err := l.fs().Rename(filepath.Join(dir, name), filepath.Join(dir, shifted+suffix)); err != nil {
+			return fmt.Errorf("can't shift backup: %s", err)
+		}
+	}
+
+	l.shiftArchives(n)
+
+	return nil
+}
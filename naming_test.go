@@ -0,0 +1,518 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupNameTemplateTimestamp(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackupNameTemplateTimestamp")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:           filename,
+		MaxBackups:         1,
+		BackupNameTemplate: "{{.Prefix}}.{{.Timestamp}}{{.Ext}}",
+	}
+	defer l.Close()
+
+	backup := func() string {
+		return filepath.Join(dir, "foobar."+fakeTime().UTC().Format(backupTimeFormat)+".log")
+	}
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	first := backup()
+	existsWithContent(t, first, b)
+
+	b2 := []byte("foo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	existsWithContent(t, backup(), b2)
+	notExist(t, first)
+	fileCount(t, dir, 2)
+}
+
+func TestBackupNameTemplateSeq(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackupNameTemplateSeq")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:           filename,
+		MaxBackups:         2,
+		BackupNameTemplate: "{{.Prefix}}-{{.Seq}}{{.Ext}}",
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte{byte('a' + i)})
+		isNil(t, err)
+		isNil(t, l.Rotate())
+	}
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	notExist(t, filepath.Join(dir, "foobar-1.log"))
+	existsWithContent(t, filepath.Join(dir, "foobar-2.log"), []byte("b"))
+	existsWithContent(t, filepath.Join(dir, "foobar-3.log"), []byte("c"))
+	fileCount(t, dir, 3)
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 2, len(files))
+	equals(t, 3, files[0].seq)
+	equals(t, 2, files[1].seq)
+}
+
+func TestBackupNameTemplateInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackupNameTemplateInvalid")
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		tmpl, msg string
+	}{
+		{"{{.Prefix", `invalid BackupNameTemplate: template: backup:1: unclosed action`},
+		{"{{.Host}}-{{.Seq}}", `invalid BackupNameTemplate: template: backup:1:2: executing "backup" at <.Host>: map has no entry for key "Host"`},
+		{"{{.Prefix}}{{.Ext}}", `invalid BackupNameTemplate "{{.Prefix}}{{.Ext}}": must use {{.Timestamp}} or {{.Seq}}`},
+		{"{{.Seq}}-{{.Seq}}", `invalid BackupNameTemplate "{{.Seq}}-{{.Seq}}": must use {{.Timestamp}} and {{.Seq}} at most once`},
+		{"old/{{.Seq}}", `invalid BackupNameTemplate "old/{{.Seq}}": must not contain a path separator`},
+	}
+
+	for _, test := range tests {
+		l := &Logger{
+			Filename:           logFile(dir),
+			BackupNameTemplate: test.tmpl,
+		}
+
+		_, err := l.Write([]byte("boo!"))
+		notNil(t, err)
+		equals(t, test.msg, err.Error())
+	}
+
+	fileCount(t, dir, 0)
+}
+
+func TestBackupNameFunc(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackupNameFunc")
+	defer os.RemoveAll(dir)
+
+	const layout = "20060102T150405"
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 2,
+		BackupNameFunc: func(baseName string, t time.Time) string {
+			return "host1." + baseName + "." + t.UTC().Format(layout)
+		},
+		ParseBackupName: func(name string) (time.Time, bool) {
+			if !strings.HasPrefix(name, "host1.foobar.log.") {
+				return time.Time{}, false
+			}
+
+			t, err := time.Parse(layout, strings.TrimPrefix(name, "host1.foobar.log."))
+
+			return t, err == nil
+		},
+	}
+	defer l.Close()
+
+	backup := func() string {
+		return filepath.Join(dir, "host1.foobar.log."+fakeTime().UTC().Format(layout))
+	}
+
+	newFakeTime()
+
+	first := backup()
+
+	for _, b := range []string{"a", "b"} {
+		_, err := l.Write([]byte(b))
+		isNil(t, err)
+		isNil(t, l.Rotate())
+	}
+
+	// The second rotation within the same second gets a number appended.
+	existsWithContent(t, first, []byte("a"))
+	existsWithContent(t, first+"-1", []byte("b"))
+
+	newFakeTime()
+
+	_, err := l.Write([]byte("c"))
+	isNil(t, err)
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	notExist(t, first)
+	existsWithContent(t, first+"-1", []byte("b"))
+	existsWithContent(t, backup(), []byte("c"))
+	fileCount(t, dir, 3)
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 2, len(files))
+	equals(t, "host1.foobar.log."+fakeTime().UTC().Format(layout), files[0].Name())
+}
+
+func TestBackupNameFuncInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackupNameFuncInvalid")
+	defer os.RemoveAll(dir)
+
+	name := func(string, time.Time) string { return "old/foobar.log" }
+	parse := func(string) (time.Time, bool) { return time.Time{}, true }
+
+	tests := []struct {
+		l   *Logger
+		msg string
+	}{
+		{&Logger{BackupNameFunc: name}, "BackupNameFunc requires ParseBackupName"},
+		{&Logger{ParseBackupName: parse}, "ParseBackupName requires BackupNameFunc"},
+		{
+			&Logger{BackupNameFunc: name, ParseBackupName: parse, BackupNameTemplate: "{{.Seq}}"},
+			"BackupNameFunc can't be used with BackupNameTemplate",
+		},
+		{
+			&Logger{BackupNameFunc: name, ParseBackupName: parse, NamingScheme: NamingSequence},
+			`BackupNameFunc can't be used with NamingScheme "sequence"`,
+		},
+	}
+
+	for _, test := range tests {
+		test.l.Filename = logFile(dir)
+
+		_, err := test.l.Write([]byte("boo!"))
+		notNil(t, err)
+		equals(t, test.msg, err.Error())
+	}
+
+	fileCount(t, dir, 0)
+
+	// Generated names are checked on rotation.
+	l := &Logger{
+		Filename:        logFile(dir),
+		BackupNameFunc:  name,
+		ParseBackupName: parse,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	err = l.Rotate()
+	notNil(t, err)
+	equals(t, `BackupNameFunc returned "old/foobar.log": must not contain a path separator`, err.Error())
+}
+
+func TestNamingSequence(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestNamingSequence")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxBackups:   2,
+		NamingScheme: NamingSequence,
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte{byte('a' + i)})
+		isNil(t, err)
+		isNil(t, l.Rotate())
+
+		// we need to wait a little bit since the files get deleted on a
+		// different goroutine.
+		<-time.After(10 * time.Millisecond)
+	}
+
+	existsWithContent(t, filename+".1", []byte("c"))
+	existsWithContent(t, filename+".2", []byte("b"))
+	notExist(t, filename+".3")
+	fileCount(t, dir, 3)
+}
+
+func TestNamingSequenceCompressed(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestNamingSequenceCompressed")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		Compress:     true,
+		NamingScheme: NamingSequence,
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte{byte('a' + i)})
+		isNil(t, err)
+		isNil(t, l.Rotate())
+	}
+
+	// we need to wait a little bit since the files get compressed on a
+	// different goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	exists(t, filename+".1"+compressSuffix)
+	exists(t, filename+".2"+compressSuffix)
+	exists(t, filename+".3"+compressSuffix)
+	fileCount(t, dir, 4)
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 3, len(files))
+
+	for i, f := range files {
+		equals(t, i+1, f.seq)
+	}
+}
+
+func TestNamingSequenceInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestNamingSequenceInvalid")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		NamingScheme:       NamingSequence,
+		BackupNameTemplate: "{{.Prefix}}-{{.Seq}}{{.Ext}}",
+	}
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, "BackupNameTemplate can't be used with NamingSequence", err.Error())
+
+	l = &Logger{
+		Filename:     logFile(dir),
+		NamingScheme: "random",
+	}
+
+	_, err = l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, `unknown NamingScheme "random"`, err.Error())
+
+	fileCount(t, dir, 0)
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestTimestampPrecision")
+	defer os.RemoveAll(dir)
+
+	// A backup named with the default precision is still recognized.
+	old := backupFile(dir)
+	err := os.WriteFile(old, []byte("old!"), fileModeNew)
+	isNil(t, err)
+
+	newFakeTime()
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:           filename,
+		TimestampPrecision: TimestampSecond,
+	}
+	defer l.Close()
+
+	backup := func(offset time.Duration) string {
+		ts := fakeTime().UTC().Truncate(time.Second).Add(offset).Format(backupTimeFormatSecond)
+
+		return filepath.Join(dir, "foobar-"+ts+".log")
+	}
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(t, err)
+	isNil(t, l.Rotate())
+
+	// The clock didn't advance, so the second backup gets a number appended.
+	_, err = l.Write([]byte("foo!"))
+	isNil(t, err)
+	isNil(t, l.Rotate())
+
+	existsWithContent(t, backup(0), []byte("boo!"))
+	existsWithContent(t, strings.TrimSuffix(backup(0), ".log")+"-1.log", []byte("foo!"))
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 3, len(files))
+	equals(t, filepath.Base(old), files[2].Name())
+
+	l.TimestampPrecision = TimestampNanosecond
+
+	_, err = l.Write([]byte("bar!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	ts := fakeTime().UTC().Format(backupTimeFormatNano)
+	existsWithContent(t, filepath.Join(dir, "foobar-"+ts+".log"), []byte("bar!"))
+}
+
+func TestTimestampPrecisionInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestTimestampPrecisionInvalid")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		TimestampPrecision: "minute",
+	}
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, `unknown TimestampPrecision "minute"`, err.Error())
+	fileCount(t, dir, 0)
+}
+
+func TestBackupNameCollision(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackupNameCollision")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+
+	// Each Logger stands for a restart within the same millisecond, so the
+	// backup name is taken already.
+	rotate := func(b []byte) {
+		l := &Logger{Filename: filename, MaxBackups: 2}
+		defer l.Close()
+
+		_, err := l.Write(b)
+		isNil(t, err)
+		isNil(t, l.Rotate())
+	}
+
+	newFakeTime()
+	rotate([]byte("foo!"))
+	rotate([]byte("bar!"))
+	rotate([]byte("baz!"))
+
+	backup := backupFile(dir)
+	dup := func(i int) string {
+		return strings.TrimSuffix(backup, ".log") + "-" + strconv.Itoa(i) + ".log"
+	}
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	// The oldest backup was removed by MaxBackups.
+	notExist(t, backup)
+	existsWithContent(t, dup(1), []byte("bar!"))
+	existsWithContent(t, dup(2), []byte("baz!"))
+
+	l := &Logger{Filename: filename}
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 2, len(files))
+	equals(t, filepath.Base(dup(2)), files[0].Name())
+	equals(t, filepath.Base(dup(1)), files[1].Name())
+}
+
+func TestBackupNameCollisionCompressed(t *testing.T) {
+	currentTime = time.Now
+
+	dir := makeTempDir(t, "TestBackupNameCollisionCompressed")
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+		Clock:    func() time.Time { return now },
+	}
+	defer l.Close()
+
+	// The first backup is compressed before the second rotation, so its name
+	// is taken by the compressed file only.
+	_, err := l.Write([]byte("foo!"))
+	isNil(t, err)
+	isNil(t, l.Rotate())
+	isNil(t, l.waitMill(context.Background()))
+
+	_, err = l.Write([]byte("bar!"))
+	isNil(t, err)
+	isNil(t, l.Rotate())
+	isNil(t, l.waitMill(context.Background()))
+
+	backup := filepath.Join(dir, "foobar-2024-01-01T00-00-00.000")
+	existsWithGzipContent(t, backup+".log"+compressSuffix, []byte("foo!"), false)
+	existsWithGzipContent(t, backup+"-1.log"+compressSuffix, []byte("bar!"), false)
+}
+
+func TestBackupNameCollisionTemplate(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestBackupNameCollisionTemplate")
+	defer os.RemoveAll(dir)
+
+	newFakeTime()
+
+	ts := fakeTime().UTC().Format(backupTimeFormatSecond)
+	taken := filepath.Join(dir, "foobar."+ts+".log")
+	err := os.WriteFile(taken, []byte("old!"), fileModeNew)
+	isNil(t, err)
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		BackupNameTemplate: "{{.Prefix}}.{{.Timestamp}}{{.Ext}}",
+		TimestampPrecision: TimestampSecond,
+	}
+	defer l.Close()
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(t, err)
+	isNil(t, l.Rotate())
+
+	existsWithContent(t, taken, []byte("old!"))
+	existsWithContent(t, filepath.Join(dir, "foobar."+ts+"-1.log"), []byte("boo!"))
+
+	// Neither the dash nor the seconds of the timestamp are taken for an
+	// appended number.
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 2, len(files))
+	equals(t, 1, files[0].dup)
+	equals(t, 0, files[1].dup)
+	equals(t, files[0].timestamp, files[1].timestamp)
+	equals(t, fakeTime().UTC().Truncate(time.Second), files[1].timestamp)
+}
@@ -0,0 +1,44 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+)
+
+// OpenMode selects what happens to the contents of an existing log file when
+// the Logger first opens it.
+type OpenMode string
+
+const (
+	// OpenAppend appends to the existing log file.
+	OpenAppend OpenMode = "append"
+
+	// OpenTruncate discards the contents of the existing log file, so that
+	// every run of the program starts with an empty one.  Backups are only
+	// made by rotations.
+	OpenTruncate OpenMode = "truncate"
+)
+
+// checkOpenMode reports an error if OpenMode is unknown or used with
+// incompatible settings.
+func (l *Logger) checkOpenMode() error {
+	switch l.OpenMode {
+	case "", OpenAppend:
+		return nil
+	case OpenTruncate:
+		if l.LockMode == LockShared {
+			// Other processes may be writing to the file.
+			return errors.New("OpenTruncate can't be used with LockShared")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown OpenMode %q", l.OpenMode)
+}
+
+// truncatesOnOpen reports if the existing log file is truncated when it is
+// opened.  It must be called with l.mu held.
+func (l *Logger) truncatesOnOpen() bool {
+	return l.OpenMode == OpenTruncate && !l.started
+}
@@ -0,0 +1,392 @@
+package lumberjack
+
+import (
+	"io"
+	"time"
+)
+
+// Option configures a Logger created by New.
+type Option func(*Logger)
+
+// New returns a Logger writing to filename, configured by opts.  Unlike a
+// Logger built as a struct literal, whose settings are only checked on the
+// first Write, the settings are validated up front and an error is returned
+// if any of them is invalid.  The log file is not opened until the first
+// Write.
+func New(filename string, opts ...Option) (*Logger, error) {
+	l := &Logger{Filename: filename}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// WithFilenameDateLayout sets FilenameDateLayout.
+func WithFilenameDateLayout(layout string) Option {
+	return func(l *Logger) { l.FilenameDateLayout = layout }
+}
+
+// WithMaxBytes sets MaxBytes.
+func WithMaxBytes(n int64) Option {
+	return func(l *Logger) { l.MaxBytes = n }
+}
+
+// WithMaxBackups sets MaxBackups.
+func WithMaxBackups(n int) Option {
+	return func(l *Logger) { l.MaxBackups = n }
+}
+
+// WithMaxUncompressedBackups sets MaxUncompressedBackups.
+func WithMaxUncompressedBackups(n int) Option {
+	return func(l *Logger) { l.MaxUncompressedBackups = n }
+}
+
+// WithMaxCompressedBackups sets MaxCompressedBackups.
+func WithMaxCompressedBackups(n int) Option {
+	return func(l *Logger) { l.MaxCompressedBackups = n }
+}
+
+// WithMaxAge sets MaxAge, in days.
+func WithMaxAge(days int) Option {
+	return func(l *Logger) { l.MaxAge = days }
+}
+
+// WithMaxTotalBytes sets MaxTotalBytes.
+func WithMaxTotalBytes(n int64) Option {
+	return func(l *Logger) { l.MaxTotalBytes = n }
+}
+
+// WithMinFreeBytes sets MinFreeBytes.
+func WithMinFreeBytes(n int64) Option {
+	return func(l *Logger) { l.MinFreeBytes = n }
+}
+
+// WithMinFreePercent sets MinFreePercent.
+func WithMinFreePercent(percent int) Option {
+	return func(l *Logger) { l.MinFreePercent = percent }
+}
+
+// WithDiskCheckInterval sets DiskCheckInterval.
+func WithDiskCheckInterval(d time.Duration) Option {
+	return func(l *Logger) { l.DiskCheckInterval = d }
+}
+
+// WithCompressOnLowDisk sets CompressOnLowDisk.
+func WithCompressOnLowDisk(enabled bool) Option {
+	return func(l *Logger) { l.CompressOnLowDisk = enabled }
+}
+
+// WithJanitorInterval sets JanitorInterval.
+func WithJanitorInterval(d time.Duration) Option {
+	return func(l *Logger) { l.JanitorInterval = d }
+}
+
+// WithCompress enables compression of backups.
+func WithCompress() Option {
+	return func(l *Logger) { l.Compress = true }
+}
+
+// WithCompressionFormat enables compression of backups in the given format.
+func WithCompressionFormat(format CompressionFormat) Option {
+	return func(l *Logger) {
+		l.Compress = true
+		l.CompressionFormat = format
+	}
+}
+
+// WithCompressConcurrency sets CompressConcurrency.
+func WithCompressConcurrency(n int) Option {
+	return func(l *Logger) { l.CompressConcurrency = n }
+}
+
+// WithCompressWorkers sets CompressWorkers.
+func WithCompressWorkers(n int) Option {
+	return func(l *Logger) { l.CompressWorkers = n }
+}
+
+// WithCompressBufferSize sets CompressBufferSize.
+func WithCompressBufferSize(size int) Option {
+	return func(l *Logger) { l.CompressBufferSize = size }
+}
+
+// WithCompressActive sets CompressActive.
+func WithCompressActive(enabled bool) Option {
+	return func(l *Logger) { l.CompressActive = enabled }
+}
+
+// WithCompressAfter sets CompressAfter.
+func WithCompressAfter(d time.Duration) Option {
+	return func(l *Logger) { l.CompressAfter = d }
+}
+
+// WithEncryptKey sets EncryptKey.
+func WithEncryptKey(key []byte) Option {
+	return func(l *Logger) { l.EncryptKey = key }
+}
+
+// WithLocalTime sets LocalTime.
+func WithLocalTime() Option {
+	return func(l *Logger) { l.LocalTime = true }
+}
+
+// WithLocation sets Location.
+func WithLocation(loc *time.Location) Option {
+	return func(l *Logger) { l.Location = loc }
+}
+
+// WithRotationInterval sets RotationInterval.
+func WithRotationInterval(d time.Duration) Option {
+	return func(l *Logger) { l.RotationInterval = d }
+}
+
+// WithRotateAt sets RotateAt.
+func WithRotateAt(clock string) Option {
+	return func(l *Logger) { l.RotateAt = clock }
+}
+
+// WithRotateOnStart sets RotateOnStart.
+func WithRotateOnStart(enabled bool) Option {
+	return func(l *Logger) { l.RotateOnStart = enabled }
+}
+
+// WithOpenMode sets OpenMode.
+func WithOpenMode(mode OpenMode) Option {
+	return func(l *Logger) { l.OpenMode = mode }
+}
+
+// WithBackupDir sets BackupDir.
+func WithBackupDir(dir string) Option {
+	return func(l *Logger) { l.BackupDir = dir }
+}
+
+// WithNamingScheme sets NamingScheme.
+func WithNamingScheme(scheme NamingScheme) Option {
+	return func(l *Logger) { l.NamingScheme = scheme }
+}
+
+// WithBackupNameTemplate sets BackupNameTemplate.
+func WithBackupNameTemplate(tmpl string) Option {
+	return func(l *Logger) { l.BackupNameTemplate = tmpl }
+}
+
+// WithBackupNameFunc sets BackupNameFunc and ParseBackupName.
+func WithBackupNameFunc(name func(baseName string, t time.Time) string, parse func(name string) (time.Time, bool)) Option {
+	return func(l *Logger) {
+		l.BackupNameFunc = name
+		l.ParseBackupName = parse
+	}
+}
+
+// WithTimestampPrecision sets TimestampPrecision.
+func WithTimestampPrecision(precision TimestampPrecision) Option {
+	return func(l *Logger) { l.TimestampPrecision = precision }
+}
+
+// WithBootFilename sets BootFilename.
+func WithBootFilename(filename string) Option {
+	return func(l *Logger) { l.BootFilename = filename }
+}
+
+// WithMirror sets Mirror.
+func WithMirror(w io.Writer) Option {
+	return func(l *Logger) { l.Mirror = w }
+}
+
+// WithWriteRetries sets WriteRetries and WriteRetryDelay.
+func WithWriteRetries(n int, delay time.Duration) Option {
+	return func(l *Logger) {
+		l.WriteRetries = n
+		l.WriteRetryDelay = delay
+	}
+}
+
+// WithFallback sets Fallback and FallbackRetryInterval.
+func WithFallback(w io.Writer, retryInterval time.Duration) Option {
+	return func(l *Logger) {
+		l.Fallback = w
+		l.FallbackRetryInterval = retryInterval
+	}
+}
+
+// WithOnRecover sets OnRecover.
+func WithOnRecover(fn func(downtime time.Duration)) Option {
+	return func(l *Logger) { l.OnRecover = fn }
+}
+
+// WithBuffer sets BufferSize and FlushInterval.
+func WithBuffer(size int, flushInterval time.Duration) Option {
+	return func(l *Logger) {
+		l.BufferSize = size
+		l.FlushInterval = flushInterval
+	}
+}
+
+// WithAsyncBuffer sets AsyncBufferSize.
+func WithAsyncBuffer(size int) Option {
+	return func(l *Logger) { l.AsyncBufferSize = size }
+}
+
+// WithPreserveOwner sets PreserveOwner.
+func WithPreserveOwner(preserve bool) Option {
+	return func(l *Logger) { l.PreserveOwner = &preserve }
+}
+
+// WithSyncInterval sets SyncInterval.
+func WithSyncInterval(d time.Duration) Option {
+	return func(l *Logger) { l.SyncInterval = d }
+}
+
+// WithSyncEveryWrite sets SyncEveryWrite.
+func WithSyncEveryWrite(sync bool) Option {
+	return func(l *Logger) { l.SyncEveryWrite = sync }
+}
+
+// WithHeader sets Header.
+func WithHeader(fn func() []byte) Option {
+	return func(l *Logger) { l.Header = fn }
+}
+
+// WithFooter sets Footer.
+func WithFooter(fn func() []byte) Option {
+	return func(l *Logger) { l.Footer = fn }
+}
+
+// WithOnRotate sets OnRotate.
+func WithOnRotate(fn func(oldPath, newPath string, reason RotationReason)) Option {
+	return func(l *Logger) { l.OnRotate = fn }
+}
+
+// WithPreallocate sets Preallocate.
+func WithPreallocate(enabled bool) Option {
+	return func(l *Logger) { l.Preallocate = enabled }
+}
+
+// WithMaxRecordBytes sets MaxRecordBytes and MaxRecordPolicy.
+func WithMaxRecordBytes(n int, policy MaxRecordPolicy) Option {
+	return func(l *Logger) {
+		l.MaxRecordBytes = n
+		l.MaxRecordPolicy = policy
+	}
+}
+
+// WithLargeWritePolicy sets LargeWritePolicy.
+func WithLargeWritePolicy(policy LargeWritePolicy) Option {
+	return func(l *Logger) { l.LargeWritePolicy = policy }
+}
+
+// WithAppendNewline sets AppendNewline.
+func WithAppendNewline(enabled bool) Option {
+	return func(l *Logger) { l.AppendNewline = enabled }
+}
+
+// WithStripANSI sets StripANSI.
+func WithStripANSI(enabled bool) Option {
+	return func(l *Logger) { l.StripANSI = enabled }
+}
+
+// WithRateLimit sets MaxBytesPerSecond and RateLimitPolicy.
+func WithRateLimit(bytesPerSecond int64, policy RateLimitPolicy) Option {
+	return func(l *Logger) {
+		l.MaxBytesPerSecond = bytesPerSecond
+		l.RateLimitPolicy = policy
+	}
+}
+
+// WithLockMode sets LockMode.
+func WithLockMode(mode LockMode) Option {
+	return func(l *Logger) { l.LockMode = mode }
+}
+
+// WithSymlinkName sets SymlinkName.
+func WithSymlinkName(name string) Option {
+	return func(l *Logger) { l.SymlinkName = name }
+}
+
+// WithFollowName sets FollowName.
+func WithFollowName(follow bool) Option {
+	return func(l *Logger) { l.FollowName = follow }
+}
+
+// WithPrevSymlink sets PrevSymlink.
+func WithPrevSymlink(enabled bool) Option {
+	return func(l *Logger) { l.PrevSymlink = enabled }
+}
+
+// WithPostRotateCommand sets PostRotateCommand and PostRotateTimeout.
+func WithPostRotateCommand(timeout time.Duration, command ...string) Option {
+	return func(l *Logger) {
+		l.PostRotateCommand = command
+		l.PostRotateTimeout = timeout
+	}
+}
+
+// WithOnError sets OnError.
+func WithOnError(fn func(err error)) Option {
+	return func(l *Logger) { l.OnError = fn }
+}
+
+// WithInstrumentation sets Instrumentation.
+func WithInstrumentation(i Instrumentation) Option {
+	return func(l *Logger) { l.Instrumentation = i }
+}
+
+// WithDiag sets Diag.
+func WithDiag(d DiagLogger) Option {
+	return func(l *Logger) { l.Diag = d }
+}
+
+// WithArchive sets Archive.
+func WithArchive(fn func(path string) error) Option {
+	return func(l *Logger) { l.Archive = fn }
+}
+
+// WithArchiveErrorPolicy sets ArchiveErrorPolicy.
+func WithArchiveErrorPolicy(policy ArchiveErrorPolicy) Option {
+	return func(l *Logger) { l.ArchiveErrorPolicy = policy }
+}
+
+// WithManager sets Manager.
+func WithManager(m *Manager) Option {
+	return func(l *Logger) { l.Manager = m }
+}
+
+// WithFS sets FS.
+func WithFS(fs FS) Option {
+	return func(l *Logger) { l.FS = fs }
+}
+
+// WithRewrite sets Rewrite.
+func WithRewrite(rewrite func(record []byte) []byte) Option {
+	return func(l *Logger) { l.Rewrite = rewrite }
+}
+
+// WithFilter sets Filter.
+func WithFilter(filter func(record []byte) bool) Option {
+	return func(l *Logger) { l.Filter = filter }
+}
+
+// WithTransformers sets Transformers.
+func WithTransformers(transformers ...func(io.Writer) io.Writer) Option {
+	return func(l *Logger) { l.Transformers = transformers }
+}
+
+// WithClock sets Clock.
+func WithClock(clock func() time.Time) Option {
+	return func(l *Logger) { l.Clock = clock }
+}
+
+// validate checks all settings of the Logger like Validate, except for the
+// directories, and returns the first invalid one.
+func (l *Logger) validate() error {
+	if errs := l.configErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
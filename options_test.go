@@ -0,0 +1,64 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestNew")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+
+	l, err := New(filename,
+		WithMaxBytes(10),
+		WithMaxBackups(1),
+		WithMaxAge(7),
+		WithCompressionFormat(CompressionZstd),
+		WithRotationInterval(time.Hour),
+	)
+	isNil(t, err)
+	defer l.Close()
+
+	equals(t, filename, l.Filename)
+	equals(t, int64(10), l.MaxBytes)
+	equals(t, 1, l.MaxBackups)
+	equals(t, 7, l.MaxAge)
+	equals(t, true, l.Compress)
+	equals(t, CompressionZstd, l.CompressionFormat)
+	equals(t, time.Hour, l.RotationInterval)
+
+	// Nothing is created before the first write.
+	fileCount(t, dir, 0)
+
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	isNil(t, err)
+	existsWithContent(t, filename, b)
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := map[string][]Option{
+		"invalid MaxBackups: must not be negative":    {WithMaxBackups(-1)},
+		"invalid MaxBytes: must not be negative":      {WithMaxBytes(-1)},
+		"invalid FlushInterval: must not be negative": {WithBuffer(1024, -time.Second)},
+		`unknown CompressionFormat "lz4"`:             {WithCompressionFormat("lz4")},
+		`invalid RotateAt "noon": must be HH:MM`:      {WithRotateAt("noon")},
+		`unknown NamingScheme "random"`:               {WithNamingScheme("random")},
+		"BackupNameTemplate can't be used with NamingSequence": {
+			WithNamingScheme(NamingSequence),
+			WithBackupNameTemplate("{{.Prefix}}-{{.Seq}}{{.Ext}}"),
+		},
+	}
+
+	for want, opts := range tests {
+		l, err := New("foo.log", opts...)
+		equals(t, (*Logger)(nil), l)
+		notNil(t, err)
+		equals(t, want, err.Error())
+	}
+}
@@ -0,0 +1,107 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// defaultCompressBufferSize is the size of the chunks in which backups are
+// fed to the compressor unless CompressBufferSize is set.
+const defaultCompressBufferSize = 32 << 10
+
+// copyBuffers pools the buffers used to copy backups to the compressor, so
+// that compressing many backups doesn't allocate a buffer for each.
+var copyBuffers sync.Pool
+
+// getCopyBuffer returns a buffer of size bytes from copyBuffers.
+func getCopyBuffer(size int) *[]byte {
+	if b, ok := copyBuffers.Get().(*[]byte); ok && len(*b) == size {
+		return b
+	}
+
+	b := make([]byte, size)
+
+	return &b
+}
+
+// compressBufferSize returns the size of the chunks in which backups are fed
+// to the compressor.
+func (l *Logger) compressBufferSize() int {
+	if l.CompressBufferSize > 0 {
+		return l.CompressBufferSize
+	}
+
+	return defaultCompressBufferSize
+}
+
+// gzipWriters and zstdEncoders pool the compressors, whose state is much
+// larger than the copy buffers.
+var (
+	gzipWriters  sync.Pool
+	zstdEncoders sync.Pool
+)
+
+// pooledGzipWriter returns its gzip.Writer to gzipWriters when it is closed.
+type pooledGzipWriter struct {
+	*gzip.Writer
+}
+
+func newGzipWriter(w io.Writer) (io.WriteCloser, error) {
+	gz, ok := gzipWriters.Get().(*gzip.Writer)
+	if ok {
+		gz.Reset(w)
+	} else {
+		gz = gzip.NewWriter(w)
+	}
+
+	return &pooledGzipWriter{gz}, nil
+}
+
+func (p *pooledGzipWriter) Close() error {
+	if p.Writer == nil {
+		return nil
+	}
+
+	err := p.Writer.Close()
+	gzipWriters.Put(p.Writer)
+	p.Writer = nil
+
+	return err
+}
+
+// pooledZstdEncoder returns its zstd.Encoder to zstdEncoders when it is
+// closed.
+type pooledZstdEncoder struct {
+	*zstd.Encoder
+}
+
+func newZstdEncoder(w io.Writer) (io.WriteCloser, error) {
+	enc, ok := zstdEncoders.Get().(*zstd.Encoder)
+	if ok {
+		enc.Reset(w)
+
+		return &pooledZstdEncoder{enc}, nil
+	}
+
+	enc, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pooledZstdEncoder{enc}, nil
+}
+
+func (p *pooledZstdEncoder) Close() error {
+	if p.Encoder == nil {
+		return nil
+	}
+
+	err := p.Encoder.Close()
+	zstdEncoders.Put(p.Encoder)
+	p.Encoder = nil
+
+	return err
+}
@@ -0,0 +1,61 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultPostRotateTimeout is used when PostRotateCommand is set without a
+// PostRotateTimeout.
+const defaultPostRotateTimeout = time.Minute
+
+// maxCommandStderr is the number of bytes of a failed command's standard
+// error included in the reported error.
+const maxCommandStderr = 1024
+
+// runPostRotateCommand runs PostRotateCommand with the path of the new backup
+// as its last argument.  It returns an error including the command's standard
+// error if the command fails or times out.
+func (l *Logger) runPostRotateCommand(backup string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.postRotateTimeout())
+	defer cancel()
+
+	var stderr bytes.Buffer
+
+	args := append(append([]string(nil), l.PostRotateCommand[1:]...), backup)
+	cmd := exec.CommandContext(ctx, l.PostRotateCommand[0], args...)
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		err = fmt.Errorf("timed out after %s", l.postRotateTimeout())
+	}
+
+	msg := strings.TrimSpace(stderr.String())
+	if len(msg) > maxCommandStderr {
+		msg = msg[:maxCommandStderr] + "..."
+	}
+
+	if msg == "" {
+		return fmt.Errorf("post-rotate command %s: %v", l.PostRotateCommand[0], err)
+	}
+
+	return fmt.Errorf("post-rotate command %s: %v: %s", l.PostRotateCommand[0], err, msg)
+}
+
+// postRotateTimeout returns PostRotateTimeout, or its default.
+func (l *Logger) postRotateTimeout() time.Duration {
+	if l.PostRotateTimeout > 0 {
+		return l.PostRotateTimeout
+	}
+
+	return defaultPostRotateTimeout
+}
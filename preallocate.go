@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package lumberjack
+
+import (
+	"os"
+)
+
+func preallocate(_ *os.File, _ int64) error {
+	return nil
+}
+
+func releasePreallocated(_ *os.File, _ int64) error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// fallocKeepSize is the flag of fallocate(2) which keeps the file size
+// unchanged.  It is missing from the syscall package.
+const fallocKeepSize = 0x1
+
+// preallocate reserves disk space for the first size bytes of f without
+// changing its size.  Filesystems which don't support this are ignored.
+func preallocate(f *os.File, size int64) error {
+	err := syscall.Fallocate(int(f.Fd()), fallocKeepSize, 0, size)
+	if errors.Is(err, syscall.EOPNOTSUPP) {
+		return nil
+	}
+
+	return err
+}
+
+// releasePreallocated frees the disk space reserved by preallocate beyond the
+// end of f, by truncating it to its current size.
+func releasePreallocated(f *os.File, reserved int64) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() >= reserved {
+		return err
+	}
+
+	return f.Truncate(info.Size())
+}
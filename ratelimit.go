@@ -0,0 +1,96 @@
+package lumberjack
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy selects what Write does with a record exceeding
+// MaxBytesPerSecond.
+type RateLimitPolicy string
+
+const (
+	// RateLimitBlock delays the record until it fits into the rate.
+	RateLimitBlock RateLimitPolicy = "block"
+
+	// RateLimitDrop discards the record, reporting it as written.  Dropped
+	// records are counted in Stats.
+	RateLimitDrop RateLimitPolicy = "drop"
+)
+
+// checkRateLimitPolicy reports an error if RateLimitPolicy is unknown.
+func (l *Logger) checkRateLimitPolicy() error {
+	switch l.RateLimitPolicy {
+	case "", RateLimitBlock, RateLimitDrop:
+		return nil
+	}
+
+	return fmt.Errorf("unknown RateLimitPolicy %q", l.RateLimitPolicy)
+}
+
+// limitRate applies MaxBytesPerSecond to a record of n bytes, waiting until it
+// may be written.  It returns false if the record is to be dropped.  It must
+// not be called with l.mu held, so that Close isn't held up by the wait.
+func (l *Logger) limitRate(n int) bool {
+	if l.MaxBytesPerSecond <= 0 {
+		return true
+	}
+
+	wait, ok := l.limiter.take(n, float64(l.MaxBytesPerSecond), l.RateLimitPolicy == RateLimitDrop)
+	if !ok {
+		l.recordDrop(n)
+
+		return false
+	}
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+
+	return true
+}
+
+// rateLimiter is a token bucket holding up to one second's worth of bytes, so
+// that short bursts are written without delay.
+type rateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// take removes n bytes from the bucket, which is refilled with rate bytes per
+// second.  If it holds fewer, take returns false if drop is set, and otherwise
+// removes them in advance and returns how long to wait until they have been
+// refilled.
+func (r *rateLimiter) take(n int, rate float64, drop bool) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	if r.last.IsZero() {
+		r.tokens = rate
+	} else {
+		r.tokens += now.Sub(r.last).Seconds() * rate
+		if r.tokens > rate {
+			r.tokens = rate
+		}
+	}
+
+	r.last = now
+
+	if r.tokens >= float64(n) {
+		r.tokens -= float64(n)
+
+		return 0, true
+	}
+
+	if drop {
+		return 0, false
+	}
+
+	r.tokens -= float64(n)
+
+	return time.Duration(-r.tokens / rate * float64(time.Second)), true
+}
@@ -0,0 +1,96 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRateLimitDrop(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRateLimitDrop")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:          filename,
+		MaxBytesPerSecond: 10,
+		RateLimitPolicy:   RateLimitDrop,
+	}
+	defer l.Close()
+
+	// The first second's worth of bytes is written right away.
+	n, err := l.Write([]byte("foo!\n"))
+	isNil(t, err)
+	equals(t, 5, n)
+
+	n, err = l.Write([]byte("bar!\n"))
+	isNil(t, err)
+	equals(t, 5, n)
+
+	// The record is reported as written, but dropped.
+	n, err = l.Write([]byte("baz!\n"))
+	isNil(t, err)
+	equals(t, 5, n)
+
+	existsWithContent(t, filename, []byte("foo!\nbar!\n"))
+
+	s := l.Stats()
+	equals(t, int64(2), s.Writes)
+	equals(t, int64(1), s.DroppedWrites)
+	equals(t, int64(5), s.DroppedBytes)
+
+	// The budget is refilled over time.
+	<-time.After(600 * time.Millisecond)
+
+	_, err = l.Write([]byte("qux!\n"))
+	isNil(t, err)
+	existsWithContent(t, filename, []byte("foo!\nbar!\nqux!\n"))
+}
+
+func TestRateLimitBlock(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRateLimitBlock")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:          filename,
+		MaxBytesPerSecond: 20,
+	}
+	defer l.Close()
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte("boo!\n\n\n\n\n\n"))
+		isNil(t, err)
+	}
+
+	// The third record has to wait for half a second's worth of bytes.
+	elapsed := time.Since(start)
+	equals(t, true, elapsed >= 400*time.Millisecond)
+
+	s := l.Stats()
+	equals(t, int64(3), s.Writes)
+	equals(t, int64(0), s.DroppedWrites)
+}
+
+func TestRateLimitPolicyInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRateLimitPolicyInvalid")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		RateLimitPolicy: "wait",
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+	equals(t, `unknown RateLimitPolicy "wait"`, err.Error())
+}
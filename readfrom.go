@@ -0,0 +1,138 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// readFromBufferSize is the size of the chunks ReadFrom reads.
+const readFromBufferSize = 32 * 1024
+
+// LargeWritePolicy selects how Write handles a record larger than MaxBytes.
+type LargeWritePolicy string
+
+const (
+	// LargeWriteError rejects the record with an error.
+	LargeWriteError LargeWritePolicy = "error"
+
+	// LargeWriteSplit spreads the record across as many log files as needed,
+	// rotating them like ReadFrom does, after newlines where possible.
+	LargeWriteSplit LargeWritePolicy = "split"
+
+	// LargeWriteAllow writes the record as a whole to a log file of its own,
+	// which is larger than MaxBytes.  The log file is rotated before the
+	// record unless it is empty, and after it.
+	LargeWriteAllow LargeWritePolicy = "allow"
+)
+
+// checkLargeWritePolicy reports an error if LargeWritePolicy is unknown.
+func (l *Logger) checkLargeWritePolicy() error {
+	switch l.LargeWritePolicy {
+	case "", LargeWriteError, LargeWriteSplit, LargeWriteAllow:
+		return nil
+	}
+
+	return fmt.Errorf("unknown LargeWritePolicy %q", l.LargeWritePolicy)
+}
+
+// ReadFrom implements io.ReaderFrom, so that io.Copy streams data such as the
+// output of a subprocess into the Logger.  Unlike Write, ReadFrom splits the
+// data to rotate the log file whenever it would grow larger than MaxBytes.
+// Where possible, the data is split after a newline, so that lines are not
+// spread across two files.  Other writes may be interleaved between the
+// chunks.  ReadFrom returns the number of bytes written and the first error
+// reading or writing, except io.EOF.
+func (l *Logger) ReadFrom(r io.Reader) (n int64, err error) {
+	if l.reentered(0) {
+		return 0, errRecursiveWrite
+	}
+
+	buf := make([]byte, readFromBufferSize)
+
+	for {
+		m, errRead := r.Read(buf)
+
+		if m > 0 {
+			written, errWrite := l.writeChunks(buf[:m])
+			n += int64(written)
+
+			if errWrite != nil {
+				return n, errWrite
+			}
+		}
+
+		if errRead == io.EOF {
+			return n, nil
+		}
+
+		if errRead != nil {
+			return n, errRead
+		}
+	}
+}
+
+// writeChunks writes p in chunks fitting into the log files.
+func (l *Logger) writeChunks(p []byte) (n int, err error) {
+	if !l.limitRate(len(p)) {
+		return len(p), nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.writeSplit(p)
+}
+
+// writeSplit writes p in chunks fitting into the log files.  It must be called
+// with l.mu held.
+func (l *Logger) writeSplit(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		m, err := l.write(l.nextChunk(p))
+		n += m
+
+		if err != nil {
+			return n, err
+		}
+
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+// nextChunk returns the leading part of p to write next.  It fills the active
+// file up to MaxBytes, up to the last newline if there is one.  If the rest of
+// the file can't take a whole line, it returns a chunk which doesn't fit so
+// that the file is rotated first.  It must be called with l.mu held.
+func (l *Logger) nextChunk(p []byte) []byte {
+	max := l.max()
+
+	space := max - l.size
+	if l.file == nil || space <= 0 {
+		space = max
+	}
+
+	if int64(len(p)) <= space {
+		return p
+	}
+
+	if i := bytes.LastIndexByte(p[:space], '\n'); i >= 0 {
+		return p[:i+1]
+	}
+
+	if l.file == nil || l.size == 0 || space == max {
+		return p[:space]
+	}
+
+	// Start over in a new file, which may hold a complete line.
+	if int64(len(p)) > max {
+		p = p[:max]
+	}
+
+	if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+		return p[:i+1]
+	}
+
+	return p
+}
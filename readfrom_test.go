@@ -0,0 +1,128 @@
+package lumberjack
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadFrom(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestReadFrom")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxBytes: 10,
+	}
+	defer l.Close()
+
+	data := "aaa\nbbb\nccc\ndddddddddddd\n"
+
+	// io.Copy uses ReadFrom unless the reader implements io.WriterTo, which
+	// pipes don't.
+	n, err := io.Copy(l, struct{ io.Reader }{strings.NewReader(data)})
+	isNil(t, err)
+	equals(t, int64(len(data)), n)
+
+	// Lines are kept together unless they don't fit in a file.
+	existsWithContent(t, filename, []byte("dd\n"))
+
+	files, err := l.oldLogFiles()
+	isNil(t, err)
+	equals(t, 3, len(files))
+
+	want := []string{"dddddddddd", "ccc\n", "aaa\nbbb\n"}
+	for i, f := range files {
+		existsWithContent(t, filepath.Join(dir, f.Name()), []byte(want[i]))
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, os.ErrClosed
+}
+
+func TestReadFromError(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestReadFromError")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	n, err := l.ReadFrom(io.MultiReader(strings.NewReader("boo!"), errReader{}))
+	equals(t, os.ErrClosed, err)
+	equals(t, int64(4), n)
+	existsWithContent(t, filename, []byte("boo!"))
+}
+
+func TestLargeWriteSplit(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestLargeWriteSplit")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		MaxBytes:         10,
+		LargeWritePolicy: LargeWriteSplit,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("foo\n"))
+	isNil(t, err)
+
+	n, err := l.Write([]byte("aaaa\nbbbb\ncccc\n"))
+	isNil(t, err)
+	equals(t, 15, n)
+
+	existsWithContent(t, backupFile(dir), []byte("foo\naaaa\n"))
+	existsWithContent(t, filename, []byte("bbbb\ncccc\n"))
+	fileCount(t, dir, 2)
+}
+
+func TestLargeWriteAllow(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestLargeWriteAllow")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		MaxBytes:         10,
+		LargeWritePolicy: LargeWriteAllow,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("foo\n"))
+	isNil(t, err)
+
+	// The record lands in a file of its own.
+	n, err := l.Write([]byte("0123456789abcde"))
+	isNil(t, err)
+	equals(t, 15, n)
+
+	existsWithContent(t, backupFile(dir), []byte("foo\n"))
+	existsWithContent(t, filename, []byte("0123456789abcde"))
+
+	newFakeTime()
+
+	_, err = l.Write([]byte("bar"))
+	isNil(t, err)
+
+	existsWithContent(t, backupFile(dir), []byte("0123456789abcde"))
+	existsWithContent(t, filename, []byte("bar"))
+	fileCount(t, dir, 3)
+}
@@ -0,0 +1,90 @@
+package lumberjack
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxRecordPolicy selects how Write handles a record larger than
+// MaxRecordBytes.
+type MaxRecordPolicy string
+
+const (
+	// MaxRecordTruncate cuts the record short and ends it with the marker
+	// "[truncated]", followed by a newline if the record ended with one.
+	MaxRecordTruncate MaxRecordPolicy = "truncate"
+
+	// MaxRecordReject rejects the record with an error.
+	MaxRecordReject MaxRecordPolicy = "reject"
+)
+
+// truncatedMarker ends a record cut short by MaxRecordTruncate.
+const truncatedMarker = "[truncated]"
+
+// checkMaxRecordPolicy reports an error if MaxRecordPolicy is unknown.
+func (l *Logger) checkMaxRecordPolicy() error {
+	switch l.MaxRecordPolicy {
+	case "", MaxRecordTruncate, MaxRecordReject:
+		return nil
+	}
+
+	return fmt.Errorf("unknown MaxRecordPolicy %q", l.MaxRecordPolicy)
+}
+
+// prepare returns the record to write for the record p passed to Write:
+// nothing if Filter drops it, or else rewritten by Rewrite, ending in a
+// newline if AppendNewline is set and limited to MaxRecordBytes, after
+// stripping it for StripANSI.
+func (l *Logger) prepare(p []byte) ([]byte, error) {
+	if l.StripANSI {
+		p = stripANSI(p)
+	}
+
+	if l.Filter != nil && len(p) > 0 && !l.Filter(p) {
+		l.recordFiltered(len(p))
+
+		return nil, nil
+	}
+
+	if l.Rewrite != nil {
+		p = l.Rewrite(p)
+	}
+
+	if l.AppendNewline && len(p) > 0 && p[len(p)-1] != '\n' {
+		p = append(p[:len(p):len(p)], '\n')
+	}
+
+	if l.MaxRecordBytes > 0 && len(p) > l.MaxRecordBytes {
+		if l.MaxRecordPolicy == MaxRecordReject {
+			return nil, fmt.Errorf("record length %d exceeds MaxRecordBytes %d", len(p), l.MaxRecordBytes)
+		}
+
+		p = truncateRecord(p, l.MaxRecordBytes)
+	}
+
+	return p, nil
+}
+
+// truncateRecord returns the first max bytes of p, ending in truncatedMarker
+// and the newline p ends with, if any.  It doesn't cut a UTF-8 sequence in
+// half.
+func truncateRecord(p []byte, max int) []byte {
+	var tail []byte
+
+	if p[len(p)-1] == '\n' {
+		tail = []byte(truncatedMarker + "\n")
+	} else {
+		tail = []byte(truncatedMarker)
+	}
+
+	if max <= len(tail) {
+		return p[:max:max]
+	}
+
+	n := max - len(tail)
+	for n > 0 && !utf8.RuneStart(p[n]) {
+		n--
+	}
+
+	return append(p[:n:n], tail...)
+}
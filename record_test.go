@@ -0,0 +1,133 @@
+package lumberjack
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRewrite")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		AppendNewline: true,
+		Rewrite: func(record []byte) []byte {
+			if bytes.HasPrefix(record, []byte("ping")) {
+				return nil
+			}
+
+			return bytes.ReplaceAll(record, []byte("hunter2"), []byte("*****"))
+		},
+	}
+	defer l.Close()
+
+	b := []byte("password=hunter2")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	// A record rewritten to nothing is dropped.
+	b2 := []byte("ping\n")
+	n, err = l.Write(b2)
+	isNil(t, err)
+	equals(t, len(b2), n)
+
+	existsWithContent(t, filename, []byte("password=*****\n"))
+	equals(t, "password=hunter2", string(b))
+}
+
+func TestFilter(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFilter")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Filter: func(record []byte) bool {
+			return !bytes.Contains(record, []byte("GET /healthz"))
+		},
+		Rewrite: bytes.ToUpper,
+	}
+	defer l.Close()
+
+	b := []byte("GET /healthz 200\n")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	b2 := []byte("GET /api 200\n")
+	n, err = l.Write(b2)
+	isNil(t, err)
+	equals(t, len(b2), n)
+
+	existsWithContent(t, filename, []byte("GET /API 200\n"))
+
+	s := l.Stats()
+	equals(t, int64(1), s.FilteredWrites)
+	equals(t, int64(len(b)), s.FilteredBytes)
+	equals(t, int64(0), s.DroppedWrites)
+	equals(t, int64(1), s.Writes)
+}
+
+func TestTruncateRecord(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"0123456789abcdefghijklmnop", 20, "012345678[truncated]"},
+		{"0123456789abcdefghijklmnop\n", 20, "01234567[truncated]\n"},
+		{"0123456789", 5, "01234"},
+		// "é" isn't cut in half.
+		{"01234567é9abcdefghijklmnop", 20, "01234567[truncated]"},
+	}
+
+	for _, tt := range tests {
+		got := truncateRecord([]byte(tt.in), tt.max)
+		equals(t, tt.want, string(got))
+	}
+}
+
+func TestMaxRecordBytes(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestMaxRecordBytes")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxRecordBytes: 16,
+	}
+	defer l.Close()
+
+	b := []byte("a very long line of text\n")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	b2 := []byte("short\n")
+	_, err = l.Write(b2)
+	isNil(t, err)
+
+	existsWithContent(t, filename, []byte("a ve[truncated]\nshort\n"))
+
+	l.MaxRecordPolicy = MaxRecordReject
+
+	n, err = l.Write(b)
+	equals(t, 0, n)
+	notNil(t, err)
+	equals(t, "record length 25 exceeds MaxRecordBytes 16", err.Error())
+
+	existsWithContent(t, filename, []byte("a ve[truncated]\nshort\n"))
+
+	l.MaxRecordPolicy = "ellipsis"
+	notNil(t, l.Validate())
+}
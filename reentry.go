@@ -0,0 +1,103 @@
+package lumberjack
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// errRecursiveWrite is returned by a Write from within a hook of the same
+// Logger which is called with the Logger locked, such as a Diag which logs to
+// it.
+var errRecursiveWrite = errors.New("lumberjack: write from within a hook of the same Logger was dropped")
+
+// hookCallers tracks the goroutines which are running a hook of the Logger
+// with l.mu or millMu held, so that a write from within such a hook is
+// dropped rather than deadlocking.  Hooks called without either, such as
+// Filter, Rewrite, OnRotate and OnError, aren't tracked, as they may write
+// to the Logger.
+type hookCallers struct {
+	// running counts the hooks running, so that writes only look up their
+	// goroutine while there are any.
+	running atomic.Int32
+
+	mu         sync.Mutex
+	goroutines map[uint64]int
+}
+
+// enter records that the calling goroutine runs a hook, and returns its id
+// for exit.
+func (h *hookCallers) enter() uint64 {
+	id := goid()
+
+	h.mu.Lock()
+	if h.goroutines == nil {
+		h.goroutines = make(map[uint64]int)
+	}
+	h.goroutines[id]++
+	h.mu.Unlock()
+
+	h.running.Add(1)
+
+	return id
+}
+
+// exit records that the goroutine id returned from a hook.
+func (h *hookCallers) exit(id uint64) {
+	h.running.Add(-1)
+
+	h.mu.Lock()
+	if h.goroutines[id]--; h.goroutines[id] == 0 {
+		delete(h.goroutines, id)
+	}
+	h.mu.Unlock()
+}
+
+// inHook reports whether the calling goroutine is running a hook.
+func (h *hookCallers) inHook() bool {
+	if h.running.Load() == 0 {
+		return false
+	}
+
+	id := goid()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.goroutines[id] > 0
+}
+
+// reentered reports whether a write of n bytes comes from within a hook of
+// the Logger, counting it as a recursive write if so.
+func (l *Logger) reentered(n int) bool {
+	if !l.hooks.inHook() {
+		return false
+	}
+
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	l.stats.RecursiveWrites++
+	l.stats.RecursiveBytes += int64(n)
+	l.lastRecursive = l.now()
+
+	return true
+}
+
+// goid returns the id of the calling goroutine, which Go doesn't expose other
+// than in the header of its stack trace, "goroutine 1 [running]:".
+func goid() uint64 {
+	var buf [64]byte
+
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+
+	return id
+}
@@ -0,0 +1,225 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// loggingDiag is a Diag which logs back into its Logger.
+type loggingDiag struct {
+	l    *Logger
+	errs []error
+}
+
+func (d *loggingDiag) Debug(msg string, _ ...any) {
+	_, err := d.l.Write([]byte(msg + "\n"))
+	d.errs = append(d.errs, err)
+}
+
+func TestRecursiveWrite(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRecursiveWrite")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	diag := &loggingDiag{l: l}
+	l.Diag = diag
+
+	var headerErr error
+
+	l.Header = func() []byte {
+		_, headerErr = l.Write([]byte("from header\n"))
+
+		return []byte("header\n")
+	}
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(t, err)
+	equals(t, errRecursiveWrite, headerErr)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	// The rotation was logged to Diag, whose write was dropped instead of
+	// deadlocking.
+	equals(t, 1, len(diag.errs))
+	equals(t, errRecursiveWrite, diag.errs[0])
+
+	existsWithContent(t, backupFile(dir), []byte("header\nboo!\n"))
+	existsWithContent(t, filename, []byte("header\n"))
+
+	s := l.Stats()
+	equals(t, int64(3), s.RecursiveWrites)
+	equals(t, int64(len("from header\n")*2+len("lumberjack: rotated log file\n")), s.RecursiveBytes)
+
+	err = l.Healthy()
+	notNil(t, err)
+	equals(t, "a write from within a hook of the Logger was dropped at "+
+		fakeTime().Format(time.RFC3339), err.Error())
+
+	// Checking again doesn't clear the report, but it expires.
+	notNil(t, l.Healthy())
+
+	newFakeTime()
+	isNil(t, l.Healthy())
+}
+
+func TestWriteFromUnlockedHooks(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestWriteFromUnlockedHooks")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	var filterErr, rotateErr error
+
+	// Filter and OnRotate are called without the Logger locked, so their
+	// writes are kept.
+	l.Filter = func(p []byte) bool {
+		if string(p) == "outer\n" {
+			_, filterErr = l.Write([]byte("inner\n"))
+		}
+
+		return true
+	}
+	l.OnRotate = func(_, _ string, _ RotationReason) {
+		_, rotateErr = l.Write([]byte("rotated\n"))
+	}
+
+	_, err := l.Write([]byte("outer\n"))
+	isNil(t, err)
+	isNil(t, filterErr)
+	existsWithContent(t, filename, []byte("inner\nouter\n"))
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.waitMill(context.Background()))
+	isNil(t, rotateErr)
+
+	existsWithContent(t, filename, []byte("rotated\n"))
+	equals(t, int64(0), l.Stats().RecursiveWrites)
+	isNil(t, l.Healthy())
+}
+
+// loggingInstrumentation is an Instrumentation which logs back into its
+// Logger when an operation starts and ends.
+type loggingInstrumentation struct {
+	l    *Logger
+	errs []error
+}
+
+func (i *loggingInstrumentation) Start(_ context.Context, op Operation, _ string) func(err error) {
+	_, err := i.l.Write([]byte("start " + string(op) + "\n"))
+	i.errs = append(i.errs, err)
+
+	return func(error) {
+		_, err := i.l.Write([]byte("end " + string(op) + "\n"))
+		i.errs = append(i.errs, err)
+	}
+}
+
+func TestRecursiveWriteInstrumentation(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRecursiveWriteInstrumentation")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	instr := &loggingInstrumentation{l: l}
+	l.Instrumentation = instr
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	equals(t, []error{errRecursiveWrite, errRecursiveWrite}, instr.errs)
+	existsWithContent(t, backupFile(dir), []byte("boo!\n"))
+	existsWithContent(t, filename, []byte{})
+	equals(t, int64(2), l.Stats().RecursiveWrites)
+}
+
+// loggingWriter is a writer which logs back into a Logger.
+type loggingWriter struct {
+	l    *Logger
+	w    io.Writer
+	errs *[]error
+}
+
+func (w loggingWriter) Write(p []byte) (int, error) {
+	_, err := w.l.Write([]byte("from writer\n"))
+	*w.errs = append(*w.errs, err)
+
+	if w.w == nil {
+		return len(p), nil
+	}
+
+	return w.w.Write(p)
+}
+
+func TestRecursiveWriteFallback(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRecursiveWriteFallback")
+	defer os.RemoveAll(dir)
+
+	// A file in place of the log directory makes the log file unavailable.
+	blocker := filepath.Join(dir, "logs")
+	isNil(t, os.WriteFile(blocker, nil, fileModeNew))
+
+	var errs []error
+
+	l := &Logger{Filename: filepath.Join(blocker, "foobar.log")}
+	l.Fallback = loggingWriter{l: l, errs: &errs}
+	defer l.Close()
+
+	n, err := l.Write([]byte("boo!\n"))
+	isNil(t, err)
+	equals(t, 5, n)
+	equals(t, []error{errRecursiveWrite}, errs)
+	equals(t, int64(1), l.Stats().RecursiveWrites)
+}
+
+func TestRecursiveWriteTransformers(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRecursiveWriteTransformers")
+	defer os.RemoveAll(dir)
+
+	var errs, transformErrs []error
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	l.Transformers = []func(io.Writer) io.Writer{
+		func(w io.Writer) io.Writer {
+			_, err := l.Write([]byte("from transformer\n"))
+			transformErrs = append(transformErrs, err)
+
+			return loggingWriter{l: l, w: w, errs: &errs}
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(t, err)
+
+	equals(t, []error{errRecursiveWrite}, transformErrs)
+	equals(t, []error{errRecursiveWrite}, errs)
+	existsWithContent(t, filename, []byte("boo!\n"))
+	equals(t, int64(2), l.Stats().RecursiveWrites)
+}
@@ -0,0 +1,84 @@
+package lumberjack
+
+import (
+	"context"
+	"sync"
+)
+
+// registry holds the Loggers added by Register.
+var registry = struct {
+	mu      sync.Mutex
+	loggers map[*Logger]bool
+}{loggers: make(map[*Logger]bool)}
+
+// Register adds l to the Loggers rotated by RotateAll and shut down by
+// CloseAll, so that a program with many Loggers can handle SIGHUP and exit in
+// one place:
+//
+//	lumberjack.Register(l)
+//
+//	hup := make(chan os.Signal, 1)
+//	signal.Notify(hup, syscall.SIGHUP)
+//
+//	go func() {
+//		for range hup {
+//			lumberjack.RotateAll()
+//		}
+//	}()
+//
+// Registering a Logger twice has no effect.
+func Register(l *Logger) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.loggers[l] = true
+}
+
+// Unregister removes l from the registered Loggers.
+func Unregister(l *Logger) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	delete(registry.loggers, l)
+}
+
+// registered returns the registered Loggers.
+func registered() []*Logger {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	loggers := make([]*Logger, 0, len(registry.loggers))
+	for l := range registry.loggers {
+		loggers = append(loggers, l)
+	}
+
+	return loggers
+}
+
+// RotateAll rotates all registered Loggers, see Logger.Rotate.  All of them are
+// rotated even if some fail, and the first error is returned.
+func RotateAll() error {
+	var err error
+
+	for _, l := range registered() {
+		if errRotate := l.Rotate(); err == nil {
+			err = errRotate
+		}
+	}
+
+	return err
+}
+
+// CloseAll shuts down all registered Loggers, see Logger.Shutdown, and
+// returns the first error.  They stay registered, as they may be used again.
+func CloseAll(ctx context.Context) error {
+	var err error
+
+	for _, l := range registered() {
+		if errShutdown := l.Shutdown(ctx); err == nil {
+			err = errShutdown
+		}
+	}
+
+	return err
+}
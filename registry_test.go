@@ -0,0 +1,49 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRegistry")
+	defer os.RemoveAll(dir)
+
+	a := &Logger{Filename: filepath.Join(dir, "a", "foobar.log")}
+	b := &Logger{Filename: filepath.Join(dir, "b", "foobar.log")}
+	c := &Logger{Filename: filepath.Join(dir, "c", "foobar.log")}
+
+	for _, l := range []*Logger{a, b, c} {
+		_, err := l.Write([]byte("boo!"))
+		isNil(t, err)
+	}
+
+	Register(a)
+	Register(b)
+	Register(b)
+	Register(c)
+	Unregister(c)
+
+	defer Unregister(a)
+	defer Unregister(b)
+
+	newFakeTime()
+	isNil(t, RotateAll())
+	isNil(t, CloseAll(context.Background()))
+
+	fileCount(t, filepath.Dir(a.Filename), 2)
+	fileCount(t, filepath.Dir(b.Filename), 2)
+	fileCount(t, filepath.Dir(c.Filename), 1)
+
+	for _, l := range []*Logger{a, b} {
+		l.mu.Lock()
+		equals(t, nil, l.file)
+		l.mu.Unlock()
+	}
+
+	isNil(t, c.Close())
+}
@@ -0,0 +1,42 @@
+package lumberjack
+
+import "os"
+
+// Reopen closes the active log file and opens the file at Filename again,
+// creating it if necessary, without rotating it.  This is a helper for
+// cooperating with external rotation tools such as logrotate, which move the
+// log file aside and then signal the process to write to a new one.  Any
+// buffered data is written to the old file first.
+func (l *Logger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.reopen()
+}
+
+// fileMoved reports whether the active file was removed or renamed since it
+// was opened, so that its path now refers to another file or to none at all.
+// It must be called with l.mu held.
+func (l *Logger) fileMoved() bool {
+	info, err := l.fs().Stat(l.activeName())
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+
+	opened, err := l.file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return !os.SameFile(info, opened)
+}
+
+// reopen closes the active file and opens the file at its path, creating it
+// if necessary, without rotating it.  It must be called with l.mu held.
+func (l *Logger) reopen() error {
+	if err := l.close(); err != nil {
+		return err
+	}
+
+	return l.openExistingOrNew(0)
+}
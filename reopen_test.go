@@ -0,0 +1,77 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFollowName(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestFollowName")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		FollowName: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	// The file was renamed by an external tool.
+	moved := filename + ".1"
+	isNil(t, os.Rename(filename, moved))
+
+	_, err = l.Write([]byte("bar"))
+	isNil(t, err)
+
+	existsWithContent(t, moved, []byte("boo!"))
+	existsWithContent(t, filename, []byte("bar"))
+
+	// The file was removed.
+	isNil(t, os.Remove(filename))
+
+	_, err = l.Write([]byte("baz"))
+	isNil(t, err)
+
+	existsWithContent(t, filename, []byte("baz"))
+	fileCount(t, dir, 2)
+}
+
+func TestReopen(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestReopen")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		BufferSize: 64,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	// The buffered data goes to the moved file.
+	moved := filename + ".1"
+	isNil(t, os.Rename(filename, moved))
+
+	isNil(t, l.Reopen())
+	existsWithContent(t, moved, []byte("boo!"))
+	existsWithContent(t, filename, []byte{})
+
+	_, err = l.Write([]byte("bar"))
+	isNil(t, err)
+	isNil(t, l.Sync())
+
+	existsWithContent(t, filename, []byte("bar"))
+
+	// Reopening doesn't rotate the file.
+	isNil(t, l.Reopen())
+	fileCount(t, dir, 2)
+}
@@ -0,0 +1,71 @@
+package lumberjack
+
+import (
+	"time"
+)
+
+// defaultWriteRetryDelay is the delay before the first retry of a write if
+// WriteRetryDelay is not set.
+const defaultWriteRetryDelay = 10 * time.Millisecond
+
+// busyRetryDelays are the delays between the attempts to rename or remove a
+// file which is busy, see isBusy.
+var busyRetryDelays = []time.Duration{
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	40 * time.Millisecond,
+	80 * time.Millisecond,
+	160 * time.Millisecond,
+}
+
+// retryBusy calls op until it succeeds, fails because of anything but a busy
+// file, or busyRetryDelays are exhausted, and returns its last error.
+func retryBusy(op func() error) error {
+	err := op()
+
+	for _, d := range busyRetryDelays {
+		if err == nil || !isBusy(err) {
+			return err
+		}
+
+		time.Sleep(d)
+
+		err = op()
+	}
+
+	return err
+}
+
+func (l *Logger) writeRetryDelay() time.Duration {
+	if l.WriteRetryDelay > 0 {
+		return l.WriteRetryDelay
+	}
+
+	return defaultWriteRetryDelay
+}
+
+// writeRetrying writes p to the active file like writeFile, and writes the
+// rest of p again up to WriteRetries times if it fails with a transient error,
+// doubling the delay before every attempt.  Writes to the buffer of BufferSize
+// are not retried, as it keeps failing once it failed.  It must be called
+// with l.mu held.
+func (l *Logger) writeRetrying(p []byte) (int, error) {
+	n, err := l.writeFile(p)
+	if l.buf != nil {
+		return n, err
+	}
+
+	delay := l.writeRetryDelay()
+
+	for i := 0; i < l.WriteRetries && err != nil && isTransient(err); i++ {
+		time.Sleep(delay)
+		delay *= 2
+
+		var m int
+
+		m, err = l.writeFile(p[n:])
+		n += m
+	}
+
+	return n, err
+}
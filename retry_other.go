@@ -0,0 +1,10 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !js && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!js,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package lumberjack
+
+// isTransient reports whether err is a failure of a write which may succeed
+// when retried, which can't be told on this platform.
+func isTransient(error) bool {
+	return false
+}
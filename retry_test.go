@@ -0,0 +1,131 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryBusy(t *testing.T) {
+	errBusy := errors.New("busy")
+
+	defer func(busy func(error) bool, delays []time.Duration) {
+		isBusy, busyRetryDelays = busy, delays
+	}(isBusy, busyRetryDelays)
+
+	isBusy = func(err error) bool { return err == errBusy }
+	busyRetryDelays = []time.Duration{time.Millisecond, time.Millisecond}
+
+	// The operation succeeds once the file is no longer busy.
+	calls := 0
+	err := retryBusy(func() error {
+		calls++
+		if calls < 3 {
+			return errBusy
+		}
+
+		return nil
+	})
+	isNil(t, err)
+	equals(t, 3, calls)
+
+	// The retries are bounded.
+	calls = 0
+	err = retryBusy(func() error {
+		calls++
+
+		return errBusy
+	})
+	equals(t, errBusy, err)
+	equals(t, 3, calls)
+
+	// Other errors are not retried.
+	errOther := errors.New("other")
+	calls = 0
+	err = retryBusy(func() error {
+		calls++
+
+		return errOther
+	})
+	equals(t, errOther, err)
+	equals(t, 1, calls)
+}
+
+// flakyFS is the FS of the operating system, whose files fail the given
+// number of writes with err after writing half of the data.
+type flakyFS struct {
+	osFS
+
+	failures int
+	err      error
+}
+
+func (f *flakyFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	file, err := f.osFS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return flakyFile{file, f}, nil
+}
+
+type flakyFile struct {
+	File
+
+	fs *flakyFS
+}
+
+func (f flakyFile) Write(p []byte) (int, error) {
+	if f.fs.failures == 0 {
+		return f.File.Write(p)
+	}
+
+	f.fs.failures--
+
+	n, _ := f.File.Write(p[:len(p)/2])
+
+	return n, f.fs.err
+}
+
+func TestWriteRetries(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestWriteRetries")
+	defer os.RemoveAll(dir)
+
+	fs := &flakyFS{err: &os.PathError{Op: "write", Path: logFile(dir), Err: syscall.EAGAIN}}
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		FS:              fs,
+		WriteRetries:    3,
+		WriteRetryDelay: time.Millisecond,
+	}
+	defer l.Close()
+
+	// The rest of the record is written once the error is gone.
+	fs.failures = 2
+
+	b := []byte("boo!boo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+	existsWithContent(t, logFile(dir), b)
+
+	// The retries are bounded.
+	fs.failures = 5
+
+	n, err = l.Write([]byte("foo!"))
+	notNil(t, err)
+	equals(t, 1, fs.failures)
+	equals(t, 3, n)
+
+	// Other errors are not retried.
+	fs.failures, fs.err = 2, errors.New("bad file descriptor")
+
+	_, err = l.Write([]byte("baz!"))
+	notNil(t, err)
+	equals(t, 1, fs.failures)
+}
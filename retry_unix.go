@@ -0,0 +1,17 @@
+//go:build aix || darwin || dragonfly || freebsd || js || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd js linux netbsd openbsd solaris
+
+package lumberjack
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isTransient reports whether err is a failure of a write which may succeed
+// when retried: an interrupted system call, a resource which is temporarily
+// unavailable, or a full disk, which other processes or the cleanup of old
+// log files may make room on.
+func isTransient(err error) bool {
+	return errors.Is(err, syscall.EINTR) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.ENOSPC)
+}
@@ -0,0 +1,16 @@
+package lumberjack
+
+import (
+	"errors"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// isTransient reports whether err is a failure of a write which may succeed
+// when retried, such as a full disk, which other processes or the cleanup of
+// old log files may make room on.
+func isTransient(err error) bool {
+	return errors.Is(err, windows.ERROR_DISK_FULL) || errors.Is(err, windows.ERROR_HANDLE_DISK_FULL) ||
+		errors.Is(err, syscall.EINTR) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.ENOSPC)
+}
@@ -23,3 +23,19 @@ func ExampleLogger_Rotate() {
 		}
 	}()
 }
+
+// Example of how to reopen the log file in response to SIGHUP, after it was
+// moved by logrotate.
+func ExampleLogger_Reopen() {
+	l := &Logger{}
+	log.SetOutput(l)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	go func() {
+		for {
+			<-c
+			l.Reopen()
+		}
+	}()
+}
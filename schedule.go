@@ -0,0 +1,152 @@
+package lumberjack
+
+import (
+	"fmt"
+	"time"
+)
+
+// rotateAtFormat is the layout of the RotateAt setting.
+const rotateAtFormat = "15:04"
+
+// nextRotationTime returns when a log file opened now is due for a time-based
+// rotation, or the zero time if no time-based rotation is configured.
+func (l *Logger) nextRotationTime() (time.Time, error) {
+	now := l.now()
+
+	var next time.Time
+
+	if l.RotationInterval > 0 {
+		next = now.Add(l.RotationInterval)
+	}
+
+	if l.RotateAt != "" {
+		at, err := l.nextRotateAt(now)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+
+	return next, nil
+}
+
+// nextRotateAt returns the first occurrence of the RotateAt time of day after
+// now.  The date is computed on the calendar rather than by adding hours, so
+// there is exactly one rotation per day across daylight saving transitions: a
+// time of day skipped by the transition is replaced by the moment the clock
+// jumps past it, and a repeated one is only used if the wall clock has not
+// reached it yet.
+func (l *Logger) nextRotateAt(now time.Time) (time.Time, error) {
+	clock, err := time.Parse(rotateAtFormat, l.RotateAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid RotateAt %q: must be HH:MM", l.RotateAt)
+	}
+
+	loc := l.location()
+	t := now.In(loc)
+	day := t.Day()
+
+	if t.Hour()*60+t.Minute() >= clock.Hour()*60+clock.Minute() {
+		day++
+	}
+
+	next := clockOn(t.Year(), t.Month(), day, clock, loc)
+	if !next.After(now) {
+		next = clockOn(t.Year(), t.Month(), day+1, clock, loc)
+	}
+
+	return next, nil
+}
+
+// clockOn returns the time of day given by clock on the given date in loc.  If
+// that time of day does not exist because of a zone transition, the time of
+// the transition is returned instead.
+func clockOn(year int, month time.Month, day int, clock time.Time, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, clock.Hour(), clock.Minute(), 0, 0, loc)
+
+	// Compare the wall clock readings, ignoring the zone offsets.
+	want := time.Date(year, month, day, clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+
+	start, end := t.ZoneBounds()
+
+	switch {
+	case got.Before(want) && !end.IsZero():
+		return end
+	case got.After(want) && !start.IsZero():
+		return start
+	}
+
+	return t
+}
+
+// scheduleRotation records when the freshly opened log file is due for a
+// time-based rotation and arms a timer for it, so that the rotation happens
+// even if no write arrives.  It must be called with l.mu held.
+func (l *Logger) scheduleRotation(next time.Time) {
+	l.stopRotationTimer()
+
+	l.nextRotation = next
+
+	if !next.IsZero() {
+		l.armRotationTimer()
+	}
+}
+
+// armRotationTimer starts a timer which fires when the next rotation is due.
+// It must be called with l.mu held.
+func (l *Logger) armRotationTimer() {
+	l.rotateTimer = time.AfterFunc(l.nextRotation.Sub(l.now()), l.timedRotate)
+}
+
+// stopRotationTimer stops the pending rotation timer, if any.  It must be
+// called with l.mu held.
+func (l *Logger) stopRotationTimer() {
+	if l.rotateTimer != nil {
+		l.rotateTimer.Stop()
+		l.rotateTimer = nil
+	}
+}
+
+// rotationDue reports whether the active file is due for a time-based
+// rotation.  It must be called with l.mu held.
+func (l *Logger) rotationDue() bool {
+	return !l.nextRotation.IsZero() && !l.now().Before(l.nextRotation)
+}
+
+// timedRotate is run by the rotation timer.  It rotates the active file if it
+// is due.  An empty file is kept and a new period is started instead.
+func (l *Logger) timedRotate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil || l.nextRotation.IsZero() {
+		return
+	}
+
+	if err := l.lock(); err != nil {
+		l.queueError(err)
+		l.mill()
+
+		return
+	}
+	defer l.unlock()
+
+	switch {
+	case !l.rotationDue():
+		l.armRotationTimer()
+	case l.size <= l.headerLen:
+		// A file holding nothing but its Header isn't rotated.  The
+		// settings were validated when the file was opened.
+		next, _ := l.nextRotationTime()
+		l.scheduleRotation(next)
+	default:
+		if err := l.rotate(RotationTime); err != nil {
+			l.queueError(err)
+			l.mill()
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotationInterval(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRotationInterval")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		MaxBytes:         100,
+		RotationInterval: time.Hour,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(t, err)
+	equals(t, len(b), n)
+
+	// Still within the interval.
+	b2 := []byte("foo!")
+	n, err = l.Write(b2)
+	isNil(t, err)
+	equals(t, len(b2), n)
+	fileCount(t, dir, 1)
+
+	newFakeTime()
+
+	// The interval has passed, so the write goes to a new file even though
+	// the old one is far from full.
+	b3 := []byte("bar!")
+	n, err = l.Write(b3)
+	isNil(t, err)
+	equals(t, len(b3), n)
+
+	existsWithContent(t, filename, b3)
+	existsWithContent(t, backupFile(dir), append(b, b2...))
+	fileCount(t, dir, 2)
+	equals(t, RotationTime, l.Stats().LastRotationReason)
+
+	// MaxBytes still applies within the interval, whichever comes first.
+	b4 := make([]byte, 97)
+	_, err = l.Write(b4)
+	isNil(t, err)
+
+	existsWithContent(t, filename, b4)
+	equals(t, RotationSize, l.Stats().LastRotationReason)
+	fileCount(t, dir, 3)
+}
+
+func TestClock(t *testing.T) {
+	currentTime = time.Now
+
+	dir := makeTempDir(t, "TestClock")
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		RotationInterval: time.Hour,
+		Clock: func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+
+			return now
+		},
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	mu.Lock()
+	now = now.Add(time.Hour)
+	mu.Unlock()
+
+	// The interval passed on the Logger's clock only.
+	b2 := []byte("foo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+
+	existsWithContent(t, filename, b2)
+	existsWithContent(t, filepath.Join(dir, "foobar-2024-01-01T01-00-00.000.log"), b)
+	fileCount(t, dir, 2)
+}
+
+func TestRotationIntervalTimer(t *testing.T) {
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir(t, "TestRotationIntervalTimer")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		RotationInterval: 50 * time.Millisecond,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	// The rotation happens in the background without any further writes.
+	<-time.After(200 * time.Millisecond)
+
+	existsWithContent(t, filename, []byte{})
+	fileCount(t, dir, 2)
+
+	// An empty file is not rotated.
+	<-time.After(200 * time.Millisecond)
+
+	fileCount(t, dir, 2)
+}
+
+func TestRotateAt(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRotateAt")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxBytes: 100,
+		RotateAt: "00:00",
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(t, err)
+	fileCount(t, dir, 1)
+
+	newFakeTime()
+
+	// Midnight has passed, so the write goes to a new file.
+	b2 := []byte("foo!")
+	_, err = l.Write(b2)
+	isNil(t, err)
+
+	existsWithContent(t, filename, b2)
+	existsWithContent(t, backupFile(dir), b)
+	fileCount(t, dir, 2)
+}
+
+func TestRotateAtInvalid(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRotateAtInvalid")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		RotateAt: "midnight",
+	}
+	defer l.Close()
+
+	n, err := l.Write([]byte("boo!"))
+	equals(t, 0, n)
+	notNil(t, err)
+	equals(t, `invalid RotateAt "midnight": must be HH:MM`, err.Error())
+	fileCount(t, dir, 0)
+}
+
+func TestNextRotateAt(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	isNil(t, err)
+
+	tests := []struct {
+		name     string
+		rotateAt string
+		local    bool // in America/New_York
+		now      time.Time
+		want     time.Time
+	}{
+		{
+			name:     "LaterToday",
+			rotateAt: "12:00",
+			now:      time.Date(2022, 6, 1, 9, 0, 0, 0, time.UTC),
+			want:     time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Tomorrow",
+			rotateAt: "00:00",
+			now:      time.Date(2022, 12, 31, 23, 59, 59, 0, time.UTC),
+			want:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "ExactlyNow",
+			rotateAt: "00:00",
+			now:      time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2022, 6, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "LocalMidnight",
+			rotateAt: "00:00",
+			local:    true,
+			now:      time.Date(2022, 6, 1, 23, 0, 0, 0, time.UTC),
+			want:     time.Date(2022, 6, 2, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			// 02:30 does not exist on this day, the rotation happens when the
+			// clock jumps from 02:00 EST to 03:00 EDT.
+			name:     "SpringForwardSkipped",
+			rotateAt: "02:30",
+			local:    true,
+			now:      time.Date(2022, 3, 13, 6, 0, 0, 0, time.UTC),
+			want:     time.Date(2022, 3, 13, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			// Midnight is only 23 hours after the previous one.
+			name:     "SpringForwardMidnight",
+			rotateAt: "00:00",
+			local:    true,
+			now:      time.Date(2022, 3, 13, 5, 0, 0, 0, time.UTC),
+			want:     time.Date(2022, 3, 14, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			// 01:30 happens twice on this day; once the first one has passed
+			// the next rotation is on the following day.
+			name:     "FallBackRepeated",
+			rotateAt: "01:30",
+			local:    true,
+			now:      time.Date(2022, 11, 6, 5, 45, 0, 0, time.UTC),
+			want:     time.Date(2022, 11, 7, 6, 30, 0, 0, time.UTC),
+		},
+		{
+			// Midnight is 25 hours after the previous one.
+			name:     "FallBackMidnight",
+			rotateAt: "00:00",
+			local:    true,
+			now:      time.Date(2022, 11, 6, 4, 0, 0, 0, time.UTC),
+			want:     time.Date(2022, 11, 7, 5, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := &Logger{RotateAt: test.rotateAt}
+			if test.local {
+				l.Location = loc
+			}
+
+			got, err := l.nextRotateAt(test.now)
+			isNil(t, err)
+			equals(t, test.want.Unix(), got.Unix())
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// RotateContext is like Rotate, but then waits until the resulting
+// compression, archival and removal of old log files are finished, or ctx is
+// done.  In the latter case it returns the context's error, and the work
+// continues in the background.
+func (l *Logger) RotateContext(ctx context.Context) error {
+	if err := l.Rotate(); err != nil {
+		return err
+	}
+
+	return l.waitMill(ctx)
+}
+
+// Shutdown closes the Logger like Close, and then waits until all pending
+// compression, archival and removal of old log files are finished, so that no
+// partially compressed backup is left behind when the process exits.  If ctx
+// is done first, the work is aborted: a partially compressed backup is
+// removed, keeping the uncompressed one for the next run, and running uploads
+// are canceled through the context passed to the Archiver.  Shutdown then
+// waits for the abort, which is quick unless the Archiver ignores
+// cancellation, and returns the context's error.
+func (l *Logger) Shutdown(ctx context.Context) error {
+	err := l.Close()
+
+	errWait := l.waitMill(ctx)
+	if errWait != nil {
+		l.hooksMu.Lock()
+		cancel := l.millCancel
+		l.hooksMu.Unlock()
+
+		if cancel != nil {
+			cancel()
+		}
+
+		_ = l.waitMill(context.Background())
+
+		// Runs requested later, if the Logger is used again, are not
+		// aborted.
+		l.hooksMu.Lock()
+		l.millCtx = nil
+		l.hooksMu.Unlock()
+	}
+
+	if err == nil {
+		err = errWait
+	}
+
+	return err
+}
+
+// CloseAndWait closes the Logger like Close, and then waits until all pending
+// compression, archival and removal of old log files are finished and the
+// goroutine running them has exited, so that tests and short-lived programs
+// neither leave half-written backups behind nor leak the goroutine.  Unlike
+// Close, it must not be called from OnRotate, OnError or an Archiver.  The
+// Logger may be used again afterwards.
+func (l *Logger) CloseAndWait() error {
+	err := l.Shutdown(context.Background())
+
+	l.mu.Lock()
+	stopped := l.stopMill()
+	l.mu.Unlock()
+
+	if stopped != nil {
+		<-stopped
+	}
+
+	return err
+}
+
+// stopMill tells the mill goroutine to exit after the runs requested so far,
+// and returns a channel which is closed when it exited, or nil if it wasn't
+// running.  It must be called with l.mu held.
+func (l *Logger) stopMill() <-chan struct{} {
+	if l.millCh == nil {
+		return nil
+	}
+
+	stopped := l.millStopped
+
+	close(l.millCh)
+	l.millCh = nil
+	l.millStopped = nil
+
+	return stopped
+}
+
+// startMillRun returns the context for a run of the mill, which stays canceled
+// until Shutdown finished aborting the mill, and the number of runs requested
+// so far, which the run covers.
+func (l *Logger) startMillRun() (context.Context, uint64) {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	if l.millCtx == nil {
+		l.millCtx, l.millCancel = context.WithCancel(context.Background())
+	}
+
+	l.millStarted = l.now()
+
+	return l.millCtx, l.millQueued
+}
+
+// requestedMillRuns returns the number of runs of the mill requested so far.
+func (l *Logger) requestedMillRuns() uint64 {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	return l.millQueued
+}
+
+// finishMillRun records that the runs of the mill up to gen are finished, and
+// wakes up waitMill.
+func (l *Logger) finishMillRun(gen uint64) {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+
+	l.millDone = gen
+	l.millStarted = time.Time{}
+
+	if l.millIdle != nil {
+		close(l.millIdle)
+		l.millIdle = nil
+	}
+}
+
+// waitMill waits until the runs of the mill requested so far are finished, or
+// ctx is done.  It must not be called with l.mu held, as the mill may need it
+// to report errors.
+func (l *Logger) waitMill(ctx context.Context) error {
+	l.hooksMu.Lock()
+	target := l.millQueued
+	l.hooksMu.Unlock()
+
+	for {
+		l.hooksMu.Lock()
+
+		if l.millDone >= target {
+			l.hooksMu.Unlock()
+
+			return nil
+		}
+
+		if l.millIdle == nil {
+			l.millIdle = make(chan struct{})
+		}
+
+		idle := l.millIdle
+
+		l.hooksMu.Unlock()
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ctxReader is an io.Reader failing with the context's error once it is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
@@ -0,0 +1,114 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRotateContext(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestRotateContext")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.RotateContext(context.Background()))
+
+	// The backup was compressed before RotateContext returned.
+	exists(t, backupFile(dir)+compressSuffix)
+	notExist(t, backupFile(dir))
+}
+
+// blockingArchiver blocks until the context of the upload is canceled.
+type blockingArchiver struct {
+	canceled chan error
+}
+
+func (a blockingArchiver) Archive(ctx context.Context, _ BackupInfo) error {
+	<-ctx.Done()
+	a.canceled <- ctx.Err()
+
+	return ctx.Err()
+}
+
+func TestShutdown(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestShutdown")
+	defer os.RemoveAll(dir)
+
+	a := blockingArchiver{canceled: make(chan error, 1)}
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+		Archiver: a,
+	}
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = l.Shutdown(ctx)
+	equals(t, true, errors.Is(err, context.DeadlineExceeded))
+
+	// The upload was canceled before Shutdown returned.
+	equals(t, context.Canceled, <-a.canceled)
+	exists(t, backupFile(dir)+compressSuffix)
+
+	// Without pending work, Shutdown returns right away.
+	isNil(t, l.Shutdown(context.Background()))
+}
+
+func TestCloseAndWait(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCloseAndWait")
+	defer os.RemoveAll(dir)
+
+	goroutines := runtime.NumGoroutine()
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+	}
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	// The backup was compressed and the mill goroutine is gone.
+	exists(t, backupFile(dir)+compressSuffix)
+	equals(t, goroutines, runtime.NumGoroutine())
+
+	// The Logger can be used again.
+	_, err = l.Write([]byte("bar"))
+	isNil(t, err)
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+	isNil(t, l.CloseAndWait())
+
+	exists(t, backupFile(dir)+compressSuffix)
+	fileCount(t, dir, 3)
+}
@@ -0,0 +1,42 @@
+//go:build go1.21
+
+package lumberjack
+
+import "log/slog"
+
+// A *slog.Logger can record the diagnostics of a Logger.
+var _ DiagLogger = (*slog.Logger)(nil)
+
+// SlogHandler is a slog.Handler writing records as JSON lines to a Logger.
+// Every record is passed to the Logger in a single Write, so records are never
+// split across log files.
+type SlogHandler struct {
+	slog.Handler
+	l *Logger
+}
+
+// NewSlogHandler returns a SlogHandler writing to l, using opts as
+// slog.NewJSONHandler does.  A nil opts uses the default options.
+func NewSlogHandler(l *Logger, opts *slog.HandlerOptions) *SlogHandler {
+	return &SlogHandler{Handler: slog.NewJSONHandler(l, opts), l: l}
+}
+
+// WithAttrs implements slog.Handler.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SlogHandler{Handler: h.Handler.WithAttrs(attrs), l: h.l}
+}
+
+// WithGroup implements slog.Handler.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	return &SlogHandler{Handler: h.Handler.WithGroup(name), l: h.l}
+}
+
+// Logger returns the Logger the handler writes to.
+func (h *SlogHandler) Logger() *Logger {
+	return h.l
+}
+
+// Rotate rotates the Logger the handler writes to, see Logger.Rotate.
+func (h *SlogHandler) Rotate() error {
+	return h.l.Rotate()
+}
@@ -0,0 +1,50 @@
+//go:build go1.21
+
+package lumberjack
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestSlogHandler")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	h := NewSlogHandler(l, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	})
+	equals(t, l, h.Logger())
+
+	log := slog.New(h).With("app", "test")
+
+	// The handler survives With, so it can still rotate.
+	sh, ok := log.Handler().(*SlogHandler)
+	equals(t, true, ok)
+
+	log.Info("boo!")
+	existsWithContent(t, filename, []byte(`{"level":"INFO","msg":"boo!","app":"test"}`+"\n"))
+
+	newFakeTime()
+
+	isNil(t, sh.Rotate())
+	existsWithContent(t, backupFile(dir), []byte(`{"level":"INFO","msg":"boo!","app":"test"}`+"\n"))
+
+	log.WithGroup("req").Warn("bar", "id", 1)
+	existsWithContent(t, filename, []byte(`{"level":"WARN","msg":"bar","app":"test","req":{"id":1}}`+"\n"))
+}
@@ -0,0 +1,275 @@
+package lumberjack
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// compressionHistory is the number of compression results kept in
+// CompressionStats.Recent.
+const compressionHistory = 100
+
+// CompressionResult describes the compression of a single backup file.
+type CompressionResult struct {
+	// Path is the path of the compressed backup.
+	Path string
+
+	// InputBytes is the size of the backup before compression.
+	InputBytes int64
+
+	// OutputBytes is the size of the backup after compression.
+	OutputBytes int64
+
+	// Duration is the time it took to compress the backup.
+	Duration time.Duration
+}
+
+// Ratio returns the compressed size as a fraction of the uncompressed size, or
+// 0 if the input was empty.
+func (r CompressionResult) Ratio() float64 {
+	return ratio(r.OutputBytes, r.InputBytes)
+}
+
+// CompressionStats aggregates the compression results of a Logger, so that the
+// effectiveness of compression can be judged from real data.
+type CompressionStats struct {
+	// Files is the number of backups compressed.
+	Files int64
+
+	// InputBytes is the total size of the backups before compression.
+	InputBytes int64
+
+	// OutputBytes is the total size of the backups after compression.
+	OutputBytes int64
+
+	// Duration is the total time spent compressing backups.
+	Duration time.Duration
+
+	// Last is the result of the most recent compression.
+	Last CompressionResult
+
+	// Recent holds the results of the most recent compressions, up to 100,
+	// oldest first, so that the ratios and durations of single backups can
+	// be compared.
+	Recent []CompressionResult
+}
+
+// Ratio returns the overall compressed size as a fraction of the uncompressed
+// size, or 0 if nothing was compressed yet.
+func (s CompressionStats) Ratio() float64 {
+	return ratio(s.OutputBytes, s.InputBytes)
+}
+
+// CompressionStats returns the aggregated results of the backups compressed by
+// this Logger so far.
+func (l *Logger) CompressionStats() CompressionStats {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	return l.compressionStats()
+}
+
+// compressionStats returns a copy of the Logger's compression statistics.  It
+// must be called with statsMu held.
+func (l *Logger) compressionStats() CompressionStats {
+	s := l.compression
+	if s.Recent != nil {
+		s.Recent = append([]CompressionResult(nil), s.Recent...)
+	}
+
+	return s
+}
+
+// recordCompression adds res to the Logger's compression statistics.
+func (l *Logger) recordCompression(res CompressionResult) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	l.compression.Files++
+	l.compression.InputBytes += res.InputBytes
+	l.compression.OutputBytes += res.OutputBytes
+	l.compression.Duration += res.Duration
+	l.compression.Last = res
+
+	if len(l.compression.Recent) == compressionHistory {
+		l.compression.Recent = append(l.compression.Recent[:0], l.compression.Recent[1:]...)
+	}
+
+	l.compression.Recent = append(l.compression.Recent, res)
+}
+
+// Stats holds counters of the activity of a Logger since it was created.
+type Stats struct {
+	// Writes is the number of calls to Write which wrote to the log file.
+	Writes int64
+
+	// WrittenBytes is the number of bytes written to the log file.
+	WrittenBytes int64
+
+	// Rotations is the number of times the log file was rotated.
+	Rotations int64
+
+	// RotationErrors is the number of rotations which failed.
+	RotationErrors int64
+
+	// DroppedWrites is the number of records dropped by RateLimitDrop or
+	// because the queue of AsyncBufferSize was full.
+	DroppedWrites int64
+
+	// DroppedBytes is the number of bytes of the dropped records.
+	DroppedBytes int64
+
+	// FilteredWrites is the number of records dropped by Filter.  They are
+	// not counted in DroppedWrites.
+	FilteredWrites int64
+
+	// FilteredBytes is the number of bytes of the records dropped by
+	// Filter.
+	FilteredBytes int64
+
+	// RecursiveWrites is the number of writes dropped because they came
+	// from within a hook of the Logger, such as a Diag logging to it.
+	RecursiveWrites int64
+
+	// RecursiveBytes is the number of bytes of the recursive writes.
+	RecursiveBytes int64
+
+	// FallbackWrites is the number of records, or their remainders, written
+	// to Fallback because the log file failed.
+	FallbackWrites int64
+
+	// FallbackBytes is the number of bytes written to Fallback.
+	FallbackBytes int64
+
+	// RemovedBackups is the number of backups removed by the cleanup of old
+	// log files.
+	RemovedBackups int64
+
+	// LastRotation is the time of the most recent rotation, or the zero time
+	// if the log file was not rotated yet.
+	LastRotation time.Time
+
+	// LastRotationReason is what triggered the most recent rotation, or ""
+	// if the log file was not rotated yet.
+	LastRotationReason RotationReason
+
+	// FileSize is the current size of the active log file, or 0 if it isn't
+	// open.
+	FileSize int64
+
+	// Backups is the current number of backups, compressed or not.
+	Backups int
+
+	// BackupBytes is the current total size of the backups.
+	BackupBytes int64
+
+	// Compression aggregates the compression results, as returned by
+	// CompressionStats.
+	Compression CompressionStats
+}
+
+// Stats returns the counters of the Logger's activity.  The backups are
+// counted by listing the backup directory; if that fails, Backups and
+// BackupBytes are 0.
+func (l *Logger) Stats() Stats {
+	// The backup directory is listed without holding l.mu, so that writes
+	// don't wait for it.
+	l.mu.RLock()
+	var size int64
+	if l.file != nil {
+		size = atomic.LoadInt64(&l.size)
+	}
+
+	p := l.previewLogger(l.config())
+	l.mu.RUnlock()
+
+	files, _ := p.oldLogFiles()
+
+	l.statsMu.Lock()
+	s := l.stats
+	s.Compression = l.compressionStats()
+	l.statsMu.Unlock()
+
+	s.Writes = l.writes.Load()
+	s.WrittenBytes = l.writtenBytes.Load()
+
+	s.FileSize = size
+	s.Backups = len(files)
+
+	for _, f := range files {
+		s.BackupBytes += f.Size()
+	}
+
+	return s
+}
+
+// Written returns the number of writes to the log file and the number of
+// bytes written, as counted in Stats.  Unlike Stats, it neither locks the
+// Logger nor lists the backups, so it may be called as often as metrics are
+// collected.
+func (l *Logger) Written() (writes, bytes int64) {
+	return l.writes.Load(), l.writtenBytes.Load()
+}
+
+// recordWrite counts a write of n bytes.
+func (l *Logger) recordWrite(n int) {
+	l.writes.Add(1)
+	l.writtenBytes.Add(int64(n))
+}
+
+// recordRotation counts a rotation for reason and whether it failed.
+func (l *Logger) recordRotation(reason RotationReason, err error) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	if err != nil {
+		l.stats.RotationErrors++
+	} else {
+		l.stats.Rotations++
+		l.stats.LastRotation = l.now()
+		l.stats.LastRotationReason = reason
+	}
+}
+
+// recordFallback counts a write of n bytes to Fallback.
+func (l *Logger) recordFallback(n int) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	l.stats.FallbackWrites++
+	l.stats.FallbackBytes += int64(n)
+}
+
+// recordDrop counts a dropped record of n bytes.
+func (l *Logger) recordDrop(n int) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	l.stats.DroppedWrites++
+	l.stats.DroppedBytes += int64(n)
+}
+
+// recordFiltered counts a record of n bytes dropped by Filter.
+func (l *Logger) recordFiltered(n int) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	l.stats.FilteredWrites++
+	l.stats.FilteredBytes += int64(n)
+}
+
+// recordRemoval counts a removed backup.
+func (l *Logger) recordRemoval() {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+
+	l.stats.RemovedBackups++
+}
+
+func ratio(out, in int64) float64 {
+	if in == 0 {
+		return 0
+	}
+
+	return float64(out) / float64(in)
+}
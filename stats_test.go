@@ -0,0 +1,158 @@
+package lumberjack
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCompressionStats(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestCompressionStats")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Compress: true,
+		Filename: logFile(dir),
+		MaxBytes: 1000,
+	}
+	defer l.Close()
+
+	equals(t, CompressionStats{}, l.CompressionStats())
+	equals(t, float64(0), l.CompressionStats().Ratio())
+
+	b := bytes.Repeat([]byte("boo!"), 100)
+	_, err := l.Write(b)
+	isNil(t, err)
+
+	newFakeTime()
+
+	err = l.Rotate()
+	isNil(t, err)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	stats := l.CompressionStats()
+	equals(t, int64(1), stats.Files)
+	equals(t, int64(len(b)), stats.InputBytes)
+	equals(t, stats.InputBytes, stats.Last.InputBytes)
+	equals(t, stats.OutputBytes, stats.Last.OutputBytes)
+	equals(t, backupFile(dir)+compressSuffix, stats.Last.Path)
+
+	info, err := os.Stat(stats.Last.Path)
+	isNil(t, err)
+	equals(t, info.Size(), stats.OutputBytes)
+
+	assert(t, stats.Ratio() > 0 && stats.Ratio() < 1, "unexpected compression ratio %v", stats.Ratio())
+	equals(t, stats.Ratio(), stats.Last.Ratio())
+	equals(t, []CompressionResult{stats.Last}, stats.Recent)
+	equals(t, stats.Recent, l.Stats().Compression.Recent)
+}
+
+func TestCompressionStatsRecent(t *testing.T) {
+	var l Logger
+
+	for i := 0; i <= compressionHistory; i++ {
+		l.recordCompression(CompressionResult{InputBytes: int64(i)})
+	}
+
+	// Only the most recent results are kept.
+	stats := l.CompressionStats()
+	equals(t, int64(compressionHistory+1), stats.Files)
+	equals(t, compressionHistory, len(stats.Recent))
+	equals(t, int64(1), stats.Recent[0].InputBytes)
+	equals(t, stats.Last, stats.Recent[compressionHistory-1])
+}
+
+func TestStats(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestStats")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBytes:   10,
+		MaxBackups: 1,
+	}
+	defer l.Close()
+
+	equals(t, Stats{}, l.Stats())
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	for i := 0; i < 2; i++ {
+		newFakeTime()
+
+		_, err = l.Write([]byte("foooooo!"))
+		isNil(t, err)
+	}
+
+	// we need to wait a little bit since the files get deleted on a different
+	// goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	stats := l.Stats()
+	equals(t, int64(3), stats.Writes)
+	equals(t, int64(20), stats.WrittenBytes)
+	equals(t, int64(2), stats.Rotations)
+	equals(t, int64(0), stats.RotationErrors)
+	equals(t, int64(1), stats.RemovedBackups)
+	equals(t, fakeTime(), stats.LastRotation)
+	equals(t, RotationSize, stats.LastRotationReason)
+	equals(t, int64(8), stats.FileSize)
+	equals(t, 1, stats.Backups)
+	equals(t, int64(8), stats.BackupBytes)
+
+	isNil(t, l.Close())
+	equals(t, int64(0), l.Stats().FileSize)
+}
+
+// blockingReadDirFS is the FS of the operating system, whose ReadDir waits
+// until release is closed.
+type blockingReadDirFS struct {
+	osFS
+
+	reading chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingReadDirFS) ReadDir(name string) ([]os.DirEntry, error) {
+	b.reading <- struct{}{}
+	<-b.release
+
+	return b.osFS.ReadDir(name)
+}
+
+func TestStatsDoesntBlockWrites(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestStatsDoesntBlockWrites")
+	defer os.RemoveAll(dir)
+
+	fs := &blockingReadDirFS{reading: make(chan struct{}), release: make(chan struct{})}
+
+	l := &Logger{Filename: logFile(dir), FS: fs}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	done := make(chan Stats)
+	go func() { done <- l.Stats() }()
+
+	// Writes go ahead while Stats lists the backup directory.
+	<-fs.reading
+	_, err = l.Write([]byte("foo!"))
+	isNil(t, err)
+	close(fs.release)
+
+	stats := <-done
+	equals(t, int64(4), stats.FileSize)
+	equals(t, int64(2), stats.Writes)
+}
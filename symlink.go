@@ -0,0 +1,94 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// prevSymlinkSuffix is appended to the log filename to name the symlink
+// maintained by PrevSymlink.
+const prevSymlinkSuffix = ".prev"
+
+// linkActive points SymlinkName at the active log file.  A failure doesn't
+// prevent logging, so it is reported like other background errors.  It must be
+// called with l.mu held.
+func (l *Logger) linkActive() {
+	if l.SymlinkName == "" {
+		return
+	}
+
+	if err := replaceSymlink(l.activeName(), l.symlinkPath(l.SymlinkName)); err != nil {
+		l.queueError(err)
+		l.mill()
+	}
+}
+
+// linkNewestBackup points the symlink maintained by PrevSymlink at the newest
+// backup, or removes it if there is none.  It must be called with l.millMu
+// held.
+func (l *Logger) linkNewestBackup() error {
+	if !l.PrevSymlink {
+		return nil
+	}
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	name := l.filename() + prevSymlinkSuffix
+
+	if len(files) == 0 {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("can't remove symlink %s: %s", name, err)
+		}
+
+		return nil
+	}
+
+	return replaceSymlink(filepath.Join(l.backupDir(), files[0].Name()), name)
+}
+
+// symlinkPath returns the path of a symlink named by a setting, which is
+// relative to the directory of the log file unless it is absolute.
+func (l *Logger) symlinkPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	return filepath.Join(l.dir(), name)
+}
+
+// replaceSymlink atomically replaces the file at name with a symlink to
+// target, by renaming a temporary symlink over it, so that readers following
+// the link never find it missing.  The link is relative if possible, so that
+// it survives the directory being moved or mounted elsewhere.
+func replaceSymlink(target, name string) error {
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return fmt.Errorf("can't update symlink %s: %s", name, err)
+	}
+
+	if dir, err := filepath.Abs(filepath.Dir(name)); err == nil {
+		if rel, err := filepath.Rel(dir, target); err == nil {
+			target = rel
+		}
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", name, os.Getpid())
+
+	_ = os.Remove(tmp)
+
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("can't update symlink %s: %s", name, err)
+	}
+
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("can't update symlink %s: %s", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSymlinkName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestSymlinkName")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		SymlinkName: "current",
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+
+	link := filepath.Join(dir, "current")
+
+	target, err := os.Readlink(link)
+	isNil(t, err)
+	equals(t, filepath.Base(filename), target)
+	existsWithContent(t, link, []byte("boo!"))
+
+	newFakeTime()
+	isNil(t, l.Rotate())
+
+	_, err = l.Write([]byte("bar"))
+	isNil(t, err)
+
+	existsWithContent(t, link, []byte("bar"))
+
+	// The log file, the symlink and the backup.
+	fileCount(t, dir, 3)
+}
+
+func TestPrevSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestPrevSymlink")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		Compress:    true,
+		PrevSymlink: true,
+	}
+	defer l.Close()
+
+	for _, s := range []string{"foo", "bar"} {
+		_, err := l.Write([]byte(s))
+		isNil(t, err)
+
+		newFakeTime()
+		isNil(t, l.Rotate())
+	}
+
+	// we need to wait a little bit since the files get compressed on a
+	// different goroutine.
+	<-time.After(300 * time.Millisecond)
+
+	target, err := os.Readlink(filename + ".prev")
+	isNil(t, err)
+	equals(t, filepath.Base(backupFile(dir))+compressSuffix, target)
+}
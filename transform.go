@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// transformedFile is an active log file written through the writers returned
+// by Transformers.
+type transformedFile struct {
+	File
+
+	// layers are the writers, the outermost first.
+	layers []io.Writer
+
+	// hooks tracks the calls into the user's writers, which are made with
+	// the Logger locked.
+	hooks *hookCallers
+}
+
+func newTransformedFile(f File, transformers []func(io.Writer) io.Writer, hooks *hookCallers) *transformedFile {
+	t := &transformedFile{File: f, hooks: hooks}
+
+	defer hooks.exit(hooks.enter())
+
+	var w io.Writer = f
+
+	for i := len(transformers) - 1; i >= 0; i-- {
+		w = transformers[i](w)
+		t.layers = append([]io.Writer{w}, t.layers...)
+	}
+
+	return t
+}
+
+func (t *transformedFile) Write(p []byte) (int, error) {
+	defer t.hooks.exit(t.hooks.enter())
+
+	return t.layers[0].Write(p)
+}
+
+// Sync flushes the writers which hold data back before committing the file.
+func (t *transformedFile) Sync() error {
+	if err := t.flush(); err != nil {
+		return err
+	}
+
+	return t.File.Sync()
+}
+
+// flush flushes the writers with a Flush() error method, the outermost first.
+func (t *transformedFile) flush() error {
+	defer t.hooks.exit(t.hooks.enter())
+
+	for _, w := range t.layers {
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close closes the writers which are io.Closers, the outermost first, so that
+// each writes out its trailer, and then the file.
+func (t *transformedFile) Close() error {
+	err := t.closeLayers()
+
+	if errClose := t.File.Close(); err == nil {
+		err = errClose
+	}
+
+	return err
+}
+
+// closeLayers closes the writers which are io.Closers, the outermost first,
+// and returns the first error.
+func (t *transformedFile) closeLayers() error {
+	defer t.hooks.exit(t.hooks.enter())
+
+	var err error
+
+	for _, w := range t.layers {
+		if c, ok := w.(io.Closer); ok {
+			if errClose := c.Close(); err == nil {
+				err = errClose
+			}
+		}
+	}
+
+	return err
+}
+
+// osFile returns the *os.File underlying the active file f, if any.
+func osFile(f File) (*os.File, bool) {
+	if t, ok := f.(*transformedFile); ok {
+		f = t.File
+	}
+
+	file, ok := f.(*os.File)
+
+	return file, ok
+}
+
+// checkTransformers reports an error if Transformers is combined with a
+// setting which relies on the data on disk being the data written.
+func (l *Logger) checkTransformers() error {
+	if len(l.Transformers) == 0 {
+		return nil
+	}
+
+	switch {
+	case l.CompressActive:
+		return errors.New("Transformers can't be used with CompressActive")
+	case l.VerifyTailBytes > 0:
+		return errors.New("Transformers can't be used with VerifyTailBytes")
+	}
+
+	return nil
+}
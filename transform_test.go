@@ -0,0 +1,88 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+// framingWriter prefixes every record with its length.
+type framingWriter struct {
+	w io.Writer
+}
+
+func (f framingWriter) Write(p []byte) (int, error) {
+	if _, err := fmt.Fprintf(f.w, "%d:", len(p)); err != nil {
+		return 0, err
+	}
+
+	return f.w.Write(p)
+}
+
+// trailingWriter writes a trailer when it is closed.
+type trailingWriter struct {
+	io.Writer
+}
+
+func (t trailingWriter) Close() error {
+	_, err := t.Write([]byte("END"))
+
+	return err
+}
+
+func TestTransformers(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestTransformers")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxBytes: 10,
+		Transformers: []func(io.Writer) io.Writer{
+			func(w io.Writer) io.Writer { return framingWriter{w} },
+			func(w io.Writer) io.Writer { return trailingWriter{w} },
+		},
+	}
+	defer l.Close()
+
+	n, err := l.Write([]byte("boo!"))
+	isNil(t, err)
+	equals(t, 4, n)
+
+	n, err = l.Write([]byte("foo!"))
+	isNil(t, err)
+	equals(t, 4, n)
+
+	// MaxBytes counts the records, not the frames.
+	equals(t, int64(8), l.Size())
+	existsWithContent(t, filename, []byte("4:boo!4:foo!"))
+
+	newFakeTime()
+
+	_, err = l.Write([]byte("bar!"))
+	isNil(t, err)
+
+	existsWithContent(t, backupFile(dir), []byte("4:boo!4:foo!END"))
+	existsWithContent(t, filename, []byte("4:bar!"))
+
+	isNil(t, l.Close())
+	existsWithContent(t, filename, []byte("4:bar!END"))
+}
+
+func TestTransformersCompressActive(t *testing.T) {
+	dir := makeTempDir(t, "TestTransformersCompressActive")
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		CompressActive: true,
+		Transformers:   []func(io.Writer) io.Writer{func(w io.Writer) io.Writer { return w }},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(t, err)
+}
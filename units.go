@@ -0,0 +1,149 @@
+package lumberjack
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits maps the unit suffixes accepted by ParseSize to their multipliers.
+// Like MaxSize, units are powers of 1024 whether or not the IEC "i" is used.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// ParseSize parses a human-readable size such as "512", "100MB" or "1.5GiB"
+// into a number of bytes.  Units are case-insensitive powers of 1024, so "1MB"
+// and "1MiB" both mean 1048576 bytes.  Negative sizes, fractional byte counts
+// and unknown units are rejected.
+func ParseSize(s string) (int64, error) {
+	str := strings.TrimSpace(s)
+
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i == -1 {
+		i = len(str)
+	}
+
+	num, unit := str[:i], strings.ToLower(strings.TrimSpace(str[i:]))
+	if num == "" {
+		return 0, fmt.Errorf("invalid size %q: missing number", s)
+	}
+
+	mult, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, unit)
+	}
+
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseInt(num, 10, 64)
+		if err != nil || n > math.MaxInt64/mult {
+			return 0, fmt.Errorf("invalid size %q: out of range", s)
+		}
+
+		return n * mult, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %v", s, err)
+	}
+
+	v := f * float64(mult)
+	if v >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: out of range", s)
+	}
+
+	if v != math.Trunc(v) {
+		return 0, fmt.Errorf("invalid size %q: not a whole number of bytes", s)
+	}
+
+	return int64(v), nil
+}
+
+// ByteSize is a number of bytes.  In JSON, YAML and TOML configs it can be
+// given as a number or as a human-readable string accepted by ParseSize, such
+// as "100MB" or "1.5GiB".
+type ByteSize int64
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseSize.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	n, err := ParseSize(string(text))
+	if err != nil {
+		return err
+	}
+
+	*b = ByteSize(n)
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a number as well as a
+// string.
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	text := string(data)
+
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	} else if text == "null" {
+		return nil
+	}
+
+	return b.UnmarshalText([]byte(text))
+}
+
+// parseDuration is like time.ParseDuration, but additionally accepts a single
+// number of days ("7d") or weeks ("2w").
+func parseDuration(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+
+	for suffix, unit := range map[string]time.Duration{"d": dayInHours, "w": 7 * dayInHours} {
+		if !strings.HasSuffix(str, suffix) {
+			continue
+		}
+
+		n, err := strconv.ParseFloat(strings.TrimSuffix(str, suffix), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+
+		return time.Duration(n * float64(unit)), nil
+	}
+
+	return time.ParseDuration(str)
+}
+
+// parseDays parses a number of days, either as a plain integer or as a duration
+// accepted by parseDuration which must be a whole number of days.
+func parseDays(s string) (int, error) {
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+		return n, nil
+	}
+
+	d, err := parseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if d%dayInHours != 0 {
+		return 0, fmt.Errorf("invalid duration %q: not a whole number of days", s)
+	}
+
+	return int(d / dayInHours), nil
+}
@@ -0,0 +1,94 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"10k", 10 << 10, false},
+		{"10KB", 10 << 10, false},
+		{"100MB", 100 << 20, false},
+		{"100 MiB", 100 << 20, false},
+		{"1.5GiB", 3 << 29, false},
+		{" 2tb ", 2 << 40, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-5MB", 0, true},
+		{"10XB", 0, true},
+		{"1.5B", 0, true},
+		{"1.2.3MB", 0, true},
+		{"99999999999TB", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseSize(test.in)
+		equals(t, test.want, got)
+		equals(t, test.wantErr, err != nil)
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	// The settings of a Config, rather than those of a Logger, accept
+	// human-readable sizes.
+	var c Config
+
+	err := json.Unmarshal([]byte(`{"maxbytes": "100MB", "maxtotalbytes": 1024}`), &c)
+	isNil(t, err)
+	equals(t, ByteSize(100<<20), c.MaxBytes)
+	equals(t, ByteSize(1024), c.MaxTotalBytes)
+
+	err = yaml.Unmarshal([]byte("maxbytes: 1.5GiB\nmaxtotalbytes: 2048"), &c)
+	isNil(t, err)
+	equals(t, ByteSize(3<<29), c.MaxBytes)
+	equals(t, ByteSize(2048), c.MaxTotalBytes)
+
+	_, err = toml.Decode("maxbytes = \"10k\"\nmaxtotalbytes = 4096", &c)
+	isNil(t, err)
+	equals(t, ByteSize(10<<10), c.MaxBytes)
+	equals(t, ByteSize(4096), c.MaxTotalBytes)
+
+	err = json.Unmarshal([]byte(`{"maxbytes": "10XB"}`), &c)
+	equals(t, `invalid size "10XB": unknown unit "xb"`, err.Error())
+
+	err = yaml.Unmarshal([]byte("maxbytes: -5MB"), &c)
+	notNil(t, err)
+}
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"7", 7, false},
+		{"7d", 7, false},
+		{"2w", 14, false},
+		{"48h", 2, false},
+		{"36h", 0, true},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseDays(test.in)
+		equals(t, test.want, got)
+		equals(t, test.wantErr, err != nil)
+	}
+
+	d, err := parseDuration("1.5d")
+	isNil(t, err)
+	equals(t, 36*time.Hour, d)
+}
@@ -0,0 +1,137 @@
+package lumberjack
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UpdateConfig applies the settings of cfg to the Logger while it is in use,
+// e.g. when the configuration of a service is changed at runtime, without
+// replacing the Logger handed to logging packages.  The settings are checked
+// like New does, and none of them is applied if any is invalid.  Every Write
+// sees either the old or the new settings, and a running cleanup of old log
+// files is finished under the old ones first.
+//
+// Retention and compression settings take effect with a cleanup which is
+// started right away, and a lower MaxBytes with the next Write.  If the path
+// of the active file changes, because of Filename or FilenameDateLayout, the
+// old file gets its Footer and is closed, like the file of an ended period,
+// and the next Write opens the new one.  The active file is closed and
+// reopened as well if a setting which is applied when it is opened changes,
+// such as BufferSize, SyncInterval, RotateAt or LockMode.
+//
+// AsyncBufferSize, AppendNewline, StripANSI, MaxRecordBytes,
+// MaxRecordPolicy, MaxBytesPerSecond, RateLimitPolicy, PostRotateCommand and
+// PostRotateTimeout can't be changed, as they are read without locking the
+// Logger.
+func (l *Logger) UpdateConfig(cfg Config) error {
+	l.lockForUpdate()
+	defer l.mu.Unlock()
+	defer l.millMu.Unlock()
+
+	old := l.config()
+
+	if err := checkUpdate(old, cfg); err != nil {
+		return err
+	}
+
+	oldName := l.activeName()
+
+	cfg.applyUpdate(l)
+
+	if err := l.validate(); err != nil {
+		old.applyUpdate(l)
+
+		return err
+	}
+
+	l.updateSlowPath()
+
+	if l.file != nil {
+		switch {
+		case l.activeName() != oldName:
+			l.queueError(l.writeFooter())
+
+			if err := l.close(); err != nil {
+				return err
+			}
+		case !reflect.DeepEqual(old.openSettings(), cfg.openSettings()):
+			if err := l.close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	l.mill()
+
+	return nil
+}
+
+// lockForUpdate locks l.mu and millMu, as the mill reads the settings without
+// l.mu.  A running mill is waited for without holding l.mu, so that writes
+// don't stall while it compresses or uploads backups.  millMu is taken after
+// l.mu, like a rotation does.
+func (l *Logger) lockForUpdate() {
+	for {
+		l.mu.Lock()
+		if l.millMu.TryLock() {
+			return
+		}
+
+		l.mu.Unlock()
+
+		// Wait for the running mill.
+		l.millMu.Lock()
+		l.millMu.Unlock()
+	}
+}
+
+// checkUpdate reports an error if cfg changes a setting of old which can't be
+// changed by UpdateConfig.
+func checkUpdate(old, cfg Config) error {
+	fixed := []struct {
+		name     string
+		old, new any
+	}{
+		{"AsyncBufferSize", old.AsyncBufferSize, cfg.AsyncBufferSize},
+		{"AppendNewline", old.AppendNewline, cfg.AppendNewline},
+		{"StripANSI", old.StripANSI, cfg.StripANSI},
+		{"MaxRecordBytes", old.MaxRecordBytes, cfg.MaxRecordBytes},
+		{"MaxRecordPolicy", old.MaxRecordPolicy, cfg.MaxRecordPolicy},
+		{"MaxBytesPerSecond", old.MaxBytesPerSecond, cfg.MaxBytesPerSecond},
+		{"RateLimitPolicy", old.RateLimitPolicy, cfg.RateLimitPolicy},
+		{"PostRotateCommand", old.PostRotateCommand, cfg.PostRotateCommand},
+		{"PostRotateTimeout", old.PostRotateTimeout, cfg.PostRotateTimeout},
+	}
+
+	for _, s := range fixed {
+		if !reflect.DeepEqual(s.old, s.new) {
+			return fmt.Errorf("UpdateConfig can't change %s", s.name)
+		}
+	}
+
+	return nil
+}
+
+// openSettings returns the settings of c which are applied when the active
+// file is opened, or by the timers started then.
+func (c Config) openSettings() Config {
+	return Config{
+		CompressActive:    c.CompressActive,
+		MinFreeBytes:      c.MinFreeBytes,
+		MinFreePercent:    c.MinFreePercent,
+		DiskCheckInterval: c.DiskCheckInterval,
+		JanitorInterval:   c.JanitorInterval,
+		LocalTime:         c.LocalTime,
+		Location:          c.Location,
+		RotationInterval:  c.RotationInterval,
+		RotateAt:          c.RotateAt,
+		BufferSize:        c.BufferSize,
+		FlushInterval:     c.FlushInterval,
+		SyncInterval:      c.SyncInterval,
+		SyncEveryWrite:    c.SyncEveryWrite,
+		Preallocate:       c.Preallocate,
+		LockMode:          c.LockMode,
+		SymlinkName:       c.SymlinkName,
+	}
+}
@@ -0,0 +1,134 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateConfig(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestUpdateConfig")
+	defer os.RemoveAll(dir)
+
+	cfg := Config{Filename: logFile(dir), MaxBytes: 100}
+	l := cfg.NewLogger()
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+
+		_, err := l.Write([]byte("boo!"))
+		isNil(t, err)
+		isNil(t, l.Rotate())
+	}
+
+	_, err := l.Write(make([]byte, 60))
+	isNil(t, err)
+	fileCount(t, dir, 4)
+
+	// The backups exceeding the new MaxBackups are removed right away.
+	cfg.MaxBackups = 1
+	cfg.MaxBytes = 80
+	isNil(t, l.UpdateConfig(cfg))
+	isNil(t, l.waitMill(context.Background()))
+	fileCount(t, dir, 2)
+
+	// The next Write is checked against the new MaxBytes.
+	newFakeTime()
+
+	_, err = l.Write(make([]byte, 30))
+	isNil(t, err)
+	existsWithContent(t, backupFile(dir), make([]byte, 60))
+	existsWithContent(t, logFile(dir), make([]byte, 30))
+}
+
+func TestUpdateConfigFilename(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestUpdateConfigFilename")
+	defer os.RemoveAll(dir)
+
+	cfg := Config{Filename: logFile(dir)}
+	l := cfg.NewLogger()
+	l.Footer = func() []byte { return []byte("end\n") }
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(t, err)
+
+	cfg.Filename = filepath.Join(dir, "other.log")
+	isNil(t, l.UpdateConfig(cfg))
+
+	_, err = l.Write([]byte("foo!\n"))
+	isNil(t, err)
+
+	existsWithContent(t, logFile(dir), []byte("boo!\nend\n"))
+	existsWithContent(t, cfg.Filename, []byte("foo!\n"))
+	fileCount(t, dir, 2)
+}
+
+func TestUpdateConfigInvalid(t *testing.T) {
+	dir := makeTempDir(t, "TestUpdateConfigInvalid")
+	defer os.RemoveAll(dir)
+
+	cfg := Config{Filename: logFile(dir), MaxBackups: 3}
+	l := cfg.NewLogger()
+	defer l.Close()
+
+	bad := cfg
+	bad.MaxBackups = 1
+	bad.MaxAge = -1
+
+	err := l.UpdateConfig(bad)
+	notNil(t, err)
+	equals(t, "invalid MaxAge: must not be negative", err.Error())
+	equals(t, 3, l.MaxBackups)
+
+	bad = cfg
+	bad.AsyncBufferSize = 1024
+
+	err = l.UpdateConfig(bad)
+	notNil(t, err)
+	equals(t, "UpdateConfig can't change AsyncBufferSize", err.Error())
+	equals(t, 0, l.AsyncBufferSize)
+}
+
+func TestUpdateConfigConcurrentWrite(t *testing.T) {
+	dir := makeTempDir(t, "TestUpdateConfigConcurrentWrite")
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		Filename:          logFile(dir),
+		MaxBytes:          1000,
+		AppendNewline:     true,
+		StripANSI:         true,
+		MaxRecordBytes:    100,
+		MaxBytesPerSecond: 1 << 30,
+	}
+	l := cfg.NewLogger()
+	defer l.Close()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			if _, err := l.Write([]byte("boo!")); err != nil {
+				t.Error(err)
+
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		cfg.MaxBackups = i%3 + 1
+		isNil(t, l.UpdateConfig(cfg))
+	}
+
+	<-done
+}
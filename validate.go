@@ -0,0 +1,199 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNegative is the cause of a ConfigError of a setting which must not be
+// negative.
+var ErrNegative = errors.New("must not be negative")
+
+// ConfigError is an invalid setting of a Logger.
+type ConfigError struct {
+	// Setting is the name of the Logger field which is invalid, or the
+	// main one if several settings conflict.
+	Setting string
+
+	// Err describes the problem.
+	Err error
+}
+
+// Error implements error.
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns Err.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigErrors are all invalid settings found by Validate.
+type ConfigErrors []*ConfigError
+
+// Error implements error, joining the messages of all errors.
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the errors matches target, so that errors.Is
+// finds them before Go 1.20 as well.
+func (e ConfigErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first of the errors which matches target, so that errors.As
+// finds them before Go 1.20 as well.
+func (e ConfigErrors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As from Go 1.20 on.
+func (e ConfigErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// Validate checks all settings of the Logger, so that a misconfiguration is
+// reported when the Logger is set up rather than by the first Write.  Besides
+// the settings checked by New, it checks that the directories of the log file
+// and of the backups are writable, or can be created.  If any setting is
+// invalid, it returns ConfigErrors listing all of them.
+func (l *Logger) Validate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	errs := l.configErrors()
+
+	if l.FS == nil {
+		dirs := []struct{ setting, dir string }{{"Filename", l.dir()}}
+		if l.backupDir() != l.dir() {
+			dirs = append(dirs, struct{ setting, dir string }{"BackupDir", l.backupDir()})
+		}
+
+		for _, d := range dirs {
+			if err := checkWritable(d.dir); err != nil {
+				errs = append(errs, &ConfigError{Setting: d.setting, Err: err})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// configErrors returns the invalid settings of the Logger, leaving out the
+// directories.  It must be called with l.mu held if the Logger is in use.
+func (l *Logger) configErrors() ConfigErrors {
+	negative := []struct {
+		name  string
+		value int64
+	}{
+		{"MaxBytes", l.MaxBytes},
+		{"MaxSize", int64(l.MaxSize)},
+		{"MaxBackups", int64(l.MaxBackups)},
+		{"MaxUncompressedBackups", int64(l.MaxUncompressedBackups)},
+		{"MaxCompressedBackups", int64(l.MaxCompressedBackups)},
+		{"MaxAge", int64(l.MaxAge)},
+		{"MaxTotalBytes", l.MaxTotalBytes},
+		{"MinFreeBytes", l.MinFreeBytes},
+		{"MinFreePercent", int64(l.MinFreePercent)},
+		{"DiskCheckInterval", int64(l.DiskCheckInterval)},
+		{"JanitorInterval", int64(l.JanitorInterval)},
+		{"MaxBytesPerSecond", l.MaxBytesPerSecond},
+		{"CompressConcurrency", int64(l.CompressConcurrency)},
+		{"CompressWorkers", int64(l.CompressWorkers)},
+		{"CompressBufferSize", int64(l.CompressBufferSize)},
+		{"CompressAfter", int64(l.CompressAfter)},
+		{"RotationInterval", int64(l.RotationInterval)},
+		{"BufferSize", int64(l.BufferSize)},
+		{"AsyncBufferSize", int64(l.AsyncBufferSize)},
+		{"MaxRecordBytes", int64(l.MaxRecordBytes)},
+		{"FallbackRetryInterval", int64(l.FallbackRetryInterval)},
+		{"WriteRetries", int64(l.WriteRetries)},
+		{"WriteRetryDelay", int64(l.WriteRetryDelay)},
+		{"FlushInterval", int64(l.FlushInterval)},
+		{"SyncInterval", int64(l.SyncInterval)},
+		{"PostRotateTimeout", int64(l.PostRotateTimeout)},
+		{"VerifyTailBytes", int64(l.VerifyTailBytes)},
+	}
+
+	var errs ConfigErrors
+
+	for _, s := range negative {
+		if s.value < 0 {
+			errs = append(errs, &ConfigError{Setting: s.name, Err: fmt.Errorf("invalid %s: %w", s.name, ErrNegative)})
+		}
+	}
+
+	for _, c := range l.settingChecks() {
+		if err := c.check(); err != nil {
+			errs = append(errs, &ConfigError{Setting: c.setting, Err: err})
+		}
+	}
+
+	if _, err := l.nextRotationTime(); err != nil {
+		errs = append(errs, &ConfigError{Setting: "RotateAt", Err: err})
+	}
+
+	return errs
+}
+
+// checkWritable reports an error if dir is not a writable directory, or, if
+// it doesn't exist, its closest existing ancestor, in which it would be
+// created.  Writability is tested by creating and removing a file.
+func checkWritable(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if os.IsNotExist(err) && filepath.Dir(dir) != dir {
+			dir = filepath.Dir(dir)
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+
+		f, err := os.CreateTemp(dir, ".lumberjack-")
+		if err != nil {
+			return fmt.Errorf("directory %s is not writable: %v", dir, err)
+		}
+
+		f.Close()
+
+		return os.Remove(f.Name())
+	}
+}
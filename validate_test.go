@@ -0,0 +1,66 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := makeTempDir(t, "TestValidate")
+	defer os.RemoveAll(dir)
+
+	// A missing directory is fine, as it is created by the first Write.
+	l := &Logger{Filename: filepath.Join(dir, "logs", "foobar.log"), MaxBackups: 3}
+	isNil(t, l.Validate())
+	notExist(t, filepath.Join(dir, "logs"))
+	fileCount(t, dir, 0)
+}
+
+func TestValidateErrors(t *testing.T) {
+	dir := makeTempDir(t, "TestValidateErrors")
+	defer os.RemoveAll(dir)
+
+	// A file in place of the backup directory.
+	isNil(t, os.WriteFile(filepath.Join(dir, "old"), nil, fileModeNew))
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		BackupDir:          "old",
+		MaxBackups:         -1,
+		MaxAge:             -2,
+		NamingScheme:       NamingSequence,
+		BackupNameTemplate: "{name}-{timestamp}{ext}",
+		RotateAt:           "25:00",
+	}
+
+	err := l.Validate()
+	notNil(t, err)
+
+	var errs ConfigErrors
+
+	equals(t, true, errors.As(err, &errs))
+
+	var settings []string
+	for _, e := range errs {
+		settings = append(settings, e.Setting)
+	}
+
+	equals(t, []string{"MaxBackups", "MaxAge", "BackupNameTemplate", "RotateAt", "BackupDir"}, settings)
+	equals(t, "invalid MaxBackups: must not be negative", errs[0].Error())
+	equals(t, true, errors.Is(err, ErrNegative))
+
+	// The errors are found without the multi-error unwrapping of Go 1.20.
+	equals(t, true, errs.Is(ErrNegative))
+
+	var first *ConfigError
+
+	equals(t, true, errs.As(&first))
+	equals(t, "MaxBackups", first.Setting)
+
+	// New reports the first invalid setting.
+	_, err = New(logFile(dir), WithMaxBackups(-1), WithMaxAge(-2))
+	notNil(t, err)
+	equals(t, "invalid MaxBackups: must not be negative", err.Error())
+}
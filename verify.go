@@ -0,0 +1,108 @@
+package lumberjack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrTailMismatch is returned by VerifyTail when the bytes read back from the
+// active file differ from the bytes that were written to it.
+var ErrTailMismatch = errors.New("active file tail does not match written data")
+
+// VerifyTail reads back the last n bytes of the active log file and compares
+// them against an in-memory copy of the most recent writes, to detect silent
+// corruption caused by unreliable storage.  It returns an error wrapping
+// ErrTailMismatch if they differ.
+//
+// Only bytes written since the active file was opened can be verified, and at
+// most VerifyTailBytes of them.  Note that the operating system may serve the
+// read from its page cache, so corruption that happens below the cache is only
+// detected once the cached pages have been evicted.
+func (l *Logger) VerifyTail(n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n <= 0 {
+		return nil
+	}
+
+	if l.file == nil {
+		return errors.New("no active log file to verify")
+	}
+
+	if n > l.VerifyTailBytes {
+		return fmt.Errorf("can't verify %d bytes, VerifyTailBytes is %d", n, l.VerifyTailBytes)
+	}
+
+	if err := l.flush(); err != nil {
+		return err
+	}
+
+	want := l.shadow.tail(n)
+	if len(want) < n {
+		return fmt.Errorf("only %d written bytes available to verify, want %d", len(want), n)
+	}
+
+	f, err := l.fs().OpenFile(l.activeName(), os.O_RDONLY, 0)
+	if err != nil {
+		return fmt.Errorf("can't open log file for verification: %s", err)
+	}
+
+	defer f.Close()
+
+	got := make([]byte, n)
+	if _, err := f.ReadAt(got, l.size-int64(n)); err != nil {
+		return fmt.Errorf("can't read back log file: %s", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("%w: last %d bytes of %s", ErrTailMismatch, n, l.activeName())
+	}
+
+	return nil
+}
+
+// tailBuffer keeps the most recently written bytes, up to a limit.  It grows to
+// at most twice the limit so that trimming it is amortized over many writes.
+type tailBuffer struct {
+	buf []byte
+}
+
+// write appends p to the buffer, keeping at least the last limit bytes.  A
+// limit of 0 or less discards everything.
+func (t *tailBuffer) write(p []byte, limit int) {
+	if limit <= 0 {
+		t.buf = nil
+
+		return
+	}
+
+	if len(p) >= limit {
+		t.buf = append(t.buf[:0], p[len(p)-limit:]...)
+
+		return
+	}
+
+	if len(t.buf)+len(p) > 2*limit {
+		keep := t.buf[len(t.buf)-(limit-len(p)):]
+		t.buf = t.buf[:copy(t.buf, keep)]
+	}
+
+	t.buf = append(t.buf, p...)
+}
+
+// tail returns up to the last n buffered bytes.
+func (t *tailBuffer) tail(n int) []byte {
+	if n > len(t.buf) {
+		n = len(t.buf)
+	}
+
+	return t.buf[len(t.buf)-n:]
+}
+
+// reset discards the buffered bytes.
+func (t *tailBuffer) reset() {
+	t.buf = t.buf[:0]
+}
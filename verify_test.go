@@ -0,0 +1,72 @@
+package lumberjack
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestVerifyTail(t *testing.T) {
+	currentTime = fakeTime
+
+	dir := makeTempDir(t, "TestVerifyTail")
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:        filename,
+		MaxBytes:        100,
+		VerifyTailBytes: 8,
+	}
+	defer l.Close()
+
+	notNil(t, l.VerifyTail(1))
+
+	_, err := l.Write([]byte("hello "))
+	isNil(t, err)
+	_, err = l.Write([]byte("world!"))
+	isNil(t, err)
+
+	isNil(t, l.VerifyTail(8))
+
+	// Only the last VerifyTailBytes bytes are kept.
+	notNil(t, l.VerifyTail(9))
+
+	// Corrupt the file behind the logger's back.
+	f, err := os.OpenFile(filename, os.O_WRONLY, 0)
+	isNil(t, err)
+	_, err = f.WriteAt([]byte("W"), 6)
+	isNil(t, err)
+	isNil(t, f.Close())
+
+	err = l.VerifyTail(8)
+	assert(t, errors.Is(err, ErrTailMismatch), "expected ErrTailMismatch, got %v", err)
+
+	// The bytes which are still intact verify fine.
+	isNil(t, l.VerifyTail(5))
+
+	// Rotation starts over with the new file.
+	newFakeTime()
+	isNil(t, l.Rotate())
+	notNil(t, l.VerifyTail(1))
+}
+
+func TestTailBuffer(t *testing.T) {
+	var tb tailBuffer
+
+	for i := 0; i < 100; i++ {
+		tb.write([]byte{byte(i)}, 10)
+		assert(t, len(tb.buf) <= 20, "buffer grew to %d bytes", len(tb.buf))
+	}
+
+	want := []byte{90, 91, 92, 93, 94, 95, 96, 97, 98, 99}
+	equals(t, want, tb.tail(10))
+	equals(t, want[7:], tb.tail(3))
+
+	tb.write(bytes.Repeat([]byte{1}, 15), 10)
+	equals(t, bytes.Repeat([]byte{1}, 10), tb.tail(20))
+
+	tb.reset()
+	equals(t, 0, len(tb.tail(5)))
+}
@@ -0,0 +1,141 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigCheckInterval is the interval at which ConfigWatcher checks
+// the config file if Interval is not set.
+const defaultConfigCheckInterval = 5 * time.Second
+
+// ConfigWatcher applies the settings in a config file to a Logger whenever
+// the file changes, so that size limits, retention and compression can be
+// tuned without restarting the service:
+//
+//	w := &lumberjack.ConfigWatcher{
+//		Path:          "/etc/myapp/logging.yaml",
+//		OnConfigError: func(err error) { log.Print(err) },
+//	}
+//	go w.Watch(ctx, l)
+//
+// The file holds a Config, in JSON, or in YAML or TOML if its name ends in
+// ".yaml", ".yml" or ".toml".  Settings which it doesn't contain keep the
+// value the Logger had when Watch was called, so a setting removed from the
+// file is reset.  The settings are applied by UpdateConfig.
+type ConfigWatcher struct {
+	// Path is the config file.
+	Path string
+
+	// Interval is the interval at which the file is checked for changes.
+	// The default is 5 seconds.
+	Interval time.Duration
+
+	// OnConfigError is called with the error if the file can't be read or
+	// contains invalid or unknown settings, in which case the Logger keeps
+	// its settings until the file changes again.  It is called from the
+	// goroutine running Watch.  The default is to ignore these errors.
+	OnConfigError func(err error)
+
+	// applied is the content of the file last applied or rejected.
+	applied []byte
+}
+
+// Watch applies the config file to l, and then again whenever it changes,
+// until ctx is canceled.  It returns ctx's error.
+func (w *ConfigWatcher) Watch(ctx context.Context, l *Logger) error {
+	l.mu.Lock()
+	base := l.config()
+	l.mu.Unlock()
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultConfigCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.check(l, base); err != nil && w.OnConfigError != nil {
+			w.OnConfigError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// check applies the config file to l on top of base if it changed since the
+// last check.
+func (w *ConfigWatcher) check(l *Logger, base Config) error {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		return fmt.Errorf("can't read config file: %v", err)
+	}
+
+	if w.applied != nil && bytes.Equal(data, w.applied) {
+		return nil
+	}
+
+	w.applied = data
+
+	cfg, err := decodeConfig(w.Path, data, base)
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %v", w.Path, err)
+	}
+
+	if err := l.UpdateConfig(cfg); err != nil {
+		return fmt.Errorf("can't apply config file %s: %v", w.Path, err)
+	}
+
+	return nil
+}
+
+// decodeConfig decodes the config file named name with the given content on
+// top of base, in the format given by its extension.  Unknown settings are an
+// error.
+func decodeConfig(name string, data []byte, base Config) (Config, error) {
+	cfg := base
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+
+		// An empty file leaves all settings at their base values.
+		if err := dec.Decode(&cfg); err != nil && len(bytes.TrimSpace(data)) > 0 {
+			return Config{}, err
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return Config{}, err
+		}
+
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return Config{}, fmt.Errorf("unknown setting %q", undecoded[0].String())
+		}
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,105 @@
+package lumberjack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	dir := makeTempDir(t, "TestConfigWatcher")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logging.yaml")
+	isNil(t, os.WriteFile(path, []byte("maxbytes: 10MB\nmaxbackups: 3\ncompress: true\n"), fileModeNew))
+
+	l := &Logger{Filename: logFile(dir), MaxAge: 7}
+	defer l.Close()
+
+	base := l.config()
+	w := &ConfigWatcher{Path: path}
+
+	isNil(t, w.check(l, base))
+	equals(t, int64(10*1024*1024), l.MaxBytes)
+	equals(t, 3, l.MaxBackups)
+	equals(t, true, l.Compress)
+	equals(t, 7, l.MaxAge)
+
+	// A setting removed from the file is reset.
+	isNil(t, os.WriteFile(path, []byte("maxbytes: 10MB\n"), fileModeNew))
+	isNil(t, w.check(l, base))
+	equals(t, 0, l.MaxBackups)
+	equals(t, false, l.Compress)
+
+	// Invalid settings are rejected once, and the Logger keeps its settings.
+	isNil(t, os.WriteFile(path, []byte("maxbytes: 1MB\nmaxbackups: -1\n"), fileModeNew))
+	notNil(t, w.check(l, base))
+	equals(t, int64(10*1024*1024), l.MaxBytes)
+	isNil(t, w.check(l, base))
+
+	isNil(t, os.WriteFile(path, []byte("maxbites: 1MB\n"), fileModeNew))
+	notNil(t, w.check(l, base))
+
+	isNil(t, os.Remove(path))
+	notNil(t, w.check(l, base))
+}
+
+func TestConfigWatcherFormats(t *testing.T) {
+	base := Config{Filename: "foo.log"}
+
+	for name, data := range map[string]string{
+		"logging.json": `{"maxbytes": "1KB", "maxbackups": 2}`,
+		"logging.yml":  "maxbytes: 1KB\nmaxbackups: 2\n",
+		"logging.toml": "maxbytes = \"1KB\"\nmaxbackups = 2\n",
+	} {
+		cfg, err := decodeConfig(name, []byte(data), base)
+		isNil(t, err)
+		equals(t, Config{Filename: "foo.log", MaxBytes: 1024, MaxBackups: 2}, cfg)
+	}
+}
+
+func TestConfigWatcherWatch(t *testing.T) {
+	dir := makeTempDir(t, "TestConfigWatcherWatch")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logging.json")
+	isNil(t, os.WriteFile(path, []byte(`{"maxbackups": -1}`), fileModeNew))
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+
+	errs := make(chan error, 1)
+	w := &ConfigWatcher{
+		Path:          path,
+		Interval:      time.Millisecond,
+		OnConfigError: func(err error) { errs <- err },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- w.Watch(ctx, l) }()
+
+	notNil(t, <-errs)
+	isNil(t, os.WriteFile(path, []byte(`{"maxbackups": 4}`), fileModeNew))
+
+	maxBackups := func() int {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		return l.MaxBackups
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); maxBackups() != 4; {
+		if time.Now().After(deadline) {
+			t.Fatal("the config file wasn't applied")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	equals(t, context.Canceled, <-done)
+}
@@ -0,0 +1,156 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+const xzSuffix = ".xz"
+
+// xzCommand is the command compressing backups for CompressionXz.
+var xzCommand = "xz"
+
+// checkXz reports an error if the xz command can't be found.
+func checkXz() error {
+	if _, err := exec.LookPath(xzCommand); err != nil {
+		return fmt.Errorf("CompressionXz requires the xz command: %v", err)
+	}
+
+	return nil
+}
+
+// newXzWriter returns a writer compressing into w with the xz command, which
+// runs until the writer is closed.
+func newXzWriter(w io.Writer) (io.WriteCloser, error) {
+	cmd := exec.Command(xzCommand, "--compress", "--stdout", "--quiet")
+	cmd.Stdout = w
+
+	x := &xzWriter{cmd: cmd}
+	cmd.Stderr = &x.stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("can't start xz: %v", err)
+	}
+
+	x.stdin = stdin
+
+	return x, nil
+}
+
+// xzWriter feeds the data written to it to an xz process.
+type xzWriter struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+	closed bool
+}
+
+func (x *xzWriter) Write(p []byte) (int, error) {
+	n, err := x.stdin.Write(p)
+	if err != nil {
+		// The process exited early, so its error tells more.
+		if errWait := x.Close(); errWait != nil {
+			return n, errWait
+		}
+	}
+
+	return n, err
+}
+
+// Close waits for the xz process to write the rest of its output.
+func (x *xzWriter) Close() error {
+	if x.closed {
+		return nil
+	}
+
+	x.closed = true
+	x.stdin.Close()
+
+	if err := x.cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(x.stderr.String()); msg != "" {
+			return fmt.Errorf("xz: %v: %s", err, msg)
+		}
+
+		return fmt.Errorf("xz: %v", err)
+	}
+
+	return nil
+}
+
+// newXzReader returns a reader decompressing the xz stream read from r with
+// the xz command.
+func newXzReader(r io.Reader) (io.ReadCloser, error) {
+	cmd := exec.Command(xzCommand, "--decompress", "--stdout", "--quiet")
+	cmd.Stdin = r
+
+	x := &xzReader{cmd: cmd}
+	cmd.Stderr = &x.stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("can't start xz: %v", err)
+	}
+
+	x.stdout = stdout
+
+	return x, nil
+}
+
+// xzReader reads the output of an xz process decompressing a stream.
+type xzReader struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+	waited bool
+}
+
+// Read returns the error of the xz process once its output is read, so that a
+// corrupt stream isn't mistaken for its end.
+func (x *xzReader) Read(p []byte) (int, error) {
+	n, err := x.stdout.Read(p)
+	if err == io.EOF {
+		if errWait := x.wait(); errWait != nil {
+			return n, errWait
+		}
+	}
+
+	return n, err
+}
+
+// Close stops reading, which ends the xz process if it hasn't finished yet.
+func (x *xzReader) Close() error {
+	if x.waited {
+		return nil
+	}
+
+	x.stdout.Close()
+	x.wait()
+
+	return nil
+}
+
+func (x *xzReader) wait() error {
+	x.waited = true
+
+	if err := x.cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(x.stderr.String()); msg != "" {
+			return fmt.Errorf("xz: %v: %s", err, msg)
+		}
+
+		return fmt.Errorf("xz: %v", err)
+	}
+
+	return nil
+}
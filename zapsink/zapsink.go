@@ -0,0 +1,162 @@
+// Package zapsink registers lumberjack as a zap sink, so that the OutputPaths
+// and ErrorOutputPaths of a zap.Config can point directly at a rotating log
+// file:
+//
+//	if err := zapsink.Register(); err != nil {
+//		panic(err)
+//	}
+//
+//	cfg := zap.NewProductionConfig()
+//	cfg.OutputPaths = []string{
+//		"lumberjack:///var/log/myapp/foo.log?maxbytes=100MiB&maxbackups=3&compress=true",
+//	}
+//
+// The path of the URL is the Filename of the Logger.  The query parameters
+// configure the Logger fields of the same name, in lowercase:
+//
+//	maxbytes           MaxBytes, as accepted by lumberjack.ParseSize
+//	maxbackups         MaxBackups
+//	maxtotalbytes      MaxTotalBytes, as accepted by lumberjack.ParseSize
+//	maxage             MaxAge, in days
+//	compress           Compress, as accepted by strconv.ParseBool
+//	compressionformat  CompressionFormat ("gzip", "zstd", "xz")
+//	localtime          LocalTime, as accepted by strconv.ParseBool
+//	location           Location, as a zone name ("UTC", "Asia/Shanghai")
+//	backupdir          BackupDir
+package zapsink
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/saucelabs/lumberjack/v3"
+	"go.uber.org/zap"
+)
+
+// Scheme is the URL scheme registered by Register.
+const Scheme = "lumberjack"
+
+var (
+	registerOnce sync.Once
+	errRegister  error
+)
+
+// Register registers the "lumberjack" URL scheme with zap.  It is safe to call
+// more than once.
+func Register() error {
+	registerOnce.Do(func() {
+		errRegister = zap.RegisterSink(Scheme, func(u *url.URL) (zap.Sink, error) {
+			return NewLogger(u)
+		})
+	})
+
+	return errRegister
+}
+
+// NewLogger returns the Logger configured by a "lumberjack" URL, as described
+// in the package documentation.
+func NewLogger(u *url.URL) (*lumberjack.Logger, error) {
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	if u.Host != "" {
+		return nil, fmt.Errorf("unexpected host %q in %s URL, use %s:///path", u.Host, Scheme, Scheme)
+	}
+
+	if u.Path == "" {
+		return nil, errors.New("missing log file path")
+	}
+
+	l := &lumberjack.Logger{Filename: u.Path}
+	q := query{values: u.Query()}
+
+	for name := range q.values {
+		switch name {
+		case "maxbytes":
+			l.MaxBytes = q.size(name)
+		case "maxbackups":
+			l.MaxBackups = q.int(name)
+		case "maxtotalbytes":
+			l.MaxTotalBytes = q.size(name)
+		case "maxage":
+			l.MaxAge = q.int(name)
+		case "compress":
+			l.Compress = q.bool(name)
+		case "compressionformat":
+			l.CompressionFormat = lumberjack.CompressionFormat(q.values.Get(name))
+		case "localtime":
+			l.LocalTime = q.bool(name)
+		case "location":
+			l.Location = q.location(name)
+		case "backupdir":
+			l.BackupDir = q.values.Get(name)
+		default:
+			q.fail(name, errors.New("unknown parameter"))
+		}
+	}
+
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	return l, nil
+}
+
+// query reads URL query parameters, remembering the first error so that
+// NewLogger can check it once.
+type query struct {
+	values url.Values
+	err    error
+}
+
+// fail records err for name unless an earlier error was already recorded.
+func (q *query) fail(name string, err error) {
+	if q.err == nil {
+		q.err = fmt.Errorf("invalid %s: %v", name, err)
+	}
+}
+
+func (q *query) int(name string) int {
+	n, err := strconv.Atoi(q.values.Get(name))
+	if err == nil && n < 0 {
+		err = errors.New("must not be negative")
+	}
+
+	if err != nil {
+		q.fail(name, err)
+	}
+
+	return n
+}
+
+func (q *query) size(name string) int64 {
+	n, err := lumberjack.ParseSize(q.values.Get(name))
+	if err != nil {
+		q.fail(name, err)
+	}
+
+	return n
+}
+
+func (q *query) location(name string) *time.Location {
+	loc, err := time.LoadLocation(q.values.Get(name))
+	if err != nil {
+		q.fail(name, err)
+	}
+
+	return loc
+}
+
+func (q *query) bool(name string) bool {
+	b, err := strconv.ParseBool(q.values.Get(name))
+	if err != nil {
+		q.fail(name, err)
+	}
+
+	return b
+}
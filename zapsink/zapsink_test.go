@@ -0,0 +1,104 @@
+package zapsink
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/saucelabs/lumberjack/v3"
+	"go.uber.org/zap"
+)
+
+func TestNewLogger(t *testing.T) {
+	u, err := url.Parse("lumberjack:///var/log/foo.log?maxbytes=10MiB&maxbackups=3&maxtotalbytes=1GiB" +
+		"&maxage=7&compress=true&compressionformat=zstd&localtime=1&backupdir=archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewLogger(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := lumberjack.Logger{
+		Filename:          "/var/log/foo.log",
+		MaxBytes:          10 << 20,
+		MaxBackups:        3,
+		MaxTotalBytes:     1 << 30,
+		MaxAge:            7,
+		Compress:          true,
+		CompressionFormat: lumberjack.CompressionZstd,
+		LocalTime:         true,
+		BackupDir:         "archive",
+	}
+
+	if !reflect.DeepEqual(l, &want) {
+		t.Fatalf("got %+v, want %+v", l, &want)
+	}
+}
+
+func TestNewLoggerInvalid(t *testing.T) {
+	tests := map[string]string{
+		"lumberjack://var/log/foo.log":                   `unexpected host "var"`,
+		"lumberjack:?maxbytes=1KiB":                      "missing log file path",
+		"lumberjack:///foo.log?maxbytes=lots":            "invalid maxbytes",
+		"lumberjack:///foo.log?maxbackups=-1":            "invalid maxbackups: must not be negative",
+		"lumberjack:///foo.log?compress=maybe":           "invalid compress",
+		"lumberjack:///foo.log?maxsize=1":                "invalid maxsize: unknown parameter",
+		"file:///foo.log":                                `unsupported scheme "file"`,
+		"lumberjack:///foo.log?maxage=7&maxbackups=many": "invalid maxbackups",
+	}
+
+	for raw, want := range tests {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewLogger(u)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got error %v, want %q", raw, err, want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "foo.log")
+
+	if err := Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Registering again is harmless.
+	if err := Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := zap.NewProductionConfig()
+	cfg.OutputPaths = []string{Scheme + "://" + filename + "?maxbytes=1KiB"}
+
+	log, err := cfg.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.Info("boo!")
+
+	if err := log.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"msg":"boo!"`) {
+		t.Fatalf("unexpected log file content %q", b)
+	}
+}